@@ -9,14 +9,32 @@ import (
 	"github.com/usa4ev/ghostorange/internal/app/adapter"
 	"github.com/usa4ev/ghostorange/internal/app/tui"
 	"github.com/usa4ev/ghostorange/internal/app/tui/clconfig"
+	"github.com/usa4ev/ghostorange/internal/app/tui/clsession"
+	"github.com/usa4ev/ghostorange/internal/app/tui/pages"
 )
 
+// profiles switches the client between profiles of config file.
+type profiles struct {
+	cfg    *clconfig.Config
+	logger *zap.SugaredLogger
+}
+
 func main() {
-	cfg := clconfig.New()
+	cfg, err := clconfig.New()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	lgcfg := zap.NewDevelopmentConfig()
 	lgcfg.OutputPaths[0] = cfg.LogPath()
 
+	level, err := zap.ParseAtomicLevel(cfg.LogLevel())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lgcfg.Level = level
+
 	logger, _ := lgcfg.Build(zap.AddCaller())
 	defer logger.Sync()
 
@@ -27,9 +45,38 @@ func main() {
 		log.Fatal(fmt.Errorf("failed to create provider: %v", err.Error()))
 	}
 
-	app := tui.New(adapter, sugar)
+	sessions := clsession.New(cfg.CacheDir(), cfg.SrvAddr())
+
+	app := tui.New(adapter, sessions, &profiles{cfg: cfg, logger: sugar},
+		cfg.ClipboardTimeout(), cfg.LockTimeout(), sugar)
 
 	if err := app.Run(); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func (p *profiles) Profiles() []string {
+	return p.cfg.Profiles()
+}
+
+func (p *profiles) Profile() string {
+	return p.cfg.Profile()
+}
+
+// Switch returns adapter and session store of profile name.
+// Log settings of the profile started with are kept.
+func (p *profiles) Switch(name string) (adapter.Adapter, pages.SessionStore, error) {
+	cfg, err := p.cfg.SwitchProfile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := adapter.New(cfg, p.logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	p.cfg = cfg
+
+	return a, clsession.New(cfg.CacheDir(), cfg.SrvAddr()), nil
+}
@@ -1,7 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
 
 	"github.com/usa4ev/ghostorange/internal/app/server"
 	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
@@ -9,14 +17,99 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrate(os.Args[2:]))
+	}
+
 	cfg := srvconfig.New()
 
-	strg, err := storage.New(cfg)
+	logger, err := newLogger(cfg)
+	if err != nil {
+		log.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+
+	strg, err := storage.New(cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to create storage", zap.Error(err))
+	}
+
+	srv := server.New(cfg, strg, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Run()
+	}()
+
+	// exit code, non-zero when the server failed
+	code := 0
+
+	select {
+	case err := <-errCh:
+		// Server failed to start or stopped unexpectedly
+		logger.Error("server stopped", zap.Error(err))
+
+		if !errors.Is(err, http.ErrServerClosed) {
+			code = 1
+		}
+	case <-ctx.Done():
+		// Restore default behaviour so the second signal kills the process
+		stop()
+
+		logger.Info("shutdown signal received")
+
+		shutdown(cfg, srv, logger)
+
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("server stopped", zap.Error(err))
+
+			code = 1
+		}
+	}
+
+	if err := strg.Close(); err != nil {
+		logger.Error("failed to close storage", zap.Error(err))
+	}
+
+	logger.Info("server stopped")
+
+	if code != 0 {
+		// deferred calls are skipped by os.Exit
+		stop()
+		logger.Sync()
+		os.Exit(code)
+	}
+}
+
+// shutdown gracefully shuts srv down giving in-flight
+// requests configured time to complete.
+func shutdown(cfg *srvconfig.Config, srv *server.Server, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		cfg.DrainDelay()+cfg.ShutdownTimeout())
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("graceful shutdown failed", zap.Error(err))
+	}
+}
+
+func newLogger(cfg *srvconfig.Config) (*zap.Logger, error) {
+	level, err := zap.ParseAtomicLevel(cfg.LogLevel())
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	srv := server.New(cfg, strg)
+	lgcfg := zap.NewProductionConfig()
+	lgcfg.Level = level
+	lgcfg.Encoding = cfg.LogFormat()
+	lgcfg.OutputPaths = []string{cfg.LogOutput()}
+
+	if cfg.LogFormat() == "console" {
+		lgcfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	}
 
-	log.Fatal(srv.Run())
+	return lgcfg.Build()
 }
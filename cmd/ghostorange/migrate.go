@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	"github.com/usa4ev/ghostorange/internal/app/storage"
+)
+
+const migrateUsage = `usage: ghostorange migrate up|down|status [flags]
+
+  up      apply all pending migrations
+  down    roll back the latest applied migration
+  status  list migrations and whether they are applied
+
+Flags are the same as the server's, e.g. -d or -c.`
+
+// migrate runs migrate subcommand with args and returns exit code.
+func migrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+
+		return 2
+	}
+
+	cmd := args[0]
+	cfg := srvconfig.New(srvconfig.WithOsArgs(args[1:]))
+
+	m, err := storage.NewMigrator(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open storage: %v\n", err)
+
+		return 1
+	}
+	defer m.Close()
+
+	ctx := context.Background()
+
+	switch cmd {
+	case "up":
+		applied, err := m.MigrateUp(ctx)
+		for _, v := range applied {
+			fmt.Printf("applied migration %v\n", v)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+
+			return 1
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		version, err := m.MigrateDown(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+
+			return 1
+		}
+
+		fmt.Printf("rolled back migration %v\n", version)
+	case "status":
+		statuses, err := m.MigrationStatus(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get migrations status: %v\n", err)
+
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+		for _, st := range statuses {
+			status, appliedAt := "pending", ""
+
+			if st.Applied {
+				status = "applied"
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			if st.Unknown {
+				status = "unknown"
+			}
+
+			fmt.Fprintf(w, "%04d\t%v\t%v\t%v\n", st.Version, st.Name, status, appliedAt)
+		}
+
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/adapter"
+	"github.com/usa4ev/ghostorange/internal/app/cli"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/tui/clconfig"
+	"github.com/usa4ev/ghostorange/internal/app/tui/clsession"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	cfg, err := clconfig.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goctl: %v\n", err)
+		return cli.ExitUsage
+	}
+
+	logger := zap.NewNop()
+
+	if cfg.LogPath() != "" {
+		lgcfg := zap.NewDevelopmentConfig()
+		lgcfg.OutputPaths = []string{cfg.LogPath()}
+		lgcfg.ErrorOutputPaths = []string{cfg.LogPath()}
+
+		if level, err := zap.ParseAtomicLevel(cfg.LogLevel()); err == nil {
+			lgcfg.Level = level
+		}
+
+		if l, err := lgcfg.Build(zap.AddCaller()); err == nil {
+			logger = l
+		}
+	}
+
+	defer logger.Sync()
+
+	a, err := adapter.New(cfg, logger.Sugar())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goctl: failed to create provider: %v\n", err)
+		return cli.ExitError
+	}
+
+	cred := model.Credentials{
+		Login:    os.Getenv(cli.EnvLogin),
+		Password: os.Getenv(cli.EnvPassword),
+	}
+
+	sessions := clsession.New(cfg.CacheDir(), cfg.SrvAddr())
+
+	return cli.New(a, cred, sessions, os.Stdin, os.Stdout, os.Stderr).Run(cfg.Args())
+}
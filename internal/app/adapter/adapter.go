@@ -1,31 +1,94 @@
 package adapter
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+
 	"go.uber.org/zap"
 
+	"github.com/usa4ev/ghostorange/internal/app/adapter/grpcp"
 	"github.com/usa4ev/ghostorange/internal/app/adapter/httpp"
+	"github.com/usa4ev/ghostorange/internal/app/adapter/offline"
 	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/tui/clconfig"
 )
 
 type (
 	config interface {
 		SrvAddr() string
+		CacheDir() string
+		Transport() string
+		TLS() *tls.Config
 	}
 	Adapter interface {
 		Login(model.Credentials) error
 		Register(model.Credentials) error
 
+		// Session returns the current session to be saved,
+		// Resume resumes saved session without password.
+		Session() (model.Session, error)
+		Resume(model.Session) error
+		Logout()
+		// Lock hides user's data until Unlock is called
+		// with the password, the session is kept.
+		Lock()
+		Unlock(password string) error
+		// Close releases local cache and connection to the server
+		Close() error
+
 		Count(dataType int) (string, error)
+		Usage() (model.Usage, error)
 
 		GetData(dataType int) (any, error)
 		AddData(dataType int, data any) error
 		UpdateData(dataType int, data any) error
+		DeleteData(dataType int, id string) error
+		// Batch applies create, update and delete ops in one go
+		// and returns their errors in the order of ops.
+		Batch(ops []model.BatchOp) ([]error, error)
 		GetCard(id, cvvHash string) (model.ItemCard, error)
+		// Export writes all user's items to w as an archive
+		// encrypted with passphrase, see backup package.
+		// The archive holds full card numbers, so user's
+		// credentials are asked again.
+		Export(w io.Writer, cred model.Credentials, passphrase string) error
+
+		Sync() error
+		Status() model.SyncStatus
+		Conflicts() ([]model.Conflict, error)
+		ResolveConflict(id int64, keepLocal bool) error
+
+		// Events streams changes made on the server
+		// until ctx is done or the stream breaks.
+		Events(ctx context.Context) (<-chan model.Change, error)
 
 		Lg() *zap.SugaredLogger
 	}
 )
 
+// New returns http or gRPC adapter, as configured,
+// wrapped with local cache, so the client keeps working
+// while the server is unreachable.
 func New(cfg config, logger *zap.SugaredLogger) (Adapter, error) {
-	return httpp.New(cfg, logger)
+	var (
+		prov offline.Remote
+		err  error
+	)
+
+	switch cfg.Transport() {
+	case clconfig.TransportGRPC:
+		prov, err = grpcp.New(cfg, logger)
+	case clconfig.TransportHTTP:
+		prov, err = httpp.New(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unsupported transport %q", cfg.Transport())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return offline.New(prov, cfg, logger), nil
 }
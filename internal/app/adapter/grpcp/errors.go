@@ -0,0 +1,61 @@
+package grpcp
+
+import (
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+)
+
+// callError turns an error status into *model.Error,
+// so callers can branch on it with errors.Is the same way
+// they do with http adapter errors. Statuses that tell
+// the server could not be reached are returned as is.
+func callError(call string, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%v call failed: %w", call, err)
+	}
+
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == apierr.ErrorDomain {
+			return &model.Error{
+				Code:    info.GetReason(),
+				Message: st.Message(),
+				Details: info.GetMetadata()["details"],
+			}
+		}
+	}
+
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return fmt.Errorf("%v call failed: %w", call, err)
+	}
+
+	return &model.Error{
+		Code:    statusCode(st.Code()),
+		Message: st.Code().String(),
+		Details: st.Message(),
+	}
+}
+
+func statusCode(code codes.Code) string {
+	switch code {
+	case codes.InvalidArgument:
+		return model.ErrCodeBadRequest
+	case codes.Unauthenticated:
+		return model.ErrCodeInvalidSession
+	case codes.NotFound:
+		return model.ErrCodeNotFound
+	case codes.AlreadyExists:
+		return model.ErrCodeUserExists
+	case codes.ResourceExhausted:
+		return model.ErrCodeTooLarge
+	}
+
+	return model.ErrCodeInternal
+}
@@ -0,0 +1,349 @@
+// Package grpcp provides an adapter that talks to the server
+// over gRPC API. It is interchangeable with httpp.
+package grpcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/pb"
+	"github.com/usa4ev/ghostorange/internal/app/server"
+)
+
+type (
+	Provider struct {
+		conn   *grpc.ClientConn
+		client pb.GhostOrangeClient
+		cfg    config
+		logger *zap.SugaredLogger
+
+		mu      sync.Mutex
+		session model.Session
+	}
+	config interface {
+		SrvAddr() string
+		TLS() *tls.Config
+	}
+)
+
+// New returns Provider that calls gRPC API on configured address.
+// Connection is established on the first call.
+func New(cfg config, logger *zap.SugaredLogger) (*Provider, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS() != nil {
+		creds = credentials.NewTLS(cfg.TLS())
+	}
+
+	conn, err := grpc.Dial(cfg.SrvAddr(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial gRPC server: %w", err)
+	}
+
+	return &Provider{
+			conn:   conn,
+			client: pb.NewGhostOrangeClient(conn),
+			cfg:    cfg,
+			logger: logger},
+		nil
+}
+
+// Close closes connection to the server.
+func (prov *Provider) Close() error {
+	return prov.conn.Close()
+}
+
+func (prov *Provider) Register(item model.Credentials) error {
+	s, err := prov.client.Register(context.Background(),
+		&pb.Credentials{Login: item.Login, Password: item.Password})
+	if err != nil {
+		return callError("Register", err)
+	}
+
+	prov.setSession(s)
+
+	return nil
+}
+
+func (prov *Provider) Login(item model.Credentials) error {
+	s, err := prov.client.Login(context.Background(),
+		&pb.Credentials{Login: item.Login, Password: item.Password})
+	if err != nil {
+		return callError("Login", err)
+	}
+
+	prov.setSession(s)
+
+	return nil
+}
+
+func (prov *Provider) Count(dataType int) (string, error) {
+	res, err := prov.client.Count(prov.ctx(context.Background()),
+		&pb.CountRequest{DataType: pb.DataType(dataType)})
+	if err != nil {
+		return "", callError("Count", err)
+	}
+
+	return strconv.FormatInt(res.GetCount(), 10), nil
+}
+
+func (prov *Provider) Usage() (model.Usage, error) {
+	res, err := prov.client.GetUsage(prov.ctx(context.Background()), &emptypb.Empty{})
+	if err != nil {
+		return model.Usage{}, callError("Usage", err)
+	}
+
+	return res.Model(), nil
+}
+
+func (prov *Provider) GetData(dataType int) (any, error) {
+	res, err := prov.client.GetData(prov.ctx(context.Background()),
+		&pb.GetDataRequest{DataType: pb.DataType(dataType)})
+	if err != nil {
+		return nil, callError("GetData", err)
+	}
+
+	obj, err := res.Model(dataType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode server message: %w", err)
+	}
+
+	return obj, nil
+}
+
+func (prov *Provider) AddData(dataType int, data any) error {
+	item, err := pb.NewItem(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+
+	if _, err := prov.client.AddData(prov.ctx(context.Background()), item); err != nil {
+		return callError("AddData", err)
+	}
+
+	return nil
+}
+
+func (prov *Provider) UpdateData(dataType int, data any) error {
+	item, err := pb.NewItem(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+
+	if _, err := prov.client.UpdateData(prov.ctx(context.Background()), item); err != nil {
+		return callError("UpdateData", err)
+	}
+
+	return nil
+}
+
+func (prov *Provider) DeleteData(dataType int, id string) error {
+	_, err := prov.client.DeleteData(prov.ctx(context.Background()),
+		&pb.DeleteDataRequest{DataType: pb.DataType(dataType), Id: id})
+	if err != nil {
+		return callError("DeleteData", err)
+	}
+
+	return nil
+}
+
+// Batch has no gRPC counterpart, so ops are applied one call
+// at a time. Unlike http batch, it is not atomic: if the server
+// cannot be reached or the session is not valid, ops applied
+// before stay applied and the error is returned.
+func (prov *Provider) Batch(ops []model.BatchOp) ([]model.BatchResult, error) {
+	res := make([]model.BatchResult, len(ops))
+
+	for i, op := range ops {
+		if err := op.Validate(); err != nil {
+			return nil, &model.Error{
+				Code:    model.ErrCodeBadRequest,
+				Message: fmt.Sprintf("bad operation %v", i),
+				Details: err.Error(),
+			}
+		}
+
+		if op.Op == model.BatchCreate && op.ItemID() == "" {
+			op = op.WithItemID(uuid.NewString())
+		}
+
+		res[i].ID = op.ItemID()
+
+		var err error
+
+		switch op.Op {
+		case model.BatchCreate:
+			err = prov.AddData(op.DataType, op.Item)
+		case model.BatchUpdate:
+			err = prov.UpdateData(op.DataType, op.Item)
+		case model.BatchDelete:
+			err = prov.DeleteData(op.DataType, op.ID)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		var e *model.Error
+		if !errors.As(err, &e) || !opError(e) {
+			return nil, err
+		}
+
+		res[i].Error = e
+	}
+
+	return res, nil
+}
+
+// opError tells if e refuses a single operation rather
+// than the whole batch.
+func opError(e *model.Error) bool {
+	switch e.Code {
+	case model.ErrCodeBadRequest, model.ErrCodeNotFound,
+		model.ErrCodeTooLarge, model.ErrCodeQuotaExceeded:
+		return true
+	}
+
+	return false
+}
+
+// Changes returns a page of changes made after the change
+// cursor points to. Empty cursor requests all items.
+func (prov *Provider) Changes(cursor string) (model.Changes, error) {
+	res, err := prov.client.Sync(prov.ctx(context.Background()),
+		&pb.SyncRequest{Since: cursor})
+	if err != nil {
+		return model.Changes{}, callError("Sync", err)
+	}
+
+	changes, err := res.Model()
+	if err != nil {
+		return changes, fmt.Errorf("failed to decode server message: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (prov *Provider) GetCard(id, cvv string) (model.ItemCard, error) {
+	res, err := prov.client.RevealCard(prov.ctx(context.Background()),
+		&pb.RevealCardRequest{Id: id, Cvv: cvv})
+	if err != nil {
+		return model.ItemCard{}, callError("RevealCard", err)
+	}
+
+	return res.Model(), nil
+}
+
+// Export is not served over gRPC, archives are streamed
+// by http endpoint only.
+func (prov *Provider) Export(w io.Writer, cred model.Credentials, passphrase string) error {
+	return &model.Error{
+		Code:    model.ErrCodeBadRequest,
+		Message: "export is not supported over gRPC",
+		Details: "switch the profile to http transport",
+	}
+}
+
+// Events opens a stream of changes of user's items.
+// The channel is closed when the stream ends or ctx is done,
+// changes might have been missed since then.
+func (prov *Provider) Events(ctx context.Context) (<-chan model.Change, error) {
+	stream, err := prov.client.Events(prov.ctx(ctx), &emptypb.Empty{})
+	if err != nil {
+		return nil, callError("Events", err)
+	}
+
+	// the server sends headers once subscribed, a call
+	// failed before that gets trailers only
+	md, err := stream.Header()
+	if err == nil && md == nil {
+		_, err = stream.Recv()
+	}
+
+	if err != nil {
+		return nil, callError("Events", err)
+	}
+
+	events := make(chan model.Change)
+
+	go func() {
+		defer close(events)
+
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+
+			c, err := msg.Model()
+			if err != nil {
+				continue
+			}
+
+			select {
+			case events <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func (prov *Provider) Lg() *zap.SugaredLogger {
+	return prov.logger
+}
+
+// Session returns the session opened by the last login.
+func (prov *Provider) Session() model.Session {
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+
+	return prov.session
+}
+
+// Resume makes the provider use a saved session.
+// Zero session drops the current one.
+func (prov *Provider) Resume(s model.Session) {
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+
+	prov.session = model.Session{Token: s.Token, ExpiresAt: s.ExpiresAt}
+}
+
+func (prov *Provider) setSession(s *pb.Session) {
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+
+	prov.session = model.Session{Token: s.GetToken()}
+	if s.GetExpiresAt() != nil {
+		prov.session.ExpiresAt = s.GetExpiresAt().AsTime()
+	}
+}
+
+// ctx returns parent with session token attached.
+func (prov *Provider) ctx(parent context.Context) context.Context {
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+
+	if prov.session.Token == "" {
+		return parent
+	}
+
+	return metadata.AppendToOutgoingContext(parent,
+		server.MDAuthorization, "Bearer "+prov.session.Token)
+}
@@ -0,0 +1,318 @@
+package grpcp
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server"
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	"github.com/usa4ev/ghostorange/internal/app/storage"
+	mockstorage "github.com/usa4ev/ghostorange/internal/app/storage/mock"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+	"github.com/usa4ev/ghostorange/internal/app/tui/clconfig"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+const grpcAddr = "localhost:8082"
+
+func TestProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strg := mockstorage.NewMockStorage(ctrl)
+
+	srv := testSrv(strg)
+	go srv.Run()
+	time.Sleep(time.Second)
+	defer srv.Shutdown(context.Background())
+
+	cfg, err := clconfig.New(clconfig.WithAddress(grpcAddr), clconfig.WithOsArgs(nil))
+	require.NoError(t, err)
+
+	prov, err := New(cfg, nil)
+	require.NoError(t, err)
+	defer prov.Close()
+
+	t.Run("No session", func(t *testing.T) {
+		_, err := prov.GetData(model.KeyCredentials)
+		assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+		_, err = prov.Events(context.Background())
+		assert.ErrorIs(t, err, model.ErrInvalidSession)
+	})
+
+	strg.EXPECT().
+		AddUser(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("user_id", nil)
+
+	strg.EXPECT().
+		UserExists(gomock.Any(), gomock.Any()).
+		Return(false, nil)
+
+	err = prov.Register(model.Credentials{Login: "test", Password: "test"})
+	require.NoError(t, err)
+
+	t.Run("Get Credentials", func(t *testing.T) {
+		tt := []model.ItemCredentials{
+			{ID: "id",
+				Credentials: model.Credentials{
+					Login:    "login",
+					Password: "password",
+				},
+				Name:    "case 1",
+				Comment: "lucky green",
+			},
+		}
+
+		strg.EXPECT().
+			GetData(gomock.Any(), model.KeyCredentials).
+			Return(tt, nil)
+
+		res, err := prov.GetData(model.KeyCredentials)
+		require.NoError(t, err)
+
+		v, ok := res.([]model.ItemCredentials)
+		assert.True(t, ok)
+
+		assert.Equal(t, tt, v)
+	})
+
+	t.Run("Add Credentials", func(t *testing.T) {
+		tt := model.ItemCredentials{
+			ID: "id",
+			Credentials: model.Credentials{
+				Login:    "login",
+				Password: "password",
+			},
+			Name:    "case 1",
+			Comment: "lucky green",
+		}
+
+		strg.EXPECT().
+			AddData(gomock.Any(), model.KeyCredentials, "user_id", tt).
+			Return(nil)
+
+		err := prov.AddData(model.KeyCredentials, tt)
+		require.NoError(t, err)
+	})
+
+	t.Run("Update quota exceeded", func(t *testing.T) {
+		tt := model.ItemText{ID: "id", Text: "text", Name: "case 1"}
+
+		strg.EXPECT().
+			AddData(gomock.Any(), model.KeyText, "user_id", tt).
+			Return(strgerrors.ErrQuotaExceeded)
+
+		err := prov.UpdateData(model.KeyText, tt)
+		assert.ErrorIs(t, err, model.ErrQuotaExceeded)
+	})
+
+	t.Run("Add too large", func(t *testing.T) {
+		tt := model.ItemText{
+			Text: strings.Repeat("a", 2048),
+			Name: "case 1",
+		}
+
+		err := prov.AddData(model.KeyText, tt)
+		assert.ErrorIs(t, err, model.ErrTooLarge)
+	})
+
+	t.Run("Login wrong password", func(t *testing.T) {
+		strg.EXPECT().
+			GetPasswordHash(gomock.Any(), "test").
+			Return("", "", nil)
+
+		err := prov.Login(model.Credentials{Login: "test", Password: "wrong"})
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("Get Card not found", func(t *testing.T) {
+		strg.EXPECT().
+			GetCardInfo(gomock.Any(), "missing", "user_id").
+			Return(model.ItemCard{}, strgerrors.ErrNotFound)
+
+		_, err := prov.GetCard("missing", "123")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		tt := model.Usage{
+			Entries: []model.UsageEntry{
+				{DataType: model.KeyCredentials, Count: 2, Bytes: 100},
+				{DataType: model.KeyText, Count: 1, Bytes: 50},
+			},
+			TotalBytes: 150,
+			ItemsLimit: 10,
+			BytesLimit: 1000,
+		}
+
+		strg.EXPECT().
+			Usage(gomock.Any(), "user_id").
+			Return(tt, nil)
+
+		res, err := prov.Usage()
+		require.NoError(t, err)
+
+		assert.Equal(t, tt, res)
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		tt := 100
+
+		strg.EXPECT().
+			Count(gomock.Any(), model.KeyCredentials, "user_id").
+			Return(tt, nil)
+
+		res, err := prov.Count(model.KeyCredentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, strconv.Itoa(tt), res)
+
+		_, err = prov.Count(model.KeyLimit)
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		strg.EXPECT().
+			DeleteData(gomock.Any(), model.KeyText, "user_id", "id").
+			Return(nil)
+
+		require.NoError(t, prov.DeleteData(model.KeyText, "id"))
+
+		strg.EXPECT().
+			DeleteData(gomock.Any(), model.KeyText, "user_id", "missing").
+			Return(strgerrors.ErrNotFound)
+
+		err := prov.DeleteData(model.KeyText, "missing")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		tt := model.ItemText{ID: "id", Text: "text", Name: "note"}
+
+		strg.EXPECT().
+			AddData(gomock.Any(), model.KeyText, "user_id", tt).
+			Return(nil)
+		strg.EXPECT().
+			DeleteData(gomock.Any(), model.KeyCards, "user_id", "missing").
+			Return(strgerrors.ErrNotFound)
+
+		res, err := prov.Batch([]model.BatchOp{
+			{Op: model.BatchUpdate, DataType: model.KeyText, Item: tt},
+			{Op: model.BatchDelete, DataType: model.KeyCards, ID: "missing"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+
+		assert.Equal(t, "id", res[0].ID)
+		assert.NoError(t, res[0].Err())
+		assert.ErrorIs(t, res[1].Err(), model.ErrNotFound)
+
+		_, err = prov.Batch([]model.BatchOp{{Op: model.BatchDelete, DataType: model.KeyText}})
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		ts := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+		tt := []model.Change{
+			{Seq: 3, DataType: model.KeyText, ID: "text", Kind: model.ChangeUpdated, ChangedAt: ts,
+				Item: model.ItemText{ID: "text", Text: "text"}},
+			{Seq: 5, DataType: model.KeyCards, ID: "card", Kind: model.ChangeDeleted, ChangedAt: ts},
+			{Seq: 6, DataType: model.KeyBinary, ID: "bin", Kind: model.ChangeCreated, ChangedAt: ts,
+				Item: model.ItemBinary{ID: "bin"}},
+		}
+
+		// the server asks for an extra change to tell if there are more pages
+		strg.EXPECT().
+			Changes(gomock.Any(), "user_id", int64(2), 501).
+			Return(tt, nil)
+
+		res, err := prov.Changes("2")
+		require.NoError(t, err)
+
+		assert.Equal(t, model.Changes{Changes: tt, Cursor: "6"}, res)
+
+		_, err = prov.Changes("bad")
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		changes := make(chan model.Change, 1)
+		tt := model.Change{Seq: 7, DataType: model.KeyText, ID: "id", Kind: model.ChangeDeleted,
+			ChangedAt: time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+		strg.EXPECT().
+			Subscribe(gomock.Any(), "user_id").
+			Return((<-chan model.Change)(changes), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := prov.Events(ctx)
+		require.NoError(t, err)
+
+		changes <- tt
+		assert.Equal(t, tt, <-events)
+
+		// the stream ends with the subscription
+		close(changes)
+
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+
+	t.Run("Get Card", func(t *testing.T) {
+		cvv := "123"
+		cvvHash, err := argon2hash.GenerateFromPassword(cvv, argon2hash.DefaultParams())
+		require.NoError(t, err)
+
+		tt := model.ItemCard{
+			ID:                 "id",
+			Number:             "1001",
+			Exp:                time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+			CardholderName:     "mr. Cardholder",
+			CardholderSurename: "Smith",
+			CVVHash:            cvvHash,
+			Name:               "case 1",
+			Comment:            "lucky green",
+		}
+
+		strg.EXPECT().
+			GetCardInfo(gomock.Any(), tt.ID, "user_id").
+			Return(tt, nil)
+
+		_, err = prov.GetCard(tt.ID, "000")
+		assert.ErrorIs(t, err, model.ErrInvalidCVV)
+
+		strg.EXPECT().
+			GetCardInfo(gomock.Any(), tt.ID, "user_id").
+			Return(tt, nil)
+
+		item, err := prov.GetCard(tt.ID, cvv)
+		require.NoError(t, err)
+
+		assert.Equal(t, tt, item)
+	})
+}
+
+func testSrv(strg storage.Storage) *server.Server {
+	vars := map[string]string{
+		"SERVER_ADDRESS":   "localhost:8081",
+		"GRPC_ADDRESS":     grpcAddr,
+		"SESSION_LIFETIME": "100000000",
+		"MAX_BODY_SIZE":    "1024",
+	}
+
+	cfg := srvconfig.New(srvconfig.WithEnvVars(vars))
+
+	return server.New(cfg, strg, zap.NewNop())
+}
@@ -0,0 +1,47 @@
+package httpp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// responseError turns an error response into *model.Error,
+// so callers can branch on it with errors.Is.
+// Responses that are not an error envelope (e.g. sent by a proxy)
+// are classified by status code.
+func responseError(res *http.Response, message []byte) error {
+	e := &model.Error{}
+	if err := json.Unmarshal(message, e); err == nil && e.Code != "" {
+		return e
+	}
+
+	e = &model.Error{
+		Code:    statusCode(res.StatusCode),
+		Message: http.StatusText(res.StatusCode),
+		Details: strings.TrimSpace(string(message)),
+	}
+
+	return e
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return model.ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return model.ErrCodeUnauthorized
+	case http.StatusNotFound:
+		return model.ErrCodeNotFound
+	case http.StatusConflict:
+		return model.ErrCodeUserExists
+	case http.StatusRequestEntityTooLarge:
+		return model.ErrCodeTooLarge
+	case http.StatusInsufficientStorage:
+		return model.ErrCodeQuotaExceeded
+	}
+
+	return model.ErrCodeInternal
+}
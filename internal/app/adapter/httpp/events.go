@@ -0,0 +1,106 @@
+package httpp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server"
+)
+
+// Events opens a stream of changes of user's items.
+// The channel is closed when the stream ends or ctx is done,
+// changes might have been missed since then.
+func (prov *Provider) Events(ctx context.Context) (<-chan model.Change, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%v/v1/events",
+			prov.origin()),
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose Events request: %w", err)
+	}
+
+	req.Header.Set("Accept", server.CTEventStream)
+
+	res, err := prov.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Events request failed: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+
+		message, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read server Events response: %w", err)
+		}
+
+		return nil, responseError(res, message)
+	}
+
+	events := make(chan model.Change)
+
+	go func() {
+		defer close(events)
+		defer res.Body.Close()
+
+		readEvents(ctx, res.Body, events)
+	}()
+
+	return events, nil
+}
+
+// readEvents sends changes of server-sent events read from r
+// to events until r is exhausted or ctx is done.
+// Events of unknown types and comments are skipped.
+func readEvents(ctx context.Context, r io.Reader, events chan<- model.Change) {
+	var (
+		event string
+		data  strings.Builder
+	)
+
+	sc := bufio.NewScanner(r)
+
+	for sc.Scan() {
+		line := sc.Text()
+
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+
+				data.WriteString(value)
+			}
+
+			continue
+		}
+
+		// an empty line dispatches the event
+		if event == server.EventChange {
+			var c model.Change
+			if err := json.Unmarshal([]byte(data.String()), &c); err == nil {
+				select {
+				case events <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		event = ""
+		data.Reset()
+	}
+}
@@ -2,32 +2,42 @@ package httpp
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"sync"
 
 	"go.uber.org/zap"
 	"golang.org/x/net/publicsuffix"
 
+	"github.com/usa4ev/ghostorange/internal/app/backup"
 	"github.com/usa4ev/ghostorange/internal/app/model"
 	"github.com/usa4ev/ghostorange/internal/app/server"
 )
 
+// sessionCookie holds the session token
+const sessionCookie = "Authorization"
+
 type (
 	Provider struct {
 		client *http.Client
 		cfg    config
 		logger *zap.SugaredLogger
+
+		mu      sync.Mutex
+		session model.Session
 	}
 	config interface {
 		SrvAddr() string
+		TLS() *tls.Config
 	}
 )
 
 func New(cfg config, logger *zap.SugaredLogger) (*Provider, error) {
-	cl := http.DefaultClient
 	jar, err := cookiejar.New(
 		&cookiejar.Options{
 			PublicSuffixList: publicsuffix.List,
@@ -37,18 +47,19 @@ func New(cfg config, logger *zap.SugaredLogger) (*Provider, error) {
 		return nil, err
 	}
 
-	cl.Jar = jar
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg.TLS()
 
 	return &Provider{
-			client: http.DefaultClient,
+			client: &http.Client{Jar: jar, Transport: transport},
 			cfg:    cfg},
 		nil
 }
 
-func (prov Provider) Count(dataType int) (string, error) {
+func (prov *Provider) Count(dataType int) (string, error) {
 	req, err := http.NewRequest(http.MethodGet,
-		fmt.Sprintf("http://%v/v1/data/count?data_type=%v",
-			prov.cfg.SrvAddr(), dataType),
+		fmt.Sprintf("%v/v1/data/count?data_type=%v",
+			prov.origin(), dataType),
 		nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to compose GetData request: %w", err)
@@ -68,15 +79,48 @@ func (prov Provider) Count(dataType int) (string, error) {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+		return "", responseError(res, message)
 	}
 
 	return string(message), nil
 }
 
-func (prov Provider) Register(item model.Credentials) error {
+func (prov *Provider) Usage() (model.Usage, error) {
+	var usage model.Usage
+
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("%v/v1/users/me/usage",
+			prov.origin()),
+		nil)
+	if err != nil {
+		return usage, fmt.Errorf("failed to compose Usage request: %w", err)
+	}
+
+	res, err := prov.client.Do(req)
+
+	if err != nil {
+		return usage, fmt.Errorf("Usage request failed: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	message, err := io.ReadAll(res.Body)
+	if err != nil {
+		return usage, fmt.Errorf("failed to read server Usage response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return usage, responseError(res, message)
+	}
+
+	if err := json.Unmarshal(message, &usage); err != nil {
+		return usage, fmt.Errorf("failed to decode server message: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (prov *Provider) Register(item model.Credentials) error {
 	buf := bytes.NewBuffer(nil)
 
 	if err := json.NewEncoder(buf).Encode(item); err != nil {
@@ -84,8 +128,8 @@ func (prov Provider) Register(item model.Credentials) error {
 	}
 
 	req, err := http.NewRequest(http.MethodPost,
-		fmt.Sprintf("http://%v/v1/users/register",
-			prov.cfg.SrvAddr()),
+		fmt.Sprintf("%v/v1/users/register",
+			prov.origin()),
 		buf)
 
 	if err != nil {
@@ -108,15 +152,15 @@ func (prov Provider) Register(item model.Credentials) error {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+		return responseError(res, message)
 	}
 
+	prov.keepSession(res)
+
 	return nil
 }
 
-func (prov Provider) Login(item model.Credentials) error {
+func (prov *Provider) Login(item model.Credentials) error {
 	buf := bytes.NewBuffer(nil)
 
 	if err := json.NewEncoder(buf).Encode(item); err != nil {
@@ -124,8 +168,8 @@ func (prov Provider) Login(item model.Credentials) error {
 	}
 
 	req, err := http.NewRequest(http.MethodPost,
-		fmt.Sprintf("http://%v/v1/users/login",
-			prov.cfg.SrvAddr()),
+		fmt.Sprintf("%v/v1/users/login",
+			prov.origin()),
 		buf)
 
 	if err != nil {
@@ -147,21 +191,19 @@ func (prov Provider) Login(item model.Credentials) error {
 		return fmt.Errorf("failed to read server Login response: %w", err)
 	}
 
-	if res.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("login or password must be wrong")
-	} else if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+	if res.StatusCode != http.StatusOK {
+		return responseError(res, message)
 	}
 
+	prov.keepSession(res)
+
 	return nil
 }
 
-func (prov Provider) GetData(dataType int) (any, error) {
+func (prov *Provider) GetData(dataType int) (any, error) {
 	req, err := http.NewRequest(http.MethodGet,
-		fmt.Sprintf("http://%v/v1/data?data_type=%v",
-			prov.cfg.SrvAddr(), dataType),
+		fmt.Sprintf("%v/v1/data?data_type=%v",
+			prov.origin(), dataType),
 		nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compose GetData request: %w", err)
@@ -181,9 +223,7 @@ func (prov Provider) GetData(dataType int) (any, error) {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+		return nil, responseError(res, message)
 	}
 
 	obj, err := model.DecodeItemsJSON(dataType, message)
@@ -201,8 +241,8 @@ func (prov *Provider) AddData(dataType int, data any) error {
 	}
 
 	req, err := http.NewRequest(http.MethodPost,
-		fmt.Sprintf("http://%v/v1/data?data_type=%v",
-			prov.cfg.SrvAddr(), dataType),
+		fmt.Sprintf("%v/v1/data?data_type=%v",
+			prov.origin(), dataType),
 		bytes.NewBuffer(msg))
 	if err != nil {
 		return fmt.Errorf("failed to compose AddData request: %w", err)
@@ -224,9 +264,7 @@ func (prov *Provider) AddData(dataType int, data any) error {
 	}
 
 	if res.StatusCode != http.StatusCreated {
-		return fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+		return responseError(res, message)
 	}
 
 	return nil
@@ -239,8 +277,8 @@ func (prov *Provider) UpdateData(dataType int, data any) error {
 	}
 
 	req, err := http.NewRequest(http.MethodPut,
-		fmt.Sprintf("http://%v/v1/data?data_type=%v",
-			prov.cfg.SrvAddr(), dataType),
+		fmt.Sprintf("%v/v1/data?data_type=%v",
+			prov.origin(), dataType),
 		bytes.NewBuffer(msg))
 	if err != nil {
 		return fmt.Errorf("failed to compose UpdateData request: %w", err)
@@ -262,20 +300,133 @@ func (prov *Provider) UpdateData(dataType int, data any) error {
 	}
 
 	if res.StatusCode != http.StatusCreated {
-		return fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+		return responseError(res, message)
 	}
 
 	return nil
 }
 
+func (prov *Provider) DeleteData(dataType int, id string) error {
+	req, err := http.NewRequest(http.MethodDelete,
+		fmt.Sprintf("%v/v1/data/%v?data_type=%v",
+			prov.origin(), url.PathEscape(id), dataType),
+		nil)
+	if err != nil {
+		return fmt.Errorf("failed to compose DeleteData request: %w", err)
+	}
+
+	res, err := prov.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("DeleteData request failed: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	message, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read server DeleteData response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return responseError(res, message)
+	}
+
+	return nil
+}
+
+// Batch applies ops in one transaction and returns their results
+// in the order of ops. An operation failing does not fail the others,
+// its result holds the error instead.
+func (prov *Provider) Batch(ops []model.BatchOp) ([]model.BatchResult, error) {
+	msg, err := json.Marshal(model.Batch{Ops: ops})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON data: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("%v/v1/data/batch",
+			prov.origin()),
+		bytes.NewBuffer(msg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose Batch request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", server.CTJSON)
+
+	res, err := prov.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Batch request failed: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	message, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server Batch response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res, message)
+	}
+
+	var results model.BatchResults
+	if err := json.Unmarshal(message, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode server message: %w", err)
+	}
+
+	if len(results.Results) != len(ops) {
+		return nil, fmt.Errorf("server returned %v results for %v operations",
+			len(results.Results), len(ops))
+	}
+
+	return results.Results, nil
+}
+
+// Changes returns a page of changes made after the change
+// cursor points to. Empty cursor requests all items.
+func (prov *Provider) Changes(cursor string) (model.Changes, error) {
+	var changes model.Changes
+
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("%v/v1/sync?since=%v",
+			prov.origin(), url.QueryEscape(cursor)),
+		nil)
+	if err != nil {
+		return changes, fmt.Errorf("failed to compose Changes request: %w", err)
+	}
+
+	res, err := prov.client.Do(req)
+
+	if err != nil {
+		return changes, fmt.Errorf("Changes request failed: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	message, err := io.ReadAll(res.Body)
+	if err != nil {
+		return changes, fmt.Errorf("failed to read server Changes response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return changes, responseError(res, message)
+	}
+
+	if err := json.Unmarshal(message, &changes); err != nil {
+		return changes, fmt.Errorf("failed to decode server message: %w", err)
+	}
+
+	return changes, nil
+}
+
 func (prov *Provider) GetCard(id, cvv string) (model.ItemCard, error) {
 	var item model.ItemCard
 
 	req, err := http.NewRequest(http.MethodGet,
-		fmt.Sprintf("http://%v/v1/data/cards/%v",
-			prov.cfg.SrvAddr(), id),
+		fmt.Sprintf("%v/v1/data/cards/%v",
+			prov.origin(), id),
 		bytes.NewBuffer([]byte(cvv)))
 	if err != nil {
 		return item, fmt.Errorf("failed to compose GetCard request: %w", err)
@@ -297,9 +448,7 @@ func (prov *Provider) GetCard(id, cvv string) (model.ItemCard, error) {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return item, fmt.Errorf(`server returned unexpected code: %v 
-			response: %v`,
-			res.StatusCode, string(message))
+		return item, responseError(res, message)
 	}
 
 	val, err := model.DecodeItemJSON(model.KeyCards, message)
@@ -315,6 +464,106 @@ func (prov *Provider) GetCard(id, cvv string) (model.ItemCard, error) {
 	return item, nil
 }
 
+// Export writes the vault archive encrypted with passphrase to w.
+// The archive is streamed as it is received, an archive cut off
+// by a broken connection fails to be read as truncated.
+// cred are sent with basic authentication on top of the session.
+func (prov *Provider) Export(w io.Writer, cred model.Credentials, passphrase string) error {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("%v/v1/export",
+			prov.origin()),
+		nil)
+	if err != nil {
+		return fmt.Errorf("failed to compose Export request: %w", err)
+	}
+
+	req.Header.Set(backup.HeaderPassphrase, passphrase)
+	req.SetBasicAuth(cred.Login, cred.Password)
+
+	res, err := prov.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Export request failed: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		message, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read server Export response: %w", err)
+		}
+
+		return responseError(res, message)
+	}
+
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return fmt.Errorf("failed to read server Export response: %w", err)
+	}
+
+	return nil
+}
+
+// Session returns the session opened by the last login.
+func (prov *Provider) Session() model.Session {
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+
+	return prov.session
+}
+
+// Resume makes the provider use a saved session.
+// Zero session drops the current one.
+func (prov *Provider) Resume(s model.Session) {
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+
+	prov.session = model.Session{Token: s.Token, ExpiresAt: s.ExpiresAt}
+
+	c := &http.Cookie{
+		Name:    sessionCookie,
+		Value:   s.Token,
+		Path:    "/v1",
+		Expires: s.ExpiresAt,
+	}
+
+	if s.Token == "" {
+		c.MaxAge = -1
+	}
+
+	prov.client.Jar.SetCookies(prov.baseURL(), []*http.Cookie{c})
+}
+
+// keepSession remembers the session cookie set by res.
+func (prov *Provider) keepSession(res *http.Response) {
+	for _, c := range res.Cookies() {
+		if c.Name != sessionCookie {
+			continue
+		}
+
+		prov.mu.Lock()
+		prov.session = model.Session{Token: c.Value, ExpiresAt: c.Expires}
+		prov.mu.Unlock()
+	}
+}
+
+func (prov *Provider) baseURL() *url.URL {
+	return &url.URL{Scheme: prov.scheme(), Host: prov.cfg.SrvAddr(), Path: "/v1/"}
+}
+
 func (prov *Provider) Lg() *zap.SugaredLogger {
 	return prov.logger
 }
+
+// origin returns scheme and address of the server.
+func (prov *Provider) origin() string {
+	return prov.scheme() + "://" + prov.cfg.SrvAddr()
+}
+
+func (prov *Provider) scheme() string {
+	if prov.cfg.TLS() != nil {
+		return "https"
+	}
+
+	return "http"
+}
@@ -1,21 +1,30 @@
 package httpp
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
+	"github.com/usa4ev/ghostorange/internal/app/backup"
 	"github.com/usa4ev/ghostorange/internal/app/model"
 	"github.com/usa4ev/ghostorange/internal/app/server"
+	"github.com/usa4ev/ghostorange/internal/app/server/openapitest"
 	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
 	"github.com/usa4ev/ghostorange/internal/app/storage"
 	mockstorage "github.com/usa4ev/ghostorange/internal/app/storage/mock"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
 	"github.com/usa4ev/ghostorange/internal/app/tui/clconfig"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
 )
 
 func TestProvider(t *testing.T) {
@@ -29,11 +38,18 @@ func TestProvider(t *testing.T) {
 	time.Sleep(time.Second)
 	defer srv.Shutdown(context.Background())
 
-	cfg := clconfig.New(clconfig.WithAddress("localhost:8080"))
+	cfg, err := clconfig.New(clconfig.WithAddress("localhost:8080"), clconfig.WithOsArgs(nil))
+	require.NoError(t, err)
 
 	prov, err := New(cfg, nil)
 	require.NoError(t, err)
 
+	// every call must match the OpenAPI document the server serves
+	prov.client = &http.Client{
+		Jar:       prov.client.Jar,
+		Transport: openapitest.New(t, openAPIDoc(t)).Transport(t, http.DefaultTransport),
+	}
+
 	strg.EXPECT().
 		AddUser(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return("user_id", nil)
@@ -89,6 +105,117 @@ func TestProvider(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("Add too large", func(t *testing.T) {
+		tt := model.ItemText{
+			Text: strings.Repeat("a", 2048),
+			Name: "case 1",
+		}
+
+		err := prov.AddData(model.KeyText, tt)
+		assert.ErrorIs(t, err, model.ErrTooLarge)
+	})
+
+	t.Run("Login wrong password", func(t *testing.T) {
+		strg.EXPECT().
+			GetPasswordHash(gomock.Any(), "test").
+			Return("", "", nil)
+
+		err := prov.Login(model.Credentials{Login: "test", Password: "wrong"})
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("Get Card not found", func(t *testing.T) {
+		strg.EXPECT().
+			GetCardInfo(gomock.Any(), "missing", gomock.Any()).
+			Return(model.ItemCard{}, strgerrors.ErrNotFound)
+
+		_, err := prov.GetCard("missing", "123")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		tt := model.Usage{
+			Entries: []model.UsageEntry{
+				{DataType: model.KeyCredentials, Count: 2, Bytes: 100},
+				{DataType: model.KeyText, Count: 1, Bytes: 50},
+			},
+			TotalBytes: 150,
+			ItemsLimit: 10,
+			BytesLimit: 1000,
+		}
+
+		strg.EXPECT().
+			Usage(gomock.Any(), gomock.Any()).
+			Return(tt, nil)
+
+		res, err := prov.Usage()
+		require.NoError(t, err)
+
+		assert.Equal(t, tt, res)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		tt := model.ItemText{ID: "id", Name: "note", Text: "text"}
+
+		pwdHash, err := argon2hash.GenerateFromPassword("test", argon2hash.DefaultParams())
+		require.NoError(t, err)
+
+		strg.EXPECT().
+			GetPasswordHash(gomock.Any(), "test").
+			Return("user_id", pwdHash, nil).
+			Times(2)
+
+		strg.EXPECT().
+			GetData(gomock.Any(), model.KeyText).
+			Return([]model.ItemText{tt}, nil)
+		strg.EXPECT().
+			GetData(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, dataType int) (any, error) {
+				return model.DecodeItemsJSON(dataType, []byte("[]"))
+			}).
+			Times(model.KeyLimit - 1)
+
+		cred := model.Credentials{Login: "test", Password: "test"}
+
+		var buf bytes.Buffer
+		require.NoError(t, prov.Export(&buf, cred, "correct horse"))
+
+		r, err := backup.NewReader(&buf, "correct horse")
+		require.NoError(t, err)
+
+		dataType, item, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, model.KeyText, dataType)
+		assert.Equal(t, tt, item)
+
+		err = prov.Export(io.Discard, cred, "short")
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+
+		err = prov.Export(io.Discard, model.Credentials{Login: "test", Password: "wrong"}, "correct horse")
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		ops := []model.BatchOp{
+			{Op: model.BatchCreate, DataType: model.KeyText,
+				Item: model.ItemText{ID: "new", Name: "note", Text: "text"}},
+			{Op: model.BatchDelete, DataType: model.KeyCards, ID: "missing"},
+		}
+
+		strg.EXPECT().
+			Batch(gomock.Any(), gomock.Any(), ops).
+			Return([]error{nil, strgerrors.ErrNotFound}, nil)
+
+		res, err := prov.Batch(ops)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+
+		assert.Equal(t, "new", res[0].ID)
+		assert.NoError(t, res[0].Err())
+		assert.Equal(t, "missing", res[1].ID)
+		assert.ErrorIs(t, res[1].Err(), model.ErrNotFound)
+	})
+
 	t.Run("Count", func(t *testing.T) {
 
 		tt := 100
@@ -103,14 +230,82 @@ func TestProvider(t *testing.T) {
 		assert.Equal(t, strconv.Itoa(tt), res)
 	})
 
+	t.Run("Delete", func(t *testing.T) {
+		strg.EXPECT().
+			DeleteData(gomock.Any(), model.KeyText, gomock.Any(), "id").
+			Return(nil)
+
+		require.NoError(t, prov.DeleteData(model.KeyText, "id"))
+
+		strg.EXPECT().
+			DeleteData(gomock.Any(), model.KeyText, gomock.Any(), "missing").
+			Return(strgerrors.ErrNotFound)
+
+		err := prov.DeleteData(model.KeyText, "missing")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		ts := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+		tt := []model.Change{
+			{Seq: 3, DataType: model.KeyText, ID: "text", Kind: model.ChangeUpdated, ChangedAt: ts,
+				Item: model.ItemText{ID: "text", Text: "text"}},
+			{Seq: 5, DataType: model.KeyCards, ID: "card", Kind: model.ChangeDeleted, ChangedAt: ts},
+			{Seq: 6, DataType: model.KeyBinary, ID: "bin", Kind: model.ChangeCreated, ChangedAt: ts,
+				Item: model.ItemBinary{ID: "bin"}},
+		}
+
+		// the server asks for an extra change to tell if there are more pages
+		strg.EXPECT().
+			Changes(gomock.Any(), gomock.Any(), int64(2), 501).
+			Return(tt, nil)
+
+		res, err := prov.Changes("2")
+		require.NoError(t, err)
+
+		assert.Equal(t, model.Changes{Changes: tt, Cursor: "6"}, res)
+
+		_, err = prov.Changes("bad")
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		changes := make(chan model.Change, 1)
+		tt := model.Change{Seq: 7, DataType: model.KeyText, ID: "id", Kind: model.ChangeDeleted,
+			ChangedAt: time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+		strg.EXPECT().
+			Subscribe(gomock.Any(), gomock.Any()).
+			Return((<-chan model.Change)(changes), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := prov.Events(ctx)
+		require.NoError(t, err)
+
+		changes <- tt
+		assert.Equal(t, tt, <-events)
+
+		// the stream ends with the subscription
+		close(changes)
+
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+
 	t.Run("Get Card", func(t *testing.T) {
+		cvv := "123"
+		cvvHash, err := argon2hash.GenerateFromPassword(cvv, argon2hash.DefaultParams())
+		require.NoError(t, err)
+
 		tt := model.ItemCard{
 			ID:                 "id",
 			Number:             "1001",
 			Exp:                time.Now().Add(time.Hour * 24000),
 			CardholderName:     "mr. Cardholder",
 			CardholderSurename: "Smith",
-			CVVHash:            "secure",
+			CVVHash:            cvvHash,
 			Name:               "case 1",
 			Comment:            "lucky green",
 		}
@@ -119,7 +314,14 @@ func TestProvider(t *testing.T) {
 			GetCardInfo(gomock.Any(), tt.ID, gomock.Any()).
 			Return(tt, nil)
 
-		item, err := prov.GetCard(tt.ID, tt.CVVHash)
+		_, err = prov.GetCard(tt.ID, "000")
+		assert.ErrorIs(t, err, model.ErrInvalidCVV)
+
+		strg.EXPECT().
+			GetCardInfo(gomock.Any(), tt.ID, gomock.Any()).
+			Return(tt, nil)
+
+		item, err := prov.GetCard(tt.ID, cvv)
 		require.NoError(t, err)
 
 		assert.WithinDuration(t, tt.Exp, item.Exp, 0)
@@ -129,13 +331,28 @@ func TestProvider(t *testing.T) {
 	})
 }
 
+func openAPIDoc(t *testing.T) []byte {
+	res, err := http.Get("http://localhost:8080/v1/openapi.json")
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	doc, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return doc
+}
+
 func testSrv(strg storage.Storage) *server.Server {
 	vars := map[string]string{
 		"SERVER_ADDRESS":   "localhost:8080",
 		"SESSION_LIFETIME": "100000000",
+		"MAX_BODY_SIZE":    "1024",
 	}
 
 	cfg := srvconfig.New(srvconfig.WithEnvVars(vars))
 
-	return server.New(cfg, strg)
+	return server.New(cfg, strg, zap.NewNop())
 }
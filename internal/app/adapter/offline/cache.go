@@ -0,0 +1,701 @@
+package offline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const (
+	// queued operation kinds
+	opAdd    = "add"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+var (
+	errNoAccount     = errors.New("account is not cached")
+	errWrongPassword = errors.New("password does not match the cached account")
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS accounts(
+	account TEXT PRIMARY KEY,
+	salt BLOB NOT NULL,
+	verifier BLOB NOT NULL,
+	cursor TEXT NOT NULL DEFAULT '',
+	synced_at TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS items(
+	account TEXT NOT NULL,
+	data_type INTEGER NOT NULL,
+	id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	rev TEXT NOT NULL,
+	payload BLOB NOT NULL,
+	PRIMARY KEY (account, data_type, id)
+);
+CREATE TABLE IF NOT EXISTS queue(
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	account TEXT NOT NULL,
+	data_type INTEGER NOT NULL,
+	op TEXT NOT NULL,
+	item_id TEXT NOT NULL,
+	base_rev TEXT NOT NULL,
+	payload BLOB NOT NULL,
+	queued_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS conflicts(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account TEXT NOT NULL,
+	data_type INTEGER NOT NULL,
+	item_id TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	local BLOB NOT NULL,
+	remote BLOB,
+	queued_at TIMESTAMP NOT NULL,
+	detected_at TIMESTAMP NOT NULL
+);`
+
+// upgrades bring caches created by older versions up to date,
+// PRAGMA user_version holds the number of applied ones.
+var upgrades = []string{
+	// kind of the conflicting change, deletions are told apart
+	`ALTER TABLE conflicts ADD COLUMN op TEXT NOT NULL DEFAULT 'update'`,
+}
+
+type (
+	// cache is a local copy of user's data. Every item payload
+	// is encrypted with a key derived from user's password,
+	// so the cache is only readable after unlock.
+	cache struct {
+		db      *sql.DB
+		account string
+		key     []byte
+	}
+
+	// operation is a write queued until the server is reachable.
+	operation struct {
+		Seq      int64
+		DataType int
+		Kind     string
+		ItemID   string
+		BaseRev  string
+		Item     any
+		QueuedAt time.Time
+	}
+)
+
+// openCache opens or creates the cache database at path.
+func openCache(path string) (*cache, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create cache schema: %w", err)
+	}
+
+	if err := upgrade(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// the cache holds nothing but ciphertext,
+	// still there is no reason to let others read it
+	if err := os.Chmod(path, 0o600); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set cache permissions: %w", err)
+	}
+
+	return &cache{db: db}, nil
+}
+
+// upgrade applies upgrades not yet applied to db.
+func upgrade(db *sql.DB) error {
+	var version int
+
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to load cache version: %w", err)
+	}
+
+	for ; version < len(upgrades); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		if _, err := tx.Exec(upgrades[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to upgrade cache: %w", err)
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to save cache version: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *cache) close() error {
+	return c.db.Close()
+}
+
+// unlock derives the key of account from password and checks it
+// against the stored verifier.
+func (c *cache) unlock(account, password string) error {
+	var salt, verifier []byte
+
+	err := c.db.QueryRow(`SELECT salt, verifier FROM accounts WHERE account = $1`, account).
+		Scan(&salt, &verifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNoAccount
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load account: %w", err)
+	}
+
+	return c.unlockKey(account, deriveKey(password, salt), verifier)
+}
+
+// unlockKey checks key of account against verifier, loaded from
+// the cache if nil, and unlocks the account with it.
+func (c *cache) unlockKey(account string, key, verifier []byte) error {
+	if verifier == nil {
+		err := c.db.QueryRow(`SELECT verifier FROM accounts WHERE account = $1`, account).
+			Scan(&verifier)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errNoAccount
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to load account: %w", err)
+		}
+	}
+
+	if _, err := open(key, verifier); err != nil {
+		return errWrongPassword
+	}
+
+	// the key is zeroed on lock, so caller's copy is not kept
+	c.account, c.key = account, append([]byte(nil), key...)
+
+	return nil
+}
+
+// lock zeroes and forgets the key, cached data can't be read until
+// the account is unlocked again.
+func (c *cache) lock() {
+	for i := range c.key {
+		c.key[i] = 0
+	}
+
+	c.account, c.key = "", nil
+}
+
+// reset drops all cached data of account and creates it anew
+// with a key derived from password.
+func (c *cache) reset(account, password string) error {
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+
+	key := deriveKey(password, salt)
+
+	verifier, err := seal(key, verifierText)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"accounts", "items", "queue", "conflicts"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE account = $1`, account); err != nil {
+			return fmt.Errorf("failed to clear %v: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO accounts(account, salt, verifier) VALUES ($1, $2, $3)`,
+		account, salt, verifier); err != nil {
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	c.account, c.key = account, key
+
+	return nil
+}
+
+func (c *cache) sealItem(item any) ([]byte, error) {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode item: %w", err)
+	}
+
+	return seal(c.key, b)
+}
+
+func (c *cache) openItem(dataType int, b []byte) (any, error) {
+	plain, err := open(c.key, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.DecodeItemJSON(dataType, plain)
+}
+
+func (c *cache) revision(item any) (string, error) {
+	return revision(c.key, item)
+}
+
+// items returns local view of dataType items, including
+// changes not yet sent to the server.
+func (c *cache) items(dataType int) (any, error) {
+	rows, err := c.db.Query(`SELECT payload FROM items
+		WHERE account = $1 AND data_type = $2
+		ORDER BY seq, rowid`, c.account, dataType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cached items: %w", err)
+	}
+	defer rows.Close()
+
+	payloads := make([][]byte, 0)
+
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, fmt.Errorf("failed to read cached item: %w", err)
+		}
+
+		plain, err := open(c.key, b)
+		if err != nil {
+			return nil, err
+		}
+
+		payloads = append(payloads, plain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cached items: %w", err)
+	}
+
+	return decodeItems(dataType, payloads)
+}
+
+func (c *cache) count(dataType int) (int, error) {
+	var n int
+
+	err := c.db.QueryRow(`SELECT COUNT(*) FROM items WHERE account = $1 AND data_type = $2`,
+		c.account, dataType).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count cached items: %w", err)
+	}
+
+	return n, nil
+}
+
+// rev returns revision of the server version the cached item
+// is based on. Empty revision means the item is not on the server yet.
+func (c *cache) rev(dataType int, id string) (string, error) {
+	var rev string
+
+	err := c.db.QueryRow(`SELECT rev FROM items WHERE account = $1 AND data_type = $2 AND id = $3`,
+		c.account, dataType, id).Scan(&rev)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to load item revision: %w", err)
+	}
+
+	return rev, nil
+}
+
+// stage saves a local change of item and queues it for the server.
+// A change of an item that is already queued replaces the queued
+// payload, so the item is sent once and checked against the
+// revision it was originally based on. An item deleted locally
+// can't be changed.
+func (c *cache) stage(dataType int, kind, baseRev string, item any) error {
+	id, _ := itemInfo(item)
+
+	payload, err := c.sealItem(item)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var deleted bool
+
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM queue
+		WHERE account = $1 AND data_type = $2 AND item_id = $3 AND op = $4)`,
+		c.account, dataType, id, opDelete).Scan(&deleted)
+	if err != nil {
+		return fmt.Errorf("failed to load queued changes: %w", err)
+	}
+
+	if deleted {
+		return fmt.Errorf("%w: item is deleted", model.ErrNotFound)
+	}
+
+	_, err = tx.Exec(`INSERT INTO items(account, data_type, id, seq, rev, payload)
+		VALUES ($1, $2, $3,
+			(SELECT COALESCE(MAX(seq), -1) + 1 FROM items WHERE account = $1 AND data_type = $2),
+			'', $4)
+		ON CONFLICT (account, data_type, id) DO UPDATE SET
+		payload = excluded.payload`,
+		c.account, dataType, id, payload)
+	if err != nil {
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	res, err := tx.Exec(`UPDATE queue SET payload = $1, queued_at = $2
+		WHERE account = $3 AND data_type = $4 AND item_id = $5`,
+		payload, now, c.account, dataType, id)
+	if err != nil {
+		return fmt.Errorf("failed to update queued change: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		_, err = tx.Exec(`INSERT INTO queue(account, data_type, op, item_id, base_rev, payload, queued_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			c.account, dataType, kind, id, baseRev, payload, now)
+		if err != nil {
+			return fmt.Errorf("failed to queue change: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// remove deletes cached item along with its queued changes and
+// queues its deletion, based on the server version the item is
+// cached with. An item that has never reached the server is just
+// dropped. It reports whether the deletion is queued.
+func (c *cache) remove(dataType int, id string) (bool, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		rev     string
+		payload []byte
+	)
+
+	err = tx.QueryRow(`SELECT rev, payload FROM items WHERE account = $1 AND data_type = $2 AND id = $3`,
+		c.account, dataType, id).Scan(&rev, &payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, model.ErrNotFound
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to load cached item: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM queue WHERE account = $1 AND data_type = $2 AND item_id = $3`,
+		c.account, dataType, id); err != nil {
+		return false, fmt.Errorf("failed to drop queued changes: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM items WHERE account = $1 AND data_type = $2 AND id = $3`,
+		c.account, dataType, id); err != nil {
+		return false, fmt.Errorf("failed to drop cached item: %w", err)
+	}
+
+	// the last local version is kept with the deletion
+	// in case it ends up as a conflict
+	if rev != "" {
+		_, err = tx.Exec(`INSERT INTO queue(account, data_type, op, item_id, base_rev, payload, queued_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			c.account, dataType, opDelete, id, rev, payload, time.Now().UTC())
+		if err != nil {
+			return false, fmt.Errorf("failed to queue deletion: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rev != "", nil
+}
+
+// operations returns queued changes in the order they were made.
+func (c *cache) operations() ([]operation, error) {
+	rows, err := c.db.Query(`SELECT seq, data_type, op, item_id, base_rev, payload, queued_at
+		FROM queue
+		WHERE account = $1
+		ORDER BY seq`, c.account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queued changes: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]operation, 0)
+
+	for rows.Next() {
+		var (
+			o       operation
+			payload []byte
+		)
+
+		if err := rows.Scan(&o.Seq, &o.DataType, &o.Kind, &o.ItemID, &o.BaseRev, &payload, &o.QueuedAt); err != nil {
+			return nil, fmt.Errorf("failed to read queued change: %w", err)
+		}
+
+		if o.Item, err = c.openItem(o.DataType, payload); err != nil {
+			return nil, err
+		}
+
+		res = append(res, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queued changes: %w", err)
+	}
+
+	return res, nil
+}
+
+func (c *cache) dropOperation(seq int64) error {
+	if _, err := c.db.Exec(`DELETE FROM queue WHERE seq = $1`, seq); err != nil {
+		return fmt.Errorf("failed to drop queued change: %w", err)
+	}
+
+	return nil
+}
+
+// cursor returns the position in the server change log
+// the cache is up to date with.
+func (c *cache) cursor() (string, error) {
+	var cursor string
+
+	err := c.db.QueryRow(`SELECT cursor FROM accounts WHERE account = $1`, c.account).
+		Scan(&cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to load sync cursor: %w", err)
+	}
+
+	return cursor, nil
+}
+
+// apply applies changes received from the server and moves
+// the cursor unless it is empty. Items with queued changes are kept,
+// they are checked against the server version when sent. Items
+// deleted locally are not brought back.
+func (c *cache) apply(changes []model.Change, cursor string) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, ch := range changes {
+		if ch.Kind == model.ChangeDeleted {
+			_, err = tx.Exec(`DELETE FROM items
+				WHERE account = $1 AND data_type = $2 AND id = $3
+				AND id NOT IN (SELECT item_id FROM queue WHERE account = $1 AND data_type = $2)`,
+				c.account, ch.DataType, ch.ID)
+			if err != nil {
+				return fmt.Errorf("failed to drop cached item: %w", err)
+			}
+
+			continue
+		}
+
+		rev, err := c.revision(ch.Item)
+		if err != nil {
+			return err
+		}
+
+		payload, err := c.sealItem(ch.Item)
+		if err != nil {
+			return err
+		}
+
+		// new items go to the end of the list, see items
+		_, err = tx.Exec(`INSERT INTO items(account, data_type, id, seq, rev, payload)
+			SELECT $1, $2, $3,
+				(SELECT COALESCE(MAX(seq), -1) + 1 FROM items WHERE account = $1 AND data_type = $2),
+				$4, $5
+			WHERE $3 NOT IN (SELECT item_id FROM queue WHERE account = $1 AND data_type = $2)
+			ON CONFLICT (account, data_type, id) DO UPDATE SET
+			rev = excluded.rev,
+			payload = excluded.payload`,
+			c.account, ch.DataType, ch.ID, rev, payload)
+		if err != nil {
+			return fmt.Errorf("failed to cache item: %w", err)
+		}
+	}
+
+	if cursor != "" {
+		if _, err := tx.Exec(`UPDATE accounts SET cursor = $1 WHERE account = $2`,
+			cursor, c.account); err != nil {
+			return fmt.Errorf("failed to save sync cursor: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// addConflict saves a queued change that could not be applied.
+func (c *cache) addConflict(o operation, reason string, remote any) error {
+	local, err := c.sealItem(o.Item)
+	if err != nil {
+		return err
+	}
+
+	var sealed []byte
+	if remote != nil {
+		if sealed, err = c.sealItem(remote); err != nil {
+			return err
+		}
+	}
+
+	_, err = c.db.Exec(`INSERT INTO conflicts(account, data_type, item_id, op, reason, local, remote, queued_at, detected_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		c.account, o.DataType, o.ItemID, o.Kind, reason, local, sealed, o.QueuedAt, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save conflict: %w", err)
+	}
+
+	return nil
+}
+
+// conflicts returns conflicts of account in the order
+// they were detected. If id is not zero only that conflict is returned.
+func (c *cache) conflicts(id int64) ([]model.Conflict, error) {
+	rows, err := c.db.Query(`SELECT id, data_type, item_id, op, reason, local, remote, queued_at, detected_at
+		FROM conflicts
+		WHERE account = $1 AND ($2 = 0 OR id = $2)
+		ORDER BY id`, c.account, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]model.Conflict, 0)
+
+	for rows.Next() {
+		var (
+			cf            model.Conflict
+			op            string
+			local, remote []byte
+		)
+
+		err := rows.Scan(&cf.ID, &cf.DataType, &cf.ItemID, &op, &cf.Reason, &local, &remote, &cf.QueuedAt, &cf.DetectedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read conflict: %w", err)
+		}
+
+		cf.Deleted = op == opDelete
+
+		if cf.Local, err = c.openItem(cf.DataType, local); err != nil {
+			return nil, err
+		}
+
+		if remote != nil {
+			if cf.Remote, err = c.openItem(cf.DataType, remote); err != nil {
+				return nil, err
+			}
+		}
+
+		_, cf.Name = itemInfo(cf.Local)
+
+		res = append(res, cf)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conflicts: %w", err)
+	}
+
+	return res, nil
+}
+
+func (c *cache) dropConflict(id int64) error {
+	if _, err := c.db.Exec(`DELETE FROM conflicts WHERE id = $1 AND account = $2`, id, c.account); err != nil {
+		return fmt.Errorf("failed to drop conflict: %w", err)
+	}
+
+	return nil
+}
+
+// status returns counters of queued changes and conflicts
+// and the time of the last complete synchronisation.
+func (c *cache) status() (model.SyncStatus, error) {
+	var (
+		st       model.SyncStatus
+		syncedAt sql.NullTime
+	)
+
+	err := c.db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM queue WHERE account = $1),
+		(SELECT COUNT(*) FROM conflicts WHERE account = $1)`, c.account).
+		Scan(&st.Pending, &st.Conflicts)
+	if err != nil {
+		return st, fmt.Errorf("failed to load sync status: %w", err)
+	}
+
+	err = c.db.QueryRow(`SELECT synced_at FROM accounts WHERE account = $1`, c.account).
+		Scan(&syncedAt)
+	if err != nil {
+		return st, fmt.Errorf("failed to load sync time: %w", err)
+	}
+
+	st.SyncedAt = syncedAt.Time
+
+	return st, nil
+}
+
+func (c *cache) markSynced(t time.Time) error {
+	if _, err := c.db.Exec(`UPDATE accounts SET synced_at = $1 WHERE account = $2`,
+		t, c.account); err != nil {
+		return fmt.Errorf("failed to save sync time: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package offline
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+const saltLength = 16
+
+// verifierText is sealed with the account key, so the key
+// derived from a password can be checked while offline.
+var verifierText = []byte("ghostorange offline cache")
+
+// deriveKey derives an AES-256 key from user's password.
+func deriveKey(password string, salt []byte) []byte {
+	p := argon2hash.DefaultParams()
+
+	return argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, 32)
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	return salt, nil
+}
+
+// seal encrypts b with AES-GCM. Random nonce is prepended
+// to the result.
+func seal(key, b []byte) ([]byte, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aesgcm.Seal(nonce, nonce, b, nil), nil
+}
+
+// open decrypts b sealed by seal.
+func open(key, b []byte) ([]byte, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < aesgcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted value is too short")
+	}
+
+	nonce, data := b[:aesgcm.NonceSize()], b[aesgcm.NonceSize():]
+
+	res, err := aesgcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return res, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aesgcm: %w", err)
+	}
+
+	return aesgcm, nil
+}
+
+// revision returns revision of an item as seen on the server.
+// It is keyed with the account key, so the revisions stored
+// in plain text reveal nothing about item contents.
+func revision(key []byte, item any) (string, error) {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode item: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
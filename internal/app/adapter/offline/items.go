@@ -0,0 +1,90 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// itemInfo returns ID and name of a stored data item.
+func itemInfo(data any) (id, name string) {
+	switch v := data.(type) {
+	case model.ItemCredentials:
+		return v.ID, v.Name
+	case model.ItemText:
+		return v.ID, v.Name
+	case model.ItemBinary:
+		return v.ID, v.Name
+	case model.ItemCard:
+		return v.ID, v.Name
+	}
+
+	return "", ""
+}
+
+// withID returns a copy of data item with ID set to id.
+func withID(data any, id string) (any, error) {
+	switch v := data.(type) {
+	case model.ItemCredentials:
+		v.ID = id
+		return v, nil
+	case model.ItemText:
+		v.ID = id
+		return v, nil
+	case model.ItemBinary:
+		v.ID = id
+		return v, nil
+	case model.ItemCard:
+		v.ID = id
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("unsupported item type %T", data)
+}
+
+// splitItems turns a slice of items returned by GetData
+// into a slice of separate items.
+func splitItems(data any) ([]any, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("expected a slice of items, got %T", data)
+	}
+
+	res := make([]any, v.Len())
+	for i := range res {
+		res[i] = v.Index(i).Interface()
+	}
+
+	return res, nil
+}
+
+// decodeItems decodes JSON encoded items into a slice
+// of dataType items as returned by GetData.
+func decodeItems(dataType int, payloads [][]byte) (any, error) {
+	switch dataType {
+	case model.KeyCredentials:
+		return decodeAll[model.ItemCredentials](payloads)
+	case model.KeyText:
+		return decodeAll[model.ItemText](payloads)
+	case model.KeyBinary:
+		return decodeAll[model.ItemBinary](payloads)
+	case model.KeyCards:
+		return decodeAll[model.ItemCard](payloads)
+	}
+
+	return nil, fmt.Errorf("unsupported data type")
+}
+
+func decodeAll[T model.Item](payloads [][]byte) ([]T, error) {
+	res := make([]T, len(payloads))
+
+	for i, b := range payloads {
+		if err := json.Unmarshal(b, &res[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
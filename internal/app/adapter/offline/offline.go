@@ -0,0 +1,1034 @@
+// Package offline provides an adapter that keeps an encrypted
+// local copy of user's data, so the client stays usable while
+// the server is unreachable. Changes made offline, deletions
+// included, are queued and sent to the server once it is
+// reachable again.
+//
+// Every cached item remembers the revision of the server version
+// it is based on. A queued change of an item that has been changed
+// on the server meanwhile is not applied. It is saved as a conflict
+// instead, for the user to resolve.
+//
+// Server changes are pulled from the server change log starting
+// at the cursor saved with the cache, so only changed items are
+// downloaded.
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const cacheFile = "cache.db"
+
+var (
+	// ErrOffline is returned when an operation requires the server
+	// and the server is unreachable.
+	ErrOffline = errors.New("server is unreachable")
+	// ErrConflict is returned when a change is saved as a conflict
+	// instead of being applied to the server.
+	ErrConflict = errors.New("change conflicts with the server version")
+)
+
+type (
+	// Remote is an adapter that talks to the server.
+	Remote interface {
+		Login(model.Credentials) error
+		Register(model.Credentials) error
+
+		Usage() (model.Usage, error)
+
+		GetData(dataType int) (any, error)
+		AddData(dataType int, data any) error
+		UpdateData(dataType int, data any) error
+		DeleteData(dataType int, id string) error
+		// Batch applies ops in one go and returns their results
+		Batch(ops []model.BatchOp) ([]model.BatchResult, error)
+		GetCard(id, cvvHash string) (model.ItemCard, error)
+		// Export writes the vault archive encrypted with passphrase to w,
+		// cred are user's credentials asked again
+		Export(w io.Writer, cred model.Credentials, passphrase string) error
+
+		Changes(cursor string) (model.Changes, error)
+		Events(ctx context.Context) (<-chan model.Change, error)
+
+		// Session returns the session opened by the last login
+		Session() model.Session
+		// Resume makes the remote use a saved session
+		Resume(model.Session)
+	}
+
+	config interface {
+		SrvAddr() string
+		CacheDir() string
+	}
+
+	Adapter struct {
+		remote Remote
+		cfg    config
+		logger *zap.SugaredLogger
+
+		mu     sync.Mutex
+		cache  *cache
+		login  string
+		online bool
+	}
+)
+
+func New(remote Remote, cfg config, logger *zap.SugaredLogger) *Adapter {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+
+	return &Adapter{
+		remote: remote,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Login logs in on the server and unlocks the local cache.
+// If the server is unreachable user is let in offline
+// as long as the password opens the cache.
+func (a *Adapter) Login(cred model.Credentials) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err := a.reach(a.remote.Login(cred))
+	if err != nil && !errors.Is(err, ErrOffline) {
+		return err
+	}
+
+	if err != nil {
+		switch err := a.openAccount(cred, false); {
+		case errors.Is(err, errNoAccount):
+			return fmt.Errorf("%w: no offline data found for %v", ErrOffline, cred.Login)
+		case errors.Is(err, errWrongPassword):
+			return model.ErrUnauthorized
+		case err != nil:
+			return err
+		}
+
+		return nil
+	}
+
+	if err := a.openAccount(cred, true); err != nil {
+		return err
+	}
+
+	if err := a.sync(nil); err != nil && !errors.Is(err, ErrOffline) {
+		return err
+	}
+
+	return nil
+}
+
+// Session returns the current session along with the key
+// of user's local cache, so it can be resumed without password.
+func (a *Adapter) Session() (model.Session, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.Session{}, model.ErrInvalidSession
+	}
+
+	s := a.remote.Session()
+	s.Login = a.login
+	s.CacheKey = append([]byte(nil), a.cache.key...)
+
+	return s, nil
+}
+
+// Resume resumes saved session and unlocks the local cache with
+// its key. The session is checked by synchronising with the server,
+// if the server is unreachable user is let in offline.
+func (a *Adapter) Resume(s model.Session) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !s.Valid(time.Now()) || len(s.CacheKey) == 0 {
+		return model.ErrInvalidSession
+	}
+
+	if err := a.openCache(); err != nil {
+		return err
+	}
+
+	err := a.cache.unlockKey(a.account(s.Login), s.CacheKey, nil)
+	if errors.Is(err, errNoAccount) || errors.Is(err, errWrongPassword) {
+		return model.ErrInvalidSession
+	}
+
+	if err != nil {
+		return err
+	}
+
+	a.login = s.Login
+	a.remote.Resume(s)
+
+	if err := a.sync(nil); err != nil && !errors.Is(err, ErrOffline) {
+		a.logout()
+
+		return err
+	}
+
+	return nil
+}
+
+// Logout forgets the session and locks the local cache.
+func (a *Adapter) Logout() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.logout()
+}
+
+// Lock locks the local cache and zeroes its key, but keeps
+// the session, so user is let back in by Unlock with the
+// password. Nothing can be read until then.
+func (a *Adapter) Lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cache != nil {
+		a.cache.lock()
+	}
+}
+
+// Unlock unlocks the local cache locked by Lock. The password
+// is checked against the cache, so the server is not asked.
+func (a *Adapter) Unlock(password string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.login == "" || a.cache == nil {
+		return model.ErrInvalidSession
+	}
+
+	switch err := a.cache.unlock(a.account(a.login), password); {
+	case errors.Is(err, errWrongPassword):
+		return model.ErrUnauthorized
+	case errors.Is(err, errNoAccount):
+		return model.ErrInvalidSession
+	default:
+		return err
+	}
+}
+
+// Close closes the local cache and the connection to the server.
+func (a *Adapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.logout()
+
+	if c, ok := a.remote.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+
+	if a.cache == nil {
+		return nil
+	}
+
+	err := a.cache.close()
+	a.cache = nil
+
+	return err
+}
+
+func (a *Adapter) Register(cred model.Credentials) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.reach(a.remote.Register(cred)); err != nil {
+		return err
+	}
+
+	return a.openAccount(cred, true)
+}
+
+// Count returns number of cached items of dataType
+// including the ones not yet sent to the server.
+func (a *Adapter) Count(dataType int) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return "", model.ErrInvalidSession
+	}
+
+	n, err := a.cache.count(dataType)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(n), nil
+}
+
+func (a *Adapter) Usage() (model.Usage, error) {
+	if err := a.checkUnlocked(); err != nil {
+		return model.Usage{}, err
+	}
+
+	usage, err := a.remote.Usage()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return usage, a.reach(err)
+}
+
+// GetData synchronises dataType items with the server if possible
+// and returns local view of them.
+func (a *Adapter) GetData(dataType int) (any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return nil, model.ErrInvalidSession
+	}
+
+	if err := a.sync(nil); err != nil && !errors.Is(err, ErrOffline) {
+		return nil, err
+	}
+
+	return a.cache.items(dataType)
+}
+
+// AddData saves a new item locally and sends it to the server.
+// New items get their IDs on the client, so the item keeps
+// its ID when it is added while offline.
+func (a *Adapter) AddData(dataType int, data any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.ErrInvalidSession
+	}
+
+	id, _ := itemInfo(data)
+	if id == "" {
+		var err error
+		if data, err = withID(data, uuid.NewString()); err != nil {
+			return err
+		}
+	}
+
+	return a.save(dataType, opAdd, "", data)
+}
+
+// UpdateData saves changed item locally and sends it to the server.
+func (a *Adapter) UpdateData(dataType int, data any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.ErrInvalidSession
+	}
+
+	id, _ := itemInfo(data)
+
+	rev, err := a.cache.rev(dataType, id)
+	if err != nil {
+		return err
+	}
+
+	kind := opUpdate
+	if rev == "" {
+		kind = opAdd
+	}
+
+	return a.save(dataType, kind, rev, data)
+}
+
+// DeleteData drops cached item along with its queued changes
+// and sends its deletion to the server. The item is hidden
+// right away, its deletion stays queued while the server is
+// unreachable. An item that has never reached the server is
+// dropped locally.
+func (a *Adapter) DeleteData(dataType int, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.ErrInvalidSession
+	}
+
+	return a.remove(dataType, id)
+}
+
+// Batch applies ops and returns their errors in the order of ops.
+// Changes are saved locally and sent to the server in one batch
+// along with other queued changes, the way AddData, UpdateData
+// and DeleteData do. Creates without item ID get one on the client,
+// as with AddData, and it is set in ops.
+func (a *Adapter) Batch(ops []model.BatchOp) ([]error, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return nil, model.ErrInvalidSession
+	}
+
+	for i, op := range ops {
+		if err := op.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: operation %v: %v", model.ErrBadRequest, i, err)
+		}
+
+		if op.Op == model.BatchCreate && op.ItemID() == "" {
+			ops[i] = op.WithItemID(uuid.NewString())
+		}
+	}
+
+	errs := make([]error, len(ops))
+	direct := make(map[string]error)
+
+	for i, op := range ops {
+		switch op.Op {
+		case model.BatchCreate:
+			errs[i] = a.cache.stage(op.DataType, opAdd, "", op.Item)
+		case model.BatchUpdate:
+			rev, err := a.cache.rev(op.DataType, op.ItemID())
+			if err != nil {
+				errs[i] = err
+
+				continue
+			}
+
+			kind := opUpdate
+			if rev == "" {
+				kind = opAdd
+			}
+
+			errs[i] = a.cache.stage(op.DataType, kind, rev, op.Item)
+		case model.BatchDelete:
+			var queued bool
+
+			// an item that has never reached the server is dropped locally
+			if queued, errs[i] = a.cache.remove(op.DataType, op.ID); !queued {
+				continue
+			}
+		}
+
+		if errs[i] == nil {
+			direct[op.ItemID()] = nil
+		}
+	}
+
+	err := a.sync(direct)
+	if errors.Is(err, ErrOffline) {
+		a.logger.Infof("%v item(s) are saved locally and will be sent once the server is reachable",
+			len(direct))
+	} else if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if errs[i] == nil {
+			errs[i] = direct[op.ItemID()]
+		}
+	}
+
+	return errs, nil
+}
+
+// GetCard requires the server to check CVV code,
+// full card numbers are never cached.
+func (a *Adapter) GetCard(id, cvvHash string) (model.ItemCard, error) {
+	if err := a.checkUnlocked(); err != nil {
+		return model.ItemCard{}, err
+	}
+
+	item, err := a.remote.GetCard(id, cvvHash)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return item, a.reach(err)
+}
+
+// Export requires the server, the archive is made of server
+// versions of items, changes not yet synchronised are left out.
+func (a *Adapter) Export(w io.Writer, cred model.Credentials, passphrase string) error {
+	if err := a.checkUnlocked(); err != nil {
+		return err
+	}
+
+	err := a.remote.Export(w, cred, passphrase)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.reach(err)
+}
+
+// Events streams changes made on the server. Changed items
+// are pulled to the cache on the next sync.
+func (a *Adapter) Events(ctx context.Context) (<-chan model.Change, error) {
+	if err := a.checkUnlocked(); err != nil {
+		return nil, err
+	}
+
+	events, err := a.remote.Events(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return events, a.reach(err)
+}
+
+// Sync sends queued changes to the server and pulls
+// server changes to the cache.
+func (a *Adapter) Sync() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.ErrInvalidSession
+	}
+
+	return a.sync(nil)
+}
+
+// Status reports whether the server is reachable and
+// how many changes wait to be synchronised.
+func (a *Adapter) Status() model.SyncStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	st := model.SyncStatus{Online: a.online}
+
+	if !a.unlocked() {
+		return st
+	}
+
+	st, err := a.cache.status()
+	if err != nil {
+		a.logger.Errorf("failed to get sync status: %v", err)
+	}
+
+	st.Online = a.online
+
+	return st
+}
+
+// Conflicts returns changes that could not be applied to the server.
+func (a *Adapter) Conflicts() ([]model.Conflict, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return nil, model.ErrInvalidSession
+	}
+
+	return a.cache.conflicts(0)
+}
+
+// ResolveConflict resolves conflict with given id.
+// If keepLocal is true local version of the item is queued
+// to overwrite the server version, otherwise local version
+// is discarded.
+func (a *Adapter) ResolveConflict(id int64, keepLocal bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.ErrInvalidSession
+	}
+
+	cfs, err := a.cache.conflicts(id)
+	if err != nil {
+		return err
+	}
+
+	if len(cfs) == 0 {
+		return model.ErrNotFound
+	}
+
+	cf := cfs[0]
+
+	if err := a.cache.dropConflict(cf.ID); err != nil {
+		return err
+	}
+
+	if !keepLocal {
+		return nil
+	}
+
+	// the item is deleted again, now based on
+	// the server version the user has just seen
+	if cf.Deleted {
+		if cf.Remote == nil {
+			return nil
+		}
+
+		return a.remove(cf.DataType, cf.ItemID)
+	}
+
+	// local version is based on the server version
+	// the user has just seen
+	kind, rev := opAdd, ""
+	if cf.Remote != nil {
+		kind = opUpdate
+		if rev, err = a.cache.revision(cf.Remote); err != nil {
+			return err
+		}
+	}
+
+	return a.save(cf.DataType, kind, rev, cf.Local)
+}
+
+func (a *Adapter) Lg() *zap.SugaredLogger {
+	return a.logger
+}
+
+// save stages a change and tries to send it right away.
+func (a *Adapter) save(dataType int, kind, rev string, data any) error {
+	if err := a.cache.stage(dataType, kind, rev, data); err != nil {
+		return err
+	}
+
+	id, _ := itemInfo(data)
+
+	return a.send(id)
+}
+
+// remove queues deletion of the item and tries to send it right away.
+func (a *Adapter) remove(dataType int, id string) error {
+	queued, err := a.cache.remove(dataType, id)
+	if err != nil || !queued {
+		return err
+	}
+
+	return a.send(id)
+}
+
+// send sends queued change of item with id along with others.
+// A change the server refuses is reported to the caller,
+// while a change that can't reach the server stays queued.
+func (a *Adapter) send(id string) error {
+	direct := map[string]error{id: nil}
+
+	err := a.sync(direct)
+	if errors.Is(err, ErrOffline) {
+		a.logger.Infof("change of item %v is saved locally and will be sent once the server is reachable", id)
+
+		return nil
+	}
+
+	if direct[id] != nil {
+		return direct[id]
+	}
+
+	return err
+}
+
+// sync sends queued changes to the server in batches and then pulls
+// server changes. If a change of item with ID in direct is refused
+// by the server, the error is put in direct instead of saving a conflict.
+func (a *Adapter) sync(direct map[string]error) error {
+	ops, err := a.cache.operations()
+	if err != nil {
+		return err
+	}
+
+	var (
+		// changes that passed revision check along with server
+		// versions they are based on
+		sent    []operation
+		remotes []any
+		batch   []model.BatchOp
+	)
+
+	// server items by data type, loaded once to check revisions
+	fetched := make(map[int][]any)
+
+	for _, o := range ops {
+		var (
+			remote any
+			reason string
+			err    error
+		)
+
+		switch o.Kind {
+		case opAdd:
+			sent, remotes = append(sent, o), append(remotes, nil)
+			batch = append(batch, batchOp(o))
+
+			continue
+		case opUpdate, opDelete:
+			if _, ok := fetched[o.DataType]; !ok {
+				fetched[o.DataType], err = a.fetch(o.DataType)
+			}
+
+			if err == nil {
+				remote, reason, err = a.check(o, fetched[o.DataType])
+			}
+
+			// an item deleted on the server as well needs nothing
+			if err == nil && o.Kind == opDelete && remote == nil {
+				reason = ""
+			} else if err == nil && reason == "" {
+				sent, remotes = append(sent, o), append(remotes, remote)
+				batch = append(batch, batchOp(o))
+
+				continue
+			}
+		}
+
+		if errors.Is(err, ErrOffline) {
+			return err
+		}
+
+		if err := a.settle(o, remote, reason, err, direct); err != nil {
+			return err
+		}
+	}
+
+	errs, err := a.push(batch)
+	if err != nil {
+		return err
+	}
+
+	for i, o := range sent {
+		// the item has been deleted on the server meanwhile
+		if o.Kind == opDelete && errors.Is(errs[i], model.ErrNotFound) {
+			errs[i] = nil
+		}
+
+		if err := a.settle(o, remotes[i], "", errs[i], direct); err != nil {
+			return err
+		}
+	}
+
+	if err := a.pull(); err != nil {
+		return err
+	}
+
+	return a.cache.markSynced(time.Now().UTC())
+}
+
+// settle drops queued change o that has been sent or found
+// conflicting. A refused or conflicting change is saved as a conflict,
+// unless its item is in direct, and the cached item is turned back
+// into the server version.
+func (a *Adapter) settle(o operation, remote any, reason string, err error, direct map[string]error) error {
+	_, isDirect := direct[o.ItemID]
+
+	if err != nil && isDirect {
+		direct[o.ItemID] = err
+	} else if err != nil {
+		reason = err.Error()
+	}
+
+	if reason != "" {
+		a.logger.Infof("conflict on item %v: %v", o.ItemID, reason)
+
+		if isDirect {
+			direct[o.ItemID] = fmt.Errorf("%w: %v", ErrConflict, reason)
+		}
+
+		if err := a.cache.addConflict(o, reason, remote); err != nil {
+			return err
+		}
+	}
+
+	if err := a.cache.dropOperation(o.Seq); err != nil {
+		return err
+	}
+
+	// the server version will not come with the change log
+	// if it has been pulled already, so it is restored right away
+	if err != nil || reason != "" {
+		return a.cache.apply([]model.Change{serverVersion(o, remote)}, "")
+	}
+
+	return nil
+}
+
+// push sends ops to the server, model.MaxBatchOps at a time,
+// and returns errors of refused ops in the order of ops.
+func (a *Adapter) push(ops []model.BatchOp) ([]error, error) {
+	errs := make([]error, len(ops))
+
+	for start := 0; start < len(ops); start += model.MaxBatchOps {
+		end := start + model.MaxBatchOps
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		if err := a.pushBatch(ops[start:end], errs[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	return errs, nil
+}
+
+// pushBatch sends ops in one batch and puts their errors in errs.
+// A batch the server finds too large is split in halves, an error
+// that refuses the whole batch is put in errs of all ops.
+func (a *Adapter) pushBatch(ops []model.BatchOp, errs []error) error {
+	res, err := a.remote.Batch(ops)
+	err = a.reach(err)
+
+	switch {
+	case errors.Is(err, ErrOffline):
+		return err
+	case errors.Is(err, model.ErrTooLarge) && len(ops) > 1:
+		half := len(ops) / 2
+		if err := a.pushBatch(ops[:half], errs[:half]); err != nil {
+			return err
+		}
+
+		return a.pushBatch(ops[half:], errs[half:])
+	case err != nil:
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return nil
+	}
+
+	// results are matched to ops by position, ops of a malformed
+	// response are left queued rather than taken as applied
+	if len(res) != len(ops) {
+		return fmt.Errorf("server answered batch of %v operations with %v results", len(ops), len(res))
+	}
+
+	for i, r := range res {
+		errs[i] = r.Err()
+	}
+
+	return nil
+}
+
+// batchOp returns the batch operation that sends o.
+func batchOp(o operation) model.BatchOp {
+	switch o.Kind {
+	case opAdd:
+		return model.BatchOp{Op: model.BatchCreate, DataType: o.DataType, Item: o.Item}
+	case opDelete:
+		return model.BatchOp{Op: model.BatchDelete, DataType: o.DataType, ID: o.ItemID}
+	}
+
+	return model.BatchOp{Op: model.BatchUpdate, DataType: o.DataType, Item: o.Item}
+}
+
+// serverVersion returns a change that turns the cached item
+// of a failed operation back into the server version.
+func serverVersion(o operation, remote any) model.Change {
+	c := model.Change{DataType: o.DataType, ID: o.ItemID, Kind: model.ChangeUpdated, Item: remote}
+	if remote == nil {
+		c.Kind = model.ChangeDeleted
+	}
+
+	return c
+}
+
+// check compares the server version of the item with the revision
+// the queued change is based on. Non-empty reason means the change
+// conflicts with the server version.
+func (a *Adapter) check(o operation, items []any) (remote any, reason string, err error) {
+	for _, item := range items {
+		if id, _ := itemInfo(item); id != o.ItemID {
+			continue
+		}
+
+		rev, err := a.cache.revision(item)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if rev != o.BaseRev {
+			return item, "item was changed on the server", nil
+		}
+
+		return item, "", nil
+	}
+
+	return nil, "item no longer exists on the server", nil
+}
+
+// pull applies server changes made since the last pull.
+func (a *Adapter) pull() error {
+	cursor, err := a.cache.cursor()
+	if err != nil {
+		return err
+	}
+
+	for {
+		changes, err := a.remote.Changes(cursor)
+		if err := a.reach(err); err != nil {
+			return err
+		}
+
+		if err := a.cache.apply(changes.Changes, changes.Cursor); err != nil {
+			return err
+		}
+
+		if !changes.HasMore {
+			return nil
+		}
+
+		cursor = changes.Cursor
+	}
+}
+
+// fetch loads dataType items from the server.
+func (a *Adapter) fetch(dataType int) ([]any, error) {
+	data, err := a.remote.GetData(dataType)
+	if err := a.reach(err); err != nil {
+		return nil, err
+	}
+
+	return splitItems(data)
+}
+
+// openAccount unlocks cached data of user. If verified is true
+// the password has been accepted by the server, so cached data
+// that can't be opened with it is dropped.
+func (a *Adapter) openAccount(cred model.Credentials, verified bool) error {
+	if err := a.openCache(); err != nil {
+		return err
+	}
+
+	account := a.account(cred.Login)
+
+	err := a.cache.unlock(account, cred.Password)
+	if verified && (errors.Is(err, errNoAccount) || errors.Is(err, errWrongPassword)) {
+		err = a.cache.reset(account, cred.Password)
+	}
+
+	if err == nil {
+		a.login = cred.Login
+	}
+
+	return err
+}
+
+// openCache opens the cache database unless it is open.
+func (a *Adapter) openCache() error {
+	if a.cache != nil {
+		return nil
+	}
+
+	dir := a.cfg.CacheDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c, err := openCache(filepath.Join(dir, cacheFile))
+	if err != nil {
+		return err
+	}
+
+	a.cache = c
+
+	return nil
+}
+
+// account returns cache account of user with login.
+// The same login may belong to different users on different servers.
+func (a *Adapter) account(login string) string {
+	return login + "@" + a.cfg.SrvAddr()
+}
+
+func (a *Adapter) logout() {
+	a.remote.Resume(model.Session{})
+	a.login = ""
+
+	if a.cache != nil {
+		a.cache.lock()
+	}
+}
+
+// unlocked reports whether user's cached data is open.
+func (a *Adapter) unlocked() bool {
+	return a.cache != nil && a.cache.key != nil
+}
+
+// checkUnlocked returns model.ErrInvalidSession unless user's
+// cached data is open. It is meant for calls that go to the server
+// without holding the lock, so the session is not used while locked.
+func (a *Adapter) checkUnlocked() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.unlocked() {
+		return model.ErrInvalidSession
+	}
+
+	return nil
+}
+
+func (a *Adapter) setOnline(online bool, err error) {
+	if a.online != online {
+		if online {
+			a.logger.Infof("server is reachable")
+		} else {
+			a.logger.Infof("server is unreachable: %v", err)
+		}
+	}
+
+	a.online = online
+}
+
+// reach updates online state by the result of a server call.
+// Transport errors mean the server is unreachable and are wrapped
+// with ErrOffline. Any other error, e.g. a response that could not
+// be decoded, is returned as is.
+func (a *Adapter) reach(err error) error {
+	var e *model.Error
+
+	switch {
+	case err == nil, errors.As(err, &e):
+		a.setOnline(true, nil)
+	case unreachable(err):
+		a.setOnline(false, err)
+
+		return fmt.Errorf("%w: %v", ErrOffline, err)
+	}
+
+	return err
+}
+
+// unreachable reports whether err is a transport error
+// of http or gRPC adapter.
+func unreachable(err error) bool {
+	var (
+		netErr net.Error
+		urlErr *url.Error
+		st     interface{ GRPCStatus() *status.Status }
+	)
+
+	switch {
+	case errors.As(err, &netErr), errors.As(err, &urlErr):
+		return true
+	case errors.As(err, &st):
+		code := st.GRPCStatus().Code()
+
+		return code == codes.Unavailable || code == codes.DeadlineExceeded
+	}
+
+	return false
+}
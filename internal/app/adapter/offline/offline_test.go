@@ -0,0 +1,779 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+var errDown error = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+// changesPage is small so that pulls take several pages
+const changesPage = 2
+
+// fakeRemote keeps items of a single user in memory.
+// While down it fails every call as if the server was unreachable.
+type fakeRemote struct {
+	mu      sync.Mutex
+	down    bool
+	cred    model.Credentials
+	items   [model.KeyLimit][]any
+	changes []model.Change
+	seq     int64
+	pulled  int
+	session model.Session
+	// revoked fails calls with saved session
+	revoked bool
+	// batches counts Batch calls, batches of more
+	// than maxBatch ops are refused as too large
+	batches  int
+	maxBatch int
+	// short drops the last result of batches, as a broken server would
+	short bool
+}
+
+func (r *fakeRemote) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.down = down
+}
+
+func (r *fakeRemote) Login(cred model.Credentials) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		return errDown
+	}
+
+	if cred != r.cred {
+		return model.ErrUnauthorized
+	}
+
+	r.session = model.Session{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}
+
+	return nil
+}
+
+func (r *fakeRemote) Register(cred model.Credentials) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		return errDown
+	}
+
+	r.cred = cred
+	r.session = model.Session{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}
+
+	return nil
+}
+
+func (r *fakeRemote) Usage() (model.Usage, error) {
+	return model.Usage{}, nil
+}
+
+func (r *fakeRemote) GetData(dataType int) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		return nil, errDown
+	}
+
+	msg, err := model.EncodeItemsJSON(r.items[dataType])
+	if err != nil {
+		return nil, err
+	}
+
+	return model.DecodeItemsJSON(dataType, msg)
+}
+
+func (r *fakeRemote) AddData(dataType int, data any) error {
+	return r.put(dataType, data)
+}
+
+func (r *fakeRemote) UpdateData(dataType int, data any) error {
+	return r.put(dataType, data)
+}
+
+func (r *fakeRemote) put(dataType int, data any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		return errDown
+	}
+
+	id, _ := itemInfo(data)
+	r.logChange(dataType, id, model.ChangeUpdated, data)
+
+	for i, item := range r.items[dataType] {
+		if itemID, _ := itemInfo(item); itemID == id {
+			r.items[dataType][i] = data
+			return nil
+		}
+	}
+
+	r.items[dataType] = append(r.items[dataType], data)
+
+	return nil
+}
+
+func (r *fakeRemote) remove(dataType int, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logChange(dataType, id, model.ChangeDeleted, nil)
+
+	for i, item := range r.items[dataType] {
+		if itemID, _ := itemInfo(item); itemID == id {
+			r.items[dataType] = append(r.items[dataType][:i], r.items[dataType][i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *fakeRemote) DeleteData(dataType int, id string) error {
+	r.mu.Lock()
+
+	if r.down {
+		r.mu.Unlock()
+
+		return errDown
+	}
+
+	found := false
+
+	for _, item := range r.items[dataType] {
+		if itemID, _ := itemInfo(item); itemID == id {
+			found = true
+		}
+	}
+
+	r.mu.Unlock()
+
+	if !found {
+		return model.ErrNotFound
+	}
+
+	r.remove(dataType, id)
+
+	return nil
+}
+
+func (r *fakeRemote) Batch(ops []model.BatchOp) ([]model.BatchResult, error) {
+	r.mu.Lock()
+	r.batches++
+
+	if r.down {
+		r.mu.Unlock()
+
+		return nil, errDown
+	}
+
+	if r.maxBatch != 0 && len(ops) > r.maxBatch {
+		r.mu.Unlock()
+
+		return nil, model.ErrTooLarge
+	}
+
+	short := r.short
+	r.mu.Unlock()
+
+	res := make([]model.BatchResult, len(ops))
+
+	for i, op := range ops {
+		res[i].ID = op.ItemID()
+
+		var err error
+		if op.Op == model.BatchDelete {
+			err = r.DeleteData(op.DataType, op.ID)
+		} else {
+			err = r.put(op.DataType, op.Item)
+		}
+
+		if err != nil {
+			res[i].Error = err.(*model.Error)
+		}
+	}
+
+	if short {
+		res = res[:len(res)-1]
+	}
+
+	return res, nil
+}
+
+// logChange keeps the latest change of every item in commit order.
+func (r *fakeRemote) logChange(dataType int, id, kind string, item any) {
+	for i, c := range r.changes {
+		if c.DataType == dataType && c.ID == id {
+			r.changes = append(r.changes[:i], r.changes[i+1:]...)
+			break
+		}
+	}
+
+	r.seq++
+	r.changes = append(r.changes, model.Change{
+		Seq:      r.seq,
+		DataType: dataType,
+		ID:       id,
+		Kind:     kind,
+		Item:     item,
+	})
+}
+
+func (r *fakeRemote) Changes(cursor string) (model.Changes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		return model.Changes{}, errDown
+	}
+
+	if r.revoked {
+		return model.Changes{}, model.ErrInvalidSession
+	}
+
+	var since int64
+	if cursor != "" {
+		since, _ = strconv.ParseInt(cursor, 10, 64)
+	}
+
+	res := model.Changes{Changes: make([]model.Change, 0), Cursor: cursor}
+
+	for _, c := range r.changes {
+		if c.Seq <= since {
+			continue
+		}
+
+		if len(res.Changes) == changesPage {
+			res.HasMore = true
+			break
+		}
+
+		res.Changes = append(res.Changes, c)
+		res.Cursor = strconv.FormatInt(c.Seq, 10)
+		r.pulled++
+	}
+
+	return res, nil
+}
+
+func (r *fakeRemote) GetCard(id, cvvHash string) (model.ItemCard, error) {
+	return model.ItemCard{}, model.ErrNotFound
+}
+
+func (r *fakeRemote) Export(w io.Writer, cred model.Credentials, passphrase string) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeRemote) Events(ctx context.Context) (<-chan model.Change, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeRemote) Session() model.Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.session
+}
+
+func (r *fakeRemote) Resume(s model.Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.session = s
+}
+
+type testConfig string
+
+func (c testConfig) SrvAddr() string {
+	return "localhost:8080"
+}
+
+func (c testConfig) CacheDir() string {
+	return string(c)
+}
+
+func newTestAdapter(t *testing.T, remote *fakeRemote, dir string) *Adapter {
+	a := New(remote, testConfig(dir), nil)
+	t.Cleanup(func() {
+		if a.cache != nil {
+			a.cache.close()
+		}
+	})
+
+	return a
+}
+
+func TestOfflineReadsAndQueuedWrites(t *testing.T) {
+	remote := &fakeRemote{}
+	a := newTestAdapter(t, remote, t.TempDir())
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	require.NoError(t, a.Register(cred))
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{Name: "first", Text: "online"}))
+
+	remote.setDown(true)
+
+	res, err := a.GetData(model.KeyText)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "online", res.([]model.ItemText)[0].Text)
+
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{Name: "second", Text: "offline"}))
+
+	st := a.Status()
+	assert.False(t, st.Online)
+	assert.Equal(t, 1, st.Pending)
+
+	n, err := a.Count(model.KeyText)
+	require.NoError(t, err)
+	assert.Equal(t, "2", n)
+
+	assert.ErrorIs(t, a.Sync(), ErrOffline)
+
+	remote.setDown(false)
+
+	require.NoError(t, a.Sync())
+
+	st = a.Status()
+	assert.True(t, st.Online)
+	assert.Equal(t, 0, st.Pending)
+	assert.False(t, st.SyncedAt.IsZero())
+
+	require.Len(t, remote.items[model.KeyText], 2)
+	assert.Equal(t, "offline", remote.items[model.KeyText][1].(model.ItemText).Text)
+}
+
+func TestOfflineLogin(t *testing.T) {
+	remote := &fakeRemote{}
+	dir := t.TempDir()
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	a := newTestAdapter(t, remote, dir)
+	require.NoError(t, a.Register(cred))
+	require.NoError(t, a.AddData(model.KeyCredentials, model.ItemCredentials{
+		Name:        "mail",
+		Credentials: model.Credentials{Login: "me", Password: "Tr0ub4dor&3"},
+	}))
+
+	remote.setDown(true)
+
+	a = newTestAdapter(t, remote, dir)
+	assert.ErrorIs(t, a.Login(model.Credentials{Login: "user", Password: "wrong"}), model.ErrUnauthorized)
+	assert.ErrorIs(t, a.Login(model.Credentials{Login: "stranger", Password: "secret"}), ErrOffline)
+
+	require.NoError(t, a.Login(cred))
+
+	res, err := a.GetData(model.KeyCredentials)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "Tr0ub4dor&3", res.([]model.ItemCredentials)[0].Credentials.Password)
+
+	// nothing but ciphertext is written to disk
+	b, err := os.ReadFile(filepath.Join(dir, cacheFile))
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(b, []byte("Tr0ub4dor&3")))
+	assert.False(t, bytes.Contains(b, []byte("mail")))
+}
+
+func TestConflicts(t *testing.T) {
+	remote := &fakeRemote{}
+	a := newTestAdapter(t, remote, t.TempDir())
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	require.NoError(t, a.Register(cred))
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{Name: "note", Text: "v1"}))
+
+	res, err := a.GetData(model.KeyText)
+	require.NoError(t, err)
+	item := res.([]model.ItemText)[0]
+
+	remote.setDown(true)
+
+	item.Text = "mine"
+	require.NoError(t, a.UpdateData(model.KeyText, item))
+
+	// the item is changed on another device meanwhile
+	remote.setDown(false)
+	theirs := item
+	theirs.Text = "theirs"
+	require.NoError(t, remote.UpdateData(model.KeyText, theirs))
+
+	require.NoError(t, a.Sync())
+
+	cfs, err := a.Conflicts()
+	require.NoError(t, err)
+	require.Len(t, cfs, 1)
+	assert.Equal(t, item, cfs[0].Local)
+	assert.Equal(t, theirs, cfs[0].Remote)
+	assert.Equal(t, 1, a.Status().Conflicts)
+
+	// server version wins until the conflict is resolved
+	res, err = a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Equal(t, "theirs", res.([]model.ItemText)[0].Text)
+
+	require.NoError(t, a.ResolveConflict(cfs[0].ID, true))
+	assert.Equal(t, "mine", remote.items[model.KeyText][0].(model.ItemText).Text)
+
+	st := a.Status()
+	assert.Equal(t, 0, st.Conflicts)
+	assert.Equal(t, 0, st.Pending)
+
+	// a change based on a stale version is refused right away
+	require.NoError(t, remote.UpdateData(model.KeyText, theirs))
+	item.Text = "stale"
+	err = a.UpdateData(model.KeyText, item)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestPullChanges(t *testing.T) {
+	remote := &fakeRemote{}
+	a := newTestAdapter(t, remote, t.TempDir())
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	require.NoError(t, a.Register(cred))
+
+	// items added by another device
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, remote.AddData(model.KeyText, model.ItemText{ID: name, Name: name}))
+	}
+
+	require.NoError(t, a.Sync())
+
+	res, err := a.GetData(model.KeyText)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	// nothing but new changes is downloaded
+	pulled := remote.pulled
+
+	require.NoError(t, remote.UpdateData(model.KeyText, model.ItemText{ID: "b", Name: "b", Text: "changed"}))
+	remote.remove(model.KeyText, "a")
+
+	require.NoError(t, a.Sync())
+	assert.Equal(t, pulled+2, remote.pulled)
+
+	res, err = a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ItemText{
+		{ID: "b", Name: "b", Text: "changed"},
+		{ID: "c", Name: "c"},
+	}, res)
+
+	// an item with a queued change is not touched by pulls
+	remote.setDown(true)
+	require.NoError(t, a.UpdateData(model.KeyText, model.ItemText{ID: "c", Name: "c", Text: "mine"}))
+	remote.setDown(false)
+
+	remote.remove(model.KeyText, "c")
+	require.NoError(t, a.pull())
+
+	res, err = a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Len(t, res, 1, "deleted item must be dropped once its change fails")
+
+	cfs, err := a.Conflicts()
+	require.NoError(t, err)
+	require.Len(t, cfs, 1)
+	assert.Nil(t, cfs[0].Remote)
+}
+
+func TestDeleteData(t *testing.T) {
+	remote := &fakeRemote{}
+	a := newTestAdapter(t, remote, t.TempDir())
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	require.NoError(t, a.Register(cred))
+
+	for _, id := range []string{"synced", "changed", "gone"} {
+		require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: id, Name: id}))
+	}
+
+	remote.setDown(true)
+
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "queued", Name: "queued"}))
+
+	for _, id := range []string{"synced", "changed", "gone", "queued"} {
+		require.NoError(t, a.DeleteData(model.KeyText, id), "deletes are queued")
+	}
+
+	res, err := a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Empty(t, res, "deleted items are hidden right away")
+
+	// the item that has never reached the server is dropped
+	assert.Equal(t, 3, a.Status().Pending)
+
+	err = a.UpdateData(model.KeyText, model.ItemText{ID: "synced", Name: "again"})
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// items are changed on another device meanwhile
+	remote.setDown(false)
+	require.NoError(t, remote.UpdateData(model.KeyText, model.ItemText{ID: "changed", Name: "theirs"}))
+	remote.remove(model.KeyText, "gone")
+
+	require.NoError(t, a.Sync())
+	assert.Equal(t, 0, a.Status().Pending)
+
+	require.Len(t, remote.items[model.KeyText], 1)
+	assert.Equal(t, "theirs", remote.items[model.KeyText][0].(model.ItemText).Name)
+
+	cfs, err := a.Conflicts()
+	require.NoError(t, err)
+	require.Len(t, cfs, 1)
+	assert.True(t, cfs[0].Deleted)
+	assert.Equal(t, "changed", cfs[0].Name)
+
+	// server version is back until the conflict is resolved
+	res, err = a.GetData(model.KeyText)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "theirs", res.([]model.ItemText)[0].Name)
+
+	require.NoError(t, a.ResolveConflict(cfs[0].ID, true))
+	assert.Empty(t, remote.items[model.KeyText])
+
+	res, err = a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	assert.ErrorIs(t, a.DeleteData(model.KeyText, "missing"), model.ErrNotFound)
+}
+
+func TestResume(t *testing.T) {
+	remote := &fakeRemote{}
+	dir := t.TempDir()
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	a := newTestAdapter(t, remote, dir)
+
+	_, err := a.Session()
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	require.NoError(t, a.Register(cred))
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "1", Name: "note"}))
+
+	s, err := a.Session()
+	require.NoError(t, err)
+	assert.Equal(t, "user", s.Login)
+	assert.Equal(t, "token", s.Token)
+	assert.NotEmpty(t, s.CacheKey)
+
+	a.Logout()
+
+	_, err = a.GetData(model.KeyText)
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	// a restarted client resumes the session even offline
+	remote.setDown(true)
+
+	b := newTestAdapter(t, remote, dir)
+	require.NoError(t, b.Resume(s))
+
+	res, err := b.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	remote.setDown(false)
+
+	expired := s
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.ErrorIs(t, b.Resume(expired), model.ErrInvalidSession)
+
+	wrongKey := s
+	wrongKey.CacheKey = bytes.Repeat([]byte{1}, len(s.CacheKey))
+	assert.ErrorIs(t, b.Resume(wrongKey), model.ErrInvalidSession)
+
+	remote.revoked = true
+
+	assert.ErrorIs(t, b.Resume(s), model.ErrInvalidSession)
+
+	_, err = b.GetData(model.KeyText)
+	assert.ErrorIs(t, err, model.ErrInvalidSession, "cache is locked")
+}
+
+func TestLock(t *testing.T) {
+	remote := &fakeRemote{}
+	a := newTestAdapter(t, remote, t.TempDir())
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	assert.ErrorIs(t, a.Unlock("secret"), model.ErrInvalidSession)
+
+	require.NoError(t, a.Register(cred))
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "1", Name: "note"}))
+
+	key := a.cache.key
+
+	a.Lock()
+
+	assert.Equal(t, make([]byte, len(key)), key, "the key is zeroed")
+
+	_, err := a.GetData(model.KeyText)
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	// calls that go to the server are refused as well
+	_, err = a.Usage()
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	_, err = a.GetCard("1", "hash")
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	err = a.Export(io.Discard, cred, "passphrase")
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	_, err = a.Events(context.Background())
+	assert.ErrorIs(t, err, model.ErrInvalidSession)
+
+	assert.ErrorIs(t, a.Unlock("wrong"), model.ErrUnauthorized)
+
+	// the password is checked offline
+	remote.setDown(true)
+	require.NoError(t, a.Unlock("secret"))
+
+	res, err := a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "token", a.remote.Session().Token, "the session is kept")
+}
+
+func TestBatch(t *testing.T) {
+	remote := &fakeRemote{maxBatch: 2}
+	a := newTestAdapter(t, remote, t.TempDir())
+	cred := model.Credentials{Login: "user", Password: "secret"}
+
+	require.NoError(t, a.Register(cred))
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "old", Name: "old"}))
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "gone", Name: "gone"}))
+
+	remote.setDown(true)
+
+	// queued changes go along with the batch
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "queued", Name: "queued"}))
+
+	errs, err := a.Batch([]model.BatchOp{{Op: model.BatchDelete, DataType: model.KeyText, ID: "gone"}})
+	require.NoError(t, err, "deletes are queued")
+	assert.Equal(t, []error{nil}, errs)
+
+	remote.setDown(false)
+
+	ops := []model.BatchOp{
+		{Op: model.BatchCreate, DataType: model.KeyText, Item: model.ItemText{Name: "new"}},
+		{Op: model.BatchUpdate, DataType: model.KeyText, Item: model.ItemText{ID: "old", Name: "old", Text: "changed"}},
+		{Op: model.BatchDelete, DataType: model.KeyCards, ID: "missing"},
+	}
+
+	batches := remote.batches
+
+	errs, err = a.Batch(ops)
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+
+	assert.NoError(t, errs[0])
+	assert.NotEmpty(t, ops[0].ItemID(), "ID is set on the client")
+	assert.NoError(t, errs[1])
+	assert.ErrorIs(t, errs[2], model.ErrNotFound)
+
+	// four changes are refused as too large and sent in halves,
+	// the missing card is not sent at all
+	assert.Equal(t, batches+3, remote.batches)
+
+	res, err := a.GetData(model.KeyText)
+	require.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Len(t, remote.items[model.KeyText], 3)
+	assert.Equal(t, 0, a.Status().Pending)
+
+	_, err = a.Batch([]model.BatchOp{{Op: model.BatchUpdate, DataType: model.KeyText, Item: model.ItemText{}}})
+	assert.ErrorIs(t, err, model.ErrBadRequest)
+
+	remote.setDown(true)
+	require.NoError(t, a.AddData(model.KeyText, model.ItemText{ID: "short", Name: "short"}))
+	remote.setDown(false)
+
+	remote.mu.Lock()
+	remote.short = true
+	remote.mu.Unlock()
+
+	assert.Error(t, a.Sync(), "results must match operations")
+	assert.Equal(t, 1, a.Status().Pending, "the change is kept queued")
+}
+
+func TestCacheUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), cacheFile)
+
+	// a cache created by a version without upgrades
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(schema)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	for i := 0; i < 2; i++ {
+		c, err := openCache(path)
+		require.NoError(t, err, "open %v", i)
+
+		var version int
+		require.NoError(t, c.db.QueryRow(`PRAGMA user_version`).Scan(&version))
+		assert.Equal(t, len(upgrades), version)
+
+		_, err = c.db.Exec(`SELECT op FROM conflicts`)
+		assert.NoError(t, err)
+		require.NoError(t, c.close())
+	}
+}
+
+func TestReach(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		offline bool
+	}{
+		{"no error", nil, false},
+		{"server error", model.ErrNotFound, false},
+		{"bad response", errors.New("failed to decode server message"), false},
+		{"http", fmt.Errorf("GetData request failed: %w", &url.Error{Op: "Get", URL: "/", Err: errDown}), true},
+		{"net", errDown, true},
+		{"grpc unavailable", fmt.Errorf("GetData call failed: %w", status.Error(codes.Unavailable, "")), true},
+		{"grpc deadline", status.Error(codes.DeadlineExceeded, ""), true},
+		{"grpc canceled", status.Error(codes.Canceled, ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAdapter(t, &fakeRemote{}, t.TempDir())
+			a.online = true
+
+			err := a.reach(tt.err)
+			assert.Equal(t, tt.offline, errors.Is(err, ErrOffline))
+			assert.Equal(t, !tt.offline, a.online)
+
+			if !tt.offline {
+				assert.Equal(t, tt.err, err, "the error is returned as is")
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/usa4ev/ghostorange/internal/app/metrics"
 	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
 )
 
@@ -24,7 +26,10 @@ func Login(ctx context.Context, userName, password string, p UsrStorage) (string
 		return "", ErrUnathorized
 	}
 
+	start := time.Now()
 	ok, err := argon2hash.ComparePasswordAndHash(password, pwdHash)
+	metrics.ObserveHash("compare", start)
+
 	if err != nil {
 		return "", fmt.Errorf("failed to verify password: %w", err)
 	}
@@ -46,7 +51,9 @@ func RegisterUser(ctx context.Context, userName, password string, us UsrStorage)
 		return "", err
 	}
 
+	start := time.Now()
 	hash, err := argon2hash.GenerateFromPassword(password, argon2hash.DefaultParams())
+	metrics.ObserveHash("generate", start)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to generate hash from password: %w", err)
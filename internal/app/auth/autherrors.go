@@ -5,4 +5,5 @@ import "fmt"
 var (
 	ErrUserAlreadyExists = fmt.Errorf("user already exists")
 	ErrUnathorized       = fmt.Errorf("wrong login or password")
+	ErrInvalidCVV        = fmt.Errorf("passed CVV code is not valid")
 )
@@ -26,14 +26,14 @@ func Verify(signedString string) (string, error) {
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("token is not valid: %w", err)
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		return claims["userID"].(string), nil
 	}
 
-	return "", fmt.Errorf("token does not contain user id")
+	return "", fmt.Errorf("%w: token does not contain user id", ErrInvalidToken)
 }
 
 // Open opens new session and returns
@@ -56,15 +56,25 @@ func Open(userID string, lifeTime time.Duration) (string, time.Time, error) {
 
 const CtxKeyUserID contextKey = 0 // key to a userID context value
 
-var sessionErrNoUserID = errors.New("request ctx does not contain userID key")
+var (
+	sessionErrNoUserID = errors.New("request ctx does not contain userID key")
+
+	// ErrInvalidToken is returned when session token is malformed or expired
+	ErrInvalidToken = errors.New("session token is not valid")
+)
 
 type contextKey int
 
 // ReqWithSession adds userID value with CtxKeyUserID key 
 // to a given ctx
 func ReqWithSession(r *http.Request, usrID string) *http.Request {
-	ctx := context.WithValue(r.Context(), CtxKeyUserID, usrID)
-	return r.WithContext(ctx)
+	return r.WithContext(CtxWithSession(r.Context(), usrID))
+}
+
+// CtxWithSession adds userID value with CtxKeyUserID key
+// to a given ctx, e.g. of a gRPC call
+func CtxWithSession(ctx context.Context, usrID string) context.Context {
+	return context.WithValue(ctx, CtxKeyUserID, usrID)
 }
 
 // UserIDFromCtx return userID from reques ctx value.
@@ -0,0 +1,175 @@
+// Package backup reads and writes vault archives. An archive holds
+// every item of a user, binary data and full card numbers included,
+// so it is always encrypted with a passphrase (see cryptostream).
+//
+// Inside the encrypted stream the archive is JSON lines: a header
+// followed by one record per item, so items are written and read
+// one at a time. Callers decide how many of them they hold.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/cryptostream"
+)
+
+const (
+	// FileExt is the extension of archive files.
+	FileExt = ".goarchive"
+	// MinPassphrase is the minimum length of archive passphrase.
+	MinPassphrase = 8
+	// HeaderPassphrase is the header export request passes the
+	// passphrase in, so it is never logged as part of URL.
+	HeaderPassphrase = "X-Export-Passphrase"
+
+	format  = "ghostorange"
+	version = 1
+)
+
+// ErrPassphrase is returned when the passphrase is too short.
+var ErrPassphrase = fmt.Errorf("passphrase must be at least %v characters", MinPassphrase)
+
+type (
+	// Writer writes items to an archive.
+	Writer struct {
+		cs  *cryptostream.Writer
+		enc *json.Encoder
+	}
+
+	// Reader reads items of an archive.
+	Reader struct {
+		dec    *json.Decoder
+		header header
+	}
+
+	header struct {
+		Format    string    `json:"format"`
+		Version   int       `json:"version"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	record struct {
+		DataType int             `json:"data_type"`
+		Item     json.RawMessage `json:"item"`
+	}
+)
+
+// CheckPassphrase returns ErrPassphrase if passphrase is too short.
+func CheckPassphrase(passphrase string) error {
+	if len([]rune(passphrase)) < MinPassphrase {
+		return ErrPassphrase
+	}
+
+	return nil
+}
+
+// NewWriter starts an archive encrypted with passphrase.
+func NewWriter(w io.Writer, passphrase string) (*Writer, error) {
+	if err := CheckPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	cs, err := cryptostream.NewWriter(w, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	aw := &Writer{cs: cs, enc: json.NewEncoder(cs)}
+
+	if err := aw.enc.Encode(header{Format: format, Version: version, CreatedAt: time.Now().UTC()}); err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// Add writes item of dataType.
+func (w *Writer) Add(dataType int, item any) error {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode item: %w", err)
+	}
+
+	return w.enc.Encode(record{DataType: dataType, Item: b})
+}
+
+// Close ends the archive. An archive that is not closed
+// fails to be read as truncated.
+func (w *Writer) Close() error {
+	return w.cs.Close()
+}
+
+// NewReader opens archive encrypted with passphrase.
+func NewReader(r io.Reader, passphrase string) (*Reader, error) {
+	cs, err := cryptostream.NewReader(r, passphrase)
+	if err != nil {
+		return nil, readErr(err)
+	}
+
+	ar := &Reader{dec: json.NewDecoder(cs)}
+
+	if err := ar.dec.Decode(&ar.header); err != nil {
+		return nil, readErr(err)
+	}
+
+	if ar.header.Format != format {
+		return nil, fmt.Errorf("not a vault archive")
+	}
+
+	if ar.header.Version != version {
+		return nil, fmt.Errorf("unsupported archive version %v", ar.header.Version)
+	}
+
+	return ar, nil
+}
+
+// CreatedAt returns the time the archive has been made at.
+func (r *Reader) CreatedAt() time.Time {
+	return r.header.CreatedAt
+}
+
+// Next returns the next item and its data type.
+// At the end of archive it returns io.EOF.
+func (r *Reader) Next() (int, any, error) {
+	var rec record
+	if err := r.dec.Decode(&rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.EOF
+		}
+
+		return 0, nil, readErr(err)
+	}
+
+	if rec.DataType < 0 || rec.DataType >= model.KeyLimit {
+		return 0, nil, fmt.Errorf("archive holds unknown data type %v", rec.DataType)
+	}
+
+	item, err := model.DecodeItemJSON(rec.DataType, rec.Item)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decode archived item: %w", err)
+	}
+
+	return rec.DataType, item, nil
+}
+
+// readErr describes errors of archive stream.
+func readErr(err error) error {
+	switch {
+	case errors.Is(err, cryptostream.ErrFormat):
+		return fmt.Errorf("not a vault archive: %w", err)
+	case errors.Is(err, cryptostream.ErrDecrypt), errors.Is(err, cryptostream.ErrTruncated):
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	return err
+}
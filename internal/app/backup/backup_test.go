@@ -0,0 +1,85 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/cryptostream"
+)
+
+const passphrase = "correct horse battery"
+
+var items = []struct {
+	dataType int
+	item     any
+}{
+	{model.KeyCredentials, model.ItemCredentials{ID: "1", Name: "mail",
+		Credentials: model.Credentials{Login: "me", Password: "Tr0ub4dor&3"}}},
+	{model.KeyText, model.ItemText{ID: "2", Name: "note", Text: "text"}},
+	{model.KeyBinary, model.ItemBinary{ID: "3", Name: "key.pem", Data: "a2V5", Size: 3}},
+	{model.KeyCards, model.ItemCard{ID: "4", Name: "visa", Number: "4111111111111111",
+		Exp: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), CVVHash: "hash"}},
+}
+
+func archive(t *testing.T, closed bool) []byte {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, passphrase)
+	require.NoError(t, err)
+
+	for _, it := range items {
+		require.NoError(t, w.Add(it.dataType, it.item))
+	}
+
+	if closed {
+		require.NoError(t, w.Close())
+	}
+
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	b := archive(t, true)
+	assert.False(t, bytes.Contains(b, []byte("4111111111111111")))
+
+	r, err := NewReader(bytes.NewReader(b), passphrase)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), r.CreatedAt(), time.Minute)
+
+	for _, it := range items {
+		dataType, item, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, it.dataType, dataType)
+		assert.Equal(t, it.item, item)
+	}
+
+	_, _, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadErrors(t *testing.T) {
+	_, err := NewWriter(io.Discard, "short")
+	assert.ErrorIs(t, err, ErrPassphrase)
+
+	_, err = NewReader(bytes.NewReader(archive(t, true)), "wrong passphrase")
+	assert.ErrorIs(t, err, cryptostream.ErrDecrypt)
+
+	_, err = NewReader(bytes.NewReader([]byte(`{"format":"ghostorange"}`)), passphrase)
+	assert.ErrorIs(t, err, cryptostream.ErrFormat)
+
+	// archive of a failed export is not closed
+	r, err := NewReader(bytes.NewReader(archive(t, false)), passphrase)
+	if err == nil {
+		for err == nil {
+			_, _, err = r.Next()
+		}
+	}
+
+	assert.ErrorIs(t, err, cryptostream.ErrTruncated)
+}
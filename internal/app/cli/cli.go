@@ -0,0 +1,801 @@
+// Package cli implements goctl, a non-interactive client for scripts.
+// Commands print results to stdout and errors to stderr, the outcome
+// is reported with the exit code.
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/usa4ev/ghostorange/internal/app/adapter/offline"
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/importer"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/cryptostream"
+	"github.com/usa4ev/ghostorange/internal/pkg/passgen"
+)
+
+// Environment variables that hold user's credentials.
+const (
+	EnvLogin    = "GHOSTORANGE_LOGIN"
+	EnvPassword = "GHOSTORANGE_PASSWORD"
+)
+
+// Exit codes.
+const (
+	ExitOK = iota
+	ExitError
+	ExitUsage
+	// ExitUnauthorized is returned on wrong credentials,
+	// CVV code or archive passphrase
+	ExitUnauthorized
+	ExitNotFound
+	// ExitOffline is returned when a command requires the server
+	// and the server is unreachable
+	ExitOffline
+)
+
+// Output formats.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+type (
+	adapter interface {
+		Login(model.Credentials) error
+		Session() (model.Session, error)
+		Resume(model.Session) error
+		Logout()
+
+		GetData(dataType int) (any, error)
+		AddData(dataType int, data any) error
+		UpdateData(dataType int, data any) error
+		DeleteData(dataType int, id string) error
+		Batch(ops []model.BatchOp) ([]error, error)
+		GetCard(id, cvv string) (model.ItemCard, error)
+		Export(w io.Writer, cred model.Credentials, passphrase string) error
+
+		Status() model.SyncStatus
+	}
+
+	sessionStore interface {
+		Load() (model.Session, error)
+		Save(model.Session) error
+		Delete() error
+	}
+
+	CLI struct {
+		adapter  adapter
+		cred     model.Credentials
+		sessions sessionStore
+		stdin    io.Reader
+		stdout   io.Writer
+		stderr   io.Writer
+	}
+
+	command struct {
+		name    string
+		args    string
+		summary string
+		run     func(c *CLI, fs *flag.FlagSet, args []string) error
+	}
+
+	// usageError is returned when a command is called wrong.
+	usageError struct {
+		msg string
+	}
+)
+
+var commands = []command{
+	{"login", "", "log in, refresh local cache and keep session for later commands", (*CLI).login},
+	{"logout", "", "forget kept session", (*CLI).logout},
+	{"ls", "<type>", "list items", (*CLI).list},
+	{"get", "<type> <id|name> [--field name]", "print item or its single field", (*CLI).get},
+	{"add", "<type> [--json] [field=value ...]", "add item, print its ID", (*CLI).add},
+	{"edit", "<type> <id|name> [--json] [field=value ...]", "change item fields", (*CLI).edit},
+	{"rm", "<type> <id|name>", "delete item", (*CLI).remove},
+	{"reveal-card", "<id|name> [--field name]", "print card with full number, CVV is read from stdin", (*CLI).revealCard},
+	{"generate", "[--length n] [--no-symbols ...] [--words n]", "print random password or passphrase", (*CLI).generate},
+	{"import", "<file> [--format name] [--dry-run]", "import export of KeePass, Bitwarden or 1Password", (*CLI).importFile},
+	{"export", "<file>", "save encrypted archive of all items, passphrase is read from stdin, credentials are required", (*CLI).export},
+	{"restore", "<file> [--dry-run]", "add items of archive made by export, passphrase is read from stdin", (*CLI).restore},
+}
+
+// New returns CLI that logs in with cred and talks to the server
+// through a. If cred is empty, the session kept in sessions by
+// the last login command is resumed. Secrets are read from stdin
+// so that they do not show up in process list.
+func New(a adapter, cred model.Credentials, sessions sessionStore,
+	stdin io.Reader, stdout, stderr io.Writer,
+) *CLI {
+	return &CLI{
+		adapter:  a,
+		cred:     cred,
+		sessions: sessions,
+		stdin:    stdin,
+		stdout:   stdout,
+		stderr:   stderr,
+	}
+}
+
+// Run runs command args[0] with the rest of args
+// and returns the exit code.
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 {
+		c.usage()
+
+		return ExitUsage
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+
+		return ExitOK
+	}
+
+	var cmd *command
+
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+
+	if cmd == nil {
+		fmt.Fprintf(c.stderr, "goctl: unknown command %q\n\n", args[0])
+		c.usage()
+
+		return ExitUsage
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: goctl %v %v\n", cmd.name, cmd.args)
+		fs.PrintDefaults()
+	}
+
+	err := cmd.run(c, fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	if err != nil {
+		fmt.Fprintf(c.stderr, "goctl %v: %v\n", cmd.name, err)
+	}
+
+	return exitCode(err)
+}
+
+func (c *CLI) usage() {
+	fmt.Fprintf(c.stderr, "usage: goctl [-a address] [-t transport] [-c cache dir] <command> [arguments]\n\n")
+	fmt.Fprintf(c.stderr, "Credentials are taken from %v and %v environment variables,\n", EnvLogin, EnvPassword)
+	fmt.Fprintf(c.stderr, "if they are not set the session kept by login command is used.\n")
+	fmt.Fprintf(c.stderr, "Types are: creds, text, binary, cards.\n\nCommands:\n")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-12v %v\n", cmd.name, cmd.summary)
+	}
+}
+
+func (c *CLI) login(fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if err := c.logIn(); err != nil {
+		return err
+	}
+
+	s, err := c.adapter.Session()
+	if err != nil {
+		return err
+	}
+
+	if err := c.sessions.Save(s); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stderr, "logged in as %v\n", c.cred.Login)
+
+	return nil
+}
+
+func (c *CLI) logout(fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	c.adapter.Logout()
+
+	return c.sessions.Delete()
+}
+
+func (c *CLI) list(fs *flag.FlagSet, args []string) error {
+	format := formatFlag(fs)
+
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataType, err := parseType(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	data, err := c.adapter.GetData(dataType)
+	if err != nil {
+		return err
+	}
+
+	return printList(c.stdout, *format, dataType, data)
+}
+
+func (c *CLI) get(fs *flag.FlagSet, args []string) error {
+	format := formatFlag(fs)
+	field := fs.String("field", "", "print the value of a single field, e.g. password")
+
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	dataType, err := parseType(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	item, err := c.find(dataType, args[1])
+	if err != nil {
+		return err
+	}
+
+	return printItem(c.stdout, *format, *field, item)
+}
+
+func (c *CLI) add(fs *flag.FlagSet, args []string) error {
+	fromJSON := jsonFlag(fs)
+
+	args, err := parse(fs, args, -1)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return usageErrorf("data type is missing")
+	}
+
+	dataType, err := parseType(args[0])
+	if err != nil {
+		return err
+	}
+
+	item, err := newItem(dataType)
+	if err != nil {
+		return err
+	}
+
+	if item, err = c.fill(item, *fromJSON, args[1:]); err != nil {
+		return err
+	}
+
+	// items get their IDs on the client, so the ID
+	// is known even if the item is queued
+	item, err = withID(item, uuid.NewString())
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	if err := c.adapter.AddData(dataType, item); err != nil {
+		return err
+	}
+
+	c.warnOffline()
+
+	id, _ := itemInfo(item)
+	fmt.Fprintln(c.stdout, id)
+
+	return nil
+}
+
+func (c *CLI) edit(fs *flag.FlagSet, args []string) error {
+	fromJSON := jsonFlag(fs)
+
+	args, err := parse(fs, args, -1)
+	if err != nil {
+		return err
+	}
+
+	if len(args) < 2 {
+		return usageErrorf("data type and item are required")
+	}
+
+	dataType, err := parseType(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !*fromJSON && len(args) == 2 {
+		return usageErrorf("nothing to change, pass field=value or --json")
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	item, err := c.find(dataType, args[1])
+	if err != nil {
+		return err
+	}
+
+	id, _ := itemInfo(item)
+
+	if item, err = c.fill(item, *fromJSON, args[2:]); err != nil {
+		return err
+	}
+
+	// ID can't be changed
+	if item, err = withID(item, id); err != nil {
+		return err
+	}
+
+	if err := c.adapter.UpdateData(dataType, item); err != nil {
+		return err
+	}
+
+	c.warnOffline()
+
+	return nil
+}
+
+func (c *CLI) remove(fs *flag.FlagSet, args []string) error {
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	dataType, err := parseType(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	item, err := c.find(dataType, args[1])
+	if err != nil {
+		return err
+	}
+
+	id, _ := itemInfo(item)
+
+	if err := c.adapter.DeleteData(dataType, id); err != nil {
+		return err
+	}
+
+	c.warnOffline()
+
+	return nil
+}
+
+func (c *CLI) revealCard(fs *flag.FlagSet, args []string) error {
+	format := formatFlag(fs)
+	field := fs.String("field", "", "print the value of a single field, e.g. number")
+
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	cvv, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read CVV code: %w", err)
+	}
+
+	cvv = strings.TrimSpace(cvv)
+	if cvv == "" {
+		return usageErrorf("CVV code is expected on stdin")
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	item, err := c.find(model.KeyCards, args[0])
+	if err != nil {
+		return err
+	}
+
+	id, _ := itemInfo(item)
+
+	card, err := c.adapter.GetCard(id, cvv)
+	if err != nil {
+		return err
+	}
+
+	return printItem(c.stdout, *format, *field, card)
+}
+
+// generate prints a random password, its strength goes to stderr
+// so that the output can be passed on as it is.
+func (c *CLI) generate(fs *flag.FlagSet, args []string) error {
+	opts := passgen.DefaultOptions
+
+	fs.IntVar(&opts.Length, "length", opts.Length, "password length")
+	noLower := fs.Bool("no-lower", false, "do not use lowercase letters")
+	noUpper := fs.Bool("no-upper", false, "do not use uppercase letters")
+	noDigits := fs.Bool("no-digits", false, "do not use digits")
+	noSymbols := fs.Bool("no-symbols", false, "do not use symbols")
+	fs.BoolVar(&opts.ExcludeAmbiguous, "exclude-ambiguous", false, "leave out characters that are easy to confuse")
+	words := fs.Int("words", 0, "generate passphrase of so many words instead")
+	sep := fs.String("separator", "-", "separator of passphrase words")
+
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	opts.Lower, opts.Upper = !*noLower, !*noUpper
+	opts.Digits, opts.Symbols = !*noDigits, !*noSymbols
+
+	var (
+		pwd string
+		err error
+	)
+
+	if *words > 0 {
+		pwd, err = passgen.Passphrase(*words, *sep)
+	} else {
+		pwd, err = passgen.Password(opts)
+	}
+
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	s := passgen.Estimate(pwd)
+
+	fmt.Fprintln(c.stdout, pwd)
+	fmt.Fprintf(c.stderr, "strength: %v, %.0f bits\n", s, s.Bits)
+
+	return nil
+}
+
+// importFile imports items of another password manager's export.
+// Results of items are printed to stdout, the summary to stderr.
+func (c *CLI) importFile(fs *flag.FlagSet, args []string) error {
+	format := fs.String("format", "", "export format: "+strings.Join(importer.Formats, ", ")+
+		", detected by file extension if not set")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without importing")
+
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	items, err := importer.ParseFile(args[0], *format)
+	if errors.Is(err, importer.ErrFormat) {
+		return usageError{err.Error()}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	return c.importItems(items, *dryRun)
+}
+
+// export saves the archive of all user's items to a file
+// readable by the user only. The archive holds full card numbers,
+// so credentials are required even if a session is kept.
+func (c *CLI) export(fs *flag.FlagSet, args []string) error {
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if c.cred.Login == "" || c.cred.Password == "" {
+		return usageErrorf("export requires credentials, set %v and %v", EnvLogin, EnvPassword)
+	}
+
+	passphrase, err := c.passphrase()
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = c.adapter.Export(f, c.cred, passphrase)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		// a partial archive is of no use
+		os.Remove(args[0])
+
+		return err
+	}
+
+	fmt.Fprintf(c.stderr, "exported to %v\n", args[0])
+
+	return nil
+}
+
+// restore adds items of archive made by export, items
+// that are in the vault already are skipped.
+func (c *CLI) restore(fs *flag.FlagSet, args []string) error {
+	dryRun := fs.Bool("dry-run", false, "report what would be restored without restoring")
+
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := c.passphrase()
+	if err != nil {
+		return err
+	}
+
+	items, err := importer.ParseArchiveFile(args[0], passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := c.open(); err != nil {
+		return err
+	}
+
+	return c.importItems(items, *dryRun)
+}
+
+// importItems imports items and reports results of items
+// to stdout, the summary to stderr.
+func (c *CLI) importItems(items []importer.Item, dryRun bool) error {
+	report, err := importer.Import(c.adapter, items, dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range report.Results {
+		fmt.Fprintln(c.stdout, res)
+	}
+
+	fmt.Fprintln(c.stderr, report.Summary())
+
+	if n := report.Count(importer.StatusFailed); n != 0 {
+		return fmt.Errorf("%v item(s) failed to import", n)
+	}
+
+	if !dryRun && report.Count(importer.StatusAdded) != 0 {
+		c.warnOffline()
+	}
+
+	return nil
+}
+
+// passphrase reads archive passphrase from the first line of stdin.
+func (c *CLI) passphrase() (string, error) {
+	passphrase, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	passphrase = strings.TrimRight(passphrase, "\r\n")
+	if passphrase == "" {
+		return "", usageErrorf("passphrase is expected on stdin")
+	}
+
+	if err := backup.CheckPassphrase(passphrase); err != nil {
+		return "", usageError{err.Error()}
+	}
+
+	return passphrase, nil
+}
+
+// open logs in with user's credentials or resumes the kept session
+// if credentials are not set.
+func (c *CLI) open() error {
+	if c.cred != (model.Credentials{}) {
+		return c.logIn()
+	}
+
+	s, err := c.sessions.Load()
+	if err != nil {
+		return usageErrorf("credentials are missing, set %v and %v or run goctl login (%v)",
+			EnvLogin, EnvPassword, err)
+	}
+
+	err = c.adapter.Resume(s)
+	if errors.Is(err, model.ErrInvalidSession) {
+		c.sessions.Delete()
+
+		return fmt.Errorf("kept session is no longer valid, log in again: %w", err)
+	}
+
+	return err
+}
+
+// logIn logs in with user's credentials.
+func (c *CLI) logIn() error {
+	if c.cred.Login == "" || c.cred.Password == "" {
+		return usageErrorf("credentials are missing, set %v and %v", EnvLogin, EnvPassword)
+	}
+
+	return c.adapter.Login(c.cred)
+}
+
+// find returns dataType item with ID or name ref.
+func (c *CLI) find(dataType int, ref string) (any, error) {
+	data, err := c.adapter.GetData(dataType)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := splitItems(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []any
+
+	for _, item := range items {
+		id, name := itemInfo(item)
+		if id == ref {
+			return item, nil
+		}
+
+		if name == ref {
+			found = append(found, item)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%w: %q", model.ErrNotFound, ref)
+	case 1:
+		return found[0], nil
+	}
+
+	return nil, fmt.Errorf("%v items are named %q, use ID instead", len(found), ref)
+}
+
+// fill sets fields of item from JSON read from stdin if fromJSON
+// is true, then from field=value pairs.
+func (c *CLI) fill(item any, fromJSON bool, pairs []string) (any, error) {
+	if fromJSON {
+		ptr := reflect.New(reflect.TypeOf(item))
+		ptr.Elem().Set(reflect.ValueOf(item))
+
+		if err := json.NewDecoder(c.stdin).Decode(ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to decode item: %w", err)
+		}
+
+		item = ptr.Elem().Interface()
+	}
+
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, usageErrorf("expected field=value, got %q", pair)
+		}
+
+		var err error
+		if item, err = setField(item, name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return item, nil
+}
+
+func (c *CLI) warnOffline() {
+	if !c.adapter.Status().Online {
+		fmt.Fprintln(c.stderr, "goctl: server is unreachable, the change is saved locally and will be sent on next sync")
+	}
+}
+
+// parse parses flags that may follow positional arguments and
+// returns positional arguments. Unless want is negative exactly
+// want arguments are expected.
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var pos []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+
+			return nil, usageError{err.Error()}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+
+	if want >= 0 && len(pos) != want {
+		fs.Usage()
+
+		return nil, usageErrorf("expected %v arguments, got %v", want, len(pos))
+	}
+
+	return pos, nil
+}
+
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("o", FormatTable, "output format: table or json")
+}
+
+func jsonFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("json", false, "read item as JSON from stdin, field=value pairs are applied on top")
+}
+
+func usageErrorf(format string, a ...any) error {
+	return usageError{fmt.Sprintf(format, a...)}
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func exitCode(err error) int {
+	var ue usageError
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &ue):
+		return ExitUsage
+	case errors.Is(err, model.ErrUnauthorized),
+		errors.Is(err, model.ErrInvalidSession),
+		errors.Is(err, model.ErrInvalidCVV),
+		errors.Is(err, cryptostream.ErrDecrypt):
+		return ExitUnauthorized
+	case errors.Is(err, model.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, offline.ErrOffline):
+		return ExitOffline
+	}
+
+	return ExitError
+}
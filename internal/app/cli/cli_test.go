@@ -0,0 +1,427 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/adapter/offline"
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+	"github.com/usa4ev/ghostorange/internal/pkg/passgen"
+)
+
+var testCred = model.Credentials{Login: "user", Password: "secret"}
+
+// fakeAdapter keeps items in memory.
+type fakeAdapter struct {
+	items   [model.KeyLimit][]any
+	offline bool
+	session model.Session
+}
+
+func (a *fakeAdapter) Login(cred model.Credentials) error {
+	if cred != testCred {
+		return model.ErrUnauthorized
+	}
+
+	a.session = model.Session{Login: cred.Login, Token: "token", ExpiresAt: time.Now().Add(time.Hour)}
+
+	return nil
+}
+
+func (a *fakeAdapter) Session() (model.Session, error) {
+	return a.session, nil
+}
+
+func (a *fakeAdapter) Resume(s model.Session) error {
+	if s.Token != a.session.Token {
+		return model.ErrInvalidSession
+	}
+
+	return nil
+}
+
+func (a *fakeAdapter) Logout() {
+	a.session = model.Session{}
+}
+
+func (a *fakeAdapter) GetData(dataType int) (any, error) {
+	msg, err := json.Marshal(a.items[dataType])
+	if err != nil {
+		return nil, err
+	}
+
+	if a.items[dataType] == nil {
+		msg = []byte("[]")
+	}
+
+	return model.DecodeItemsJSON(dataType, msg)
+}
+
+func (a *fakeAdapter) AddData(dataType int, data any) error {
+	a.items[dataType] = append(a.items[dataType], data)
+
+	return nil
+}
+
+func (a *fakeAdapter) UpdateData(dataType int, data any) error {
+	id, _ := itemInfo(data)
+
+	for i, item := range a.items[dataType] {
+		if itemID, _ := itemInfo(item); itemID == id {
+			a.items[dataType][i] = data
+
+			return nil
+		}
+	}
+
+	return model.ErrNotFound
+}
+
+func (a *fakeAdapter) DeleteData(dataType int, id string) error {
+	for i, item := range a.items[dataType] {
+		if itemID, _ := itemInfo(item); itemID == id {
+			a.items[dataType] = append(a.items[dataType][:i], a.items[dataType][i+1:]...)
+
+			return nil
+		}
+	}
+
+	return model.ErrNotFound
+}
+
+func (a *fakeAdapter) Batch(ops []model.BatchOp) ([]error, error) {
+	errs := make([]error, len(ops))
+
+	for i, op := range ops {
+		switch op.Op {
+		case model.BatchCreate:
+			errs[i] = a.AddData(op.DataType, op.Item)
+		case model.BatchUpdate:
+			errs[i] = a.UpdateData(op.DataType, op.Item)
+		case model.BatchDelete:
+			errs[i] = a.DeleteData(op.DataType, op.ID)
+		}
+	}
+
+	return errs, nil
+}
+
+func (a *fakeAdapter) GetCard(id, cvv string) (model.ItemCard, error) {
+	if a.offline {
+		return model.ItemCard{}, offline.ErrOffline
+	}
+
+	for _, item := range a.items[model.KeyCards] {
+		card := item.(model.ItemCard)
+		if card.ID != id {
+			continue
+		}
+
+		if ok, _ := argon2hash.ComparePasswordAndHash(cvv, card.CVVHash); !ok {
+			return model.ItemCard{}, model.ErrInvalidCVV
+		}
+
+		return card, nil
+	}
+
+	return model.ItemCard{}, model.ErrNotFound
+}
+
+func (a *fakeAdapter) Export(w io.Writer, cred model.Credentials, passphrase string) error {
+	aw, err := backup.NewWriter(w, passphrase)
+	if err != nil {
+		return err
+	}
+
+	for dataType, items := range a.items {
+		for _, item := range items {
+			if err := aw.Add(dataType, item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return aw.Close()
+}
+
+func (a *fakeAdapter) Status() model.SyncStatus {
+	return model.SyncStatus{Online: !a.offline}
+}
+
+// fakeSessions keeps a single session in memory.
+type fakeSessions struct {
+	session *model.Session
+}
+
+func (s *fakeSessions) Load() (model.Session, error) {
+	if s.session == nil {
+		return model.Session{}, errors.New("no saved session")
+	}
+
+	return *s.session, nil
+}
+
+func (s *fakeSessions) Save(sess model.Session) error {
+	s.session = &sess
+
+	return nil
+}
+
+func (s *fakeSessions) Delete() error {
+	s.session = nil
+
+	return nil
+}
+
+// run runs goctl with args and stdin and returns exit code and output.
+func run(a *fakeAdapter, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	code := New(a, testCred, &fakeSessions{}, strings.NewReader(stdin), &stdout, &stderr).Run(args)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	a := &fakeAdapter{}
+
+	code, id, _ := run(a, "", "add", "creds", "name=mail", "login=me", "password=p@ss", "comment=work")
+	require.Equal(t, ExitOK, code)
+
+	id = strings.TrimSpace(id)
+	assert.NotEmpty(t, id)
+
+	code, out, _ := run(a, "", "get", "creds", "mail", "--field", "password")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, "p@ss\n", out)
+
+	code, out, _ = run(a, "", "get", id, "-o", "json", "creds")
+	assert.Equal(t, ExitUsage, code, "type goes first")
+	assert.Empty(t, out)
+
+	code, _, _ = run(a, "", "edit", "creds", id, "credentials.password=new")
+	require.Equal(t, ExitOK, code)
+
+	code, out, _ = run(a, "", "get", "creds", id, "-o", "json")
+	require.Equal(t, ExitOK, code)
+
+	var item model.ItemCredentials
+	require.NoError(t, json.Unmarshal([]byte(out), &item))
+	assert.Equal(t, model.ItemCredentials{ID: id, Name: "mail", Comment: "work",
+		Credentials: model.Credentials{Login: "me", Password: "new"}}, item)
+
+	code, _, _ = run(a, `{"comment": "from json"}`, "edit", "creds", "mail", "--json")
+	require.Equal(t, ExitOK, code)
+
+	code, out, _ = run(a, "", "ls", "creds")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, []string{"ID", "NAME", "LOGIN", "COMMENT"}, strings.Fields(strings.Split(out, "\n")[0]))
+	assert.Contains(t, out, "from json")
+	assert.NotContains(t, out, "new", "passwords are not listed")
+
+	code, out, _ = run(a, "", "ls", "-o", "json", "text")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, "[]\n", out)
+
+	code, _, _ = run(a, "", "rm", "creds", id)
+	assert.Equal(t, ExitOK, code)
+	assert.Empty(t, a.items[model.KeyCredentials])
+
+	code, _, stderr := run(a, "", "rm", "creds", id)
+	assert.Equal(t, ExitNotFound, code)
+	assert.Contains(t, stderr, "not found")
+}
+
+func TestCards(t *testing.T) {
+	a := &fakeAdapter{}
+
+	code, _, _ := run(a, "", "add", "cards", "name=visa", "number=4111111111111111",
+		"expiration_date=01/30", "cvv=123")
+	require.Equal(t, ExitOK, code)
+
+	card := a.items[model.KeyCards][0].(model.ItemCard)
+	assert.Equal(t, time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), card.Exp)
+	assert.NotEqual(t, "123", card.CVVHash)
+
+	code, out, _ := run(a, "123\n", "reveal-card", "visa", "--field", "number")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, "4111111111111111\n", out)
+
+	code, out, _ = run(a, "", "get", "cards", "visa")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, out, "01/30")
+	assert.NotContains(t, out, card.CVVHash)
+
+	code, _, _ = run(a, "000", "reveal-card", "visa")
+	assert.Equal(t, ExitUnauthorized, code)
+
+	code, _, _ = run(a, "", "reveal-card", "visa")
+	assert.Equal(t, ExitUsage, code, "CVV code is required")
+}
+
+func TestExitCodes(t *testing.T) {
+	a := &fakeAdapter{}
+
+	tests := []struct {
+		name string
+		cred model.Credentials
+		args []string
+		want int
+	}{
+		{"no command", testCred, nil, ExitUsage},
+		{"help", testCred, []string{"help"}, ExitOK},
+		{"unknown command", testCred, []string{"cp"}, ExitUsage},
+		{"unknown type", testCred, []string{"ls", "photos"}, ExitUsage},
+		{"unknown field", testCred, []string{"add", "text", "title=x"}, ExitUsage},
+		{"unknown format", testCred, []string{"ls", "-o", "yaml", "text"}, ExitUsage},
+		{"no credentials", model.Credentials{}, []string{"login"}, ExitUsage},
+		{"wrong password", model.Credentials{Login: "user", Password: "wrong"}, []string{"ls", "0"}, ExitUnauthorized},
+		{"login", testCred, []string{"login"}, ExitOK},
+		{"not found", testCred, []string{"get", "text", "missing"}, ExitNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := New(a, tt.cred, &fakeSessions{}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}).Run(tt.args)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+
+	a.offline = true
+
+	code, _, stderr := run(a, "", "add", "text", "name=note", "text=queued")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "saved locally")
+
+	code, _, stderr = run(a, "", "rm", "text", "note")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "saved locally")
+
+	code, _, _ = run(a, "", "add", "cards", "name=visa", "number=4111111111111111", "cvv=123")
+	require.Equal(t, ExitOK, code)
+
+	code, _, _ = run(a, "123\n", "reveal-card", "visa")
+	assert.Equal(t, ExitOffline, code, "revealing a card requires the server")
+}
+
+func TestSession(t *testing.T) {
+	a := &fakeAdapter{}
+	sessions := &fakeSessions{}
+
+	run := func(cred model.Credentials, args ...string) int {
+		return New(a, cred, sessions, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}).Run(args)
+	}
+
+	assert.Equal(t, ExitUsage, run(model.Credentials{}, "ls", "text"), "nothing to resume")
+
+	require.Equal(t, ExitOK, run(testCred, "login"))
+	require.NotNil(t, sessions.session)
+	assert.Equal(t, "token", sessions.session.Token)
+
+	assert.Equal(t, ExitOK, run(model.Credentials{}, "ls", "text"), "kept session is resumed")
+
+	a.session.Token = "revoked"
+	assert.Equal(t, ExitUnauthorized, run(model.Credentials{}, "ls", "text"))
+	assert.Nil(t, sessions.session, "invalid session is forgotten")
+
+	require.Equal(t, ExitOK, run(testCred, "login"))
+	require.Equal(t, ExitOK, run(model.Credentials{}, "logout"))
+	assert.Nil(t, sessions.session)
+	assert.Equal(t, ExitUsage, run(model.Credentials{}, "ls", "text"))
+}
+
+func TestGenerate(t *testing.T) {
+	a := &fakeAdapter{}
+
+	code, out, stderr := run(a, "", "generate", "--length", "12", "--no-symbols")
+	require.Equal(t, ExitOK, code)
+
+	pwd := strings.TrimSpace(out)
+	assert.Len(t, pwd, 12)
+	assert.False(t, strings.ContainsAny(pwd, passgen.Symbols))
+	assert.Contains(t, stderr, "bits")
+
+	code, out, _ = run(a, "", "generate", "--words", "4", "--separator", " ")
+	require.Equal(t, ExitOK, code)
+	assert.Len(t, strings.Fields(out), 4)
+
+	code, _, _ = run(a, "", "generate", "--no-lower", "--no-upper", "--no-digits", "--no-symbols")
+	assert.Equal(t, ExitUsage, code)
+}
+
+func TestImport(t *testing.T) {
+	a := &fakeAdapter{}
+	export := "../importer/testdata/1password.csv"
+
+	code, out, stderr := run(a, "", "import", export, "--dry-run")
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Contains(t, out, "new        Credentials  mail (me@example.com)")
+	assert.Contains(t, stderr, "3 new (dry run")
+
+	code, _, _ = run(a, "", "ls", "creds")
+	require.Equal(t, ExitOK, code)
+	assert.Empty(t, a.items[model.KeyCredentials])
+
+	code, _, stderr = run(a, "", "import", "--format", "1password", export)
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Contains(t, stderr, "3 added")
+	assert.Len(t, a.items[model.KeyCredentials], 2)
+	assert.Len(t, a.items[model.KeyText], 1)
+
+	code, _, stderr = run(a, "", "import", export)
+	require.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "3 duplicate", "imported items are not added twice")
+
+	code, _, _ = run(a, "", "import", "export.kdbx")
+	assert.Equal(t, ExitUsage, code)
+}
+
+func TestExportRestore(t *testing.T) {
+	a := &fakeAdapter{}
+	archive := filepath.Join(t.TempDir(), "vault"+backup.FileExt)
+
+	code, _, stderr := run(a, "", "add", "creds", "name=mail", "login=me", "password=pwd")
+	require.Equal(t, ExitOK, code, stderr)
+
+	code, _, _ = run(a, "short\n", "export", archive)
+	assert.Equal(t, ExitUsage, code, "passphrase is too short")
+
+	var stderrBuf bytes.Buffer
+	code = New(a, model.Credentials{}, &fakeSessions{}, strings.NewReader("correct horse\n"),
+		io.Discard, &stderrBuf).Run([]string{"export", archive})
+	assert.Equal(t, ExitUsage, code, "export requires credentials")
+
+	code, _, stderr = run(a, "correct horse\n", "export", archive)
+	require.Equal(t, ExitOK, code, stderr)
+
+	info, err := os.Stat(archive)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	code, _, _ = run(a, "wrong horse\n", "restore", archive)
+	assert.Equal(t, ExitUnauthorized, code)
+
+	code, out, _ := run(a, "correct horse\n", "restore", archive)
+	require.Equal(t, ExitOK, code)
+	assert.Contains(t, out, "duplicate  Credentials  mail (me)")
+
+	b := &fakeAdapter{}
+
+	code, _, stderr = run(b, "correct horse\n", "restore", archive)
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Contains(t, stderr, "1 added")
+	require.Len(t, b.items[model.KeyCredentials], 1)
+	assert.Equal(t, "pwd", b.items[model.KeyCredentials][0].(model.ItemCredentials).Credentials.Password)
+}
@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+// expLayout is the layout card expiration month is printed and
+// parsed with, RFC 3339 is accepted as well.
+const expLayout = "01/06"
+
+// pseudo fields that are not stored as is
+const (
+	// fieldCVV sets card CVV code hash
+	fieldCVV = "cvv"
+	// fieldFile loads binary data from file
+	fieldFile = "file"
+)
+
+var typeNames = map[string]int{
+	"creds":       model.KeyCredentials,
+	"credentials": model.KeyCredentials,
+	"text":        model.KeyText,
+	"binary":      model.KeyBinary,
+	"card":        model.KeyCards,
+	"cards":       model.KeyCards,
+}
+
+// field is a leaf field of an item named with JSON path,
+// e.g. credentials.password.
+type field struct {
+	path  string
+	value reflect.Value
+}
+
+func parseType(s string) (int, error) {
+	if dataType, ok := typeNames[s]; ok {
+		return dataType, nil
+	}
+
+	if dataType, err := strconv.Atoi(s); err == nil && dataType >= 0 && dataType < model.KeyLimit {
+		return dataType, nil
+	}
+
+	return 0, usageErrorf("unknown data type %q", s)
+}
+
+func newItem(dataType int) (any, error) {
+	switch dataType {
+	case model.KeyCredentials:
+		return model.ItemCredentials{}, nil
+	case model.KeyText:
+		return model.ItemText{}, nil
+	case model.KeyBinary:
+		return model.ItemBinary{}, nil
+	case model.KeyCards:
+		return model.ItemCard{}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported data type")
+}
+
+// itemInfo returns ID and name of a stored data item.
+func itemInfo(item any) (id, name string) {
+	v := reflect.ValueOf(item)
+	if v.Kind() != reflect.Struct {
+		return "", ""
+	}
+
+	if f := v.FieldByName("ID"); f.Kind() == reflect.String {
+		id = f.String()
+	}
+
+	if f := v.FieldByName("Name"); f.Kind() == reflect.String {
+		name = f.String()
+	}
+
+	return id, name
+}
+
+// withID returns a copy of item with ID set to id.
+func withID(item any, id string) (any, error) {
+	return setField(item, "id", id)
+}
+
+// splitItems turns a slice of items returned by GetData
+// into a slice of separate items.
+func splitItems(data any) ([]any, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("expected a slice of items, got %T", data)
+	}
+
+	res := make([]any, v.Len())
+	for i := range res {
+		res[i] = v.Index(i).Interface()
+	}
+
+	return res, nil
+}
+
+// fields returns leaf fields of struct v in declaration order.
+func fields(v reflect.Value, prefix string) []field {
+	var res []field
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !sf.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			res = append(res, fields(fv, prefix+name+".")...)
+
+			continue
+		}
+
+		res = append(res, field{path: prefix + name, value: fv})
+	}
+
+	return res
+}
+
+// lookup finds a field by its path or, if unambiguous,
+// by the last element of its path, e.g. password.
+func lookup(fs []field, name string) (field, error) {
+	var found []field
+
+	for _, f := range fs {
+		if f.path == name {
+			return f, nil
+		}
+
+		if f.path[strings.LastIndex(f.path, ".")+1:] == name {
+			found = append(found, f)
+		}
+	}
+
+	if len(found) == 1 {
+		return found[0], nil
+	}
+
+	names := make([]string, len(fs))
+	for i, f := range fs {
+		names[i] = f.path
+	}
+
+	sort.Strings(names)
+
+	return field{}, usageErrorf("unknown field %q, expected one of: %v", name, strings.Join(names, ", "))
+}
+
+// getField returns printable value of item field.
+func getField(item any, name string) (string, error) {
+	f, err := lookup(fields(reflect.ValueOf(item), ""), name)
+	if err != nil {
+		return "", err
+	}
+
+	return formatValue(f.value), nil
+}
+
+// setField returns a copy of item with field set to value parsed
+// according to the field type. Card CVV code is hashed,
+// binary data is loaded from file.
+func setField(item any, name, value string) (any, error) {
+	ptr := reflect.New(reflect.TypeOf(item))
+	ptr.Elem().Set(reflect.ValueOf(item))
+
+	if card, ok := item.(model.ItemCard); ok && name == fieldCVV {
+		hash, err := argon2hash.GenerateFromPassword(value, argon2hash.DefaultParams())
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash CVV code: %w", err)
+		}
+
+		card.CVVHash = hash
+
+		return card, nil
+	}
+
+	if bin, ok := item.(model.ItemBinary); ok && name == fieldFile {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		bin.Data = base64.StdEncoding.EncodeToString(data)
+		bin.Name = filepath.Base(value)
+		bin.Extention = filepath.Ext(value)
+		bin.Size = len(data)
+
+		return bin, nil
+	}
+
+	f, err := lookup(fields(ptr.Elem(), ""), name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := f.value.Interface().(type) {
+	case string:
+		f.value.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, usageErrorf("%v must be a number", f.path)
+		}
+
+		f.value.SetInt(int64(n))
+	case time.Time:
+		t, err := time.Parse(expLayout, value)
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, value); err != nil {
+				return nil, usageErrorf("%v must be formatted as MM/YY", f.path)
+			}
+		}
+
+		f.value.Set(reflect.ValueOf(t))
+	default:
+		return nil, fmt.Errorf("unsupported field type %T", v)
+	}
+
+	return ptr.Elem().Interface(), nil
+}
+
+func formatValue(v reflect.Value) string {
+	switch val := v.Interface().(type) {
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+
+		return val.Format(expLayout)
+	case int:
+		return strconv.Itoa(val)
+	}
+
+	return v.String()
+}
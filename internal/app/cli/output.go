@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// columns lists fields ls prints in table format.
+var columns = map[int][]string{
+	model.KeyCredentials: {"id", "name", "login", "comment"},
+	model.KeyText:        {"id", "name", "comment"},
+	model.KeyBinary:      {"id", "name", "extention", "size", "comment"},
+	model.KeyCards:       {"id", "name", "number", "expiration_date", "comment"},
+}
+
+// hidden lists fields get leaves out in table format,
+// they are printed with --field or in JSON only.
+var hidden = map[string]bool{
+	"data":     true,
+	"cvv_hash": true,
+}
+
+func printList(w io.Writer, format string, dataType int, data any) error {
+	if format == FormatJSON {
+		return printJSON(w, data)
+	}
+
+	if format != FormatTable {
+		return usageErrorf("unknown output format %q", format)
+	}
+
+	items, err := splitItems(data)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	cols := columns[dataType]
+	header := make([]string, len(cols))
+
+	for i, col := range cols {
+		header[i] = strings.ToUpper(col)
+	}
+
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, item := range items {
+		row := make([]string, len(cols))
+
+		for i, col := range cols {
+			if row[i], err = getField(item, col); err != nil {
+				return err
+			}
+
+			row[i] = oneLine(row[i])
+		}
+
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// printItem prints item or, if field is not empty, the raw value
+// of its single field, so it can be captured by a script as is.
+func printItem(w io.Writer, format, field string, item any) error {
+	if field != "" {
+		value, err := getField(item, field)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, value)
+
+		return err
+	}
+
+	if format == FormatJSON {
+		return printJSON(w, item)
+	}
+
+	if format != FormatTable {
+		return usageErrorf("unknown output format %q", format)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, f := range fields(reflect.ValueOf(item), "") {
+		if hidden[f.path] {
+			continue
+		}
+
+		fmt.Fprintf(tw, "%v:\t%v\n", f.path, oneLine(formatValue(f.value)))
+	}
+
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// ParseArchiveFile reads items of vault archive file.
+func ParseArchiveFile(path, passphrase string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	return ParseArchive(f, passphrase)
+}
+
+// ParseArchive reads items of vault archive made by export.
+// Items lose their IDs, so an archive can be restored to the
+// vault it has been made of as well as to another one, items
+// still there are skipped as duplicates. Binary items get new IDs
+// instead, so notes keep their attachments. Notes come before
+// attachments in archives, so the whole archive is read before
+// items are returned.
+func ParseArchive(r io.Reader, passphrase string) ([]Item, error) {
+	ar, err := backup.NewReader(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+
+	// new IDs of binary items by the archived ones
+	ids := make(map[string]string)
+
+	for {
+		dataType, item, err := ar.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if v, ok := item.(model.ItemBinary); ok {
+			ids[v.ID] = uuid.NewString()
+			v.ID = ids[v.ID]
+			items = append(items, Item{dataType, v})
+
+			continue
+		}
+
+		items = append(items, Item{dataType, withoutID(item)})
+	}
+
+	// notes come before attachments in archives,
+	// so links are updated once all items are read
+	for i, item := range items {
+		v, ok := item.Data.(model.ItemText)
+		if !ok || len(v.Attachments) == 0 {
+			continue
+		}
+
+		var attachments []string
+
+		for _, id := range v.Attachments {
+			if ids[id] != "" {
+				attachments = append(attachments, ids[id])
+			}
+		}
+
+		v.Attachments = attachments
+		items[i].Data = v
+	}
+
+	return items, nil
+}
+
+func withoutID(item any) any {
+	switch v := item.(type) {
+	case model.ItemCredentials:
+		v.ID = ""
+
+		return v
+	case model.ItemText:
+		v.ID = ""
+
+		return v
+	case model.ItemBinary:
+		v.ID = ""
+
+		return v
+	case model.ItemCard:
+		v.ID = ""
+
+		return v
+	}
+
+	return item
+}
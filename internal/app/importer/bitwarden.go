@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+// types of Bitwarden items
+const (
+	bwLogin      = 1
+	bwSecureNote = 2
+	bwCard       = 3
+	bwIdentity   = 4
+)
+
+// bwIdentityFields lists identity fields in the order they are
+// written to text, Bitwarden names them in camel case.
+var bwIdentityFields = []string{
+	"title", "firstName", "middleName", "lastName", "username", "company",
+	"email", "phone", "address1", "address2", "address3", "city", "state",
+	"postalCode", "country", "ssn", "passportNumber", "licenseNumber",
+}
+
+type (
+	bwFile struct {
+		Encrypted bool     `json:"encrypted"`
+		Items     []bwItem `json:"items"`
+	}
+
+	bwItem struct {
+		Type   int    `json:"type"`
+		Name   string `json:"name"`
+		Notes  string `json:"notes"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+		Login *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+		Card *struct {
+			CardholderName string `json:"cardholderName"`
+			Brand          string `json:"brand"`
+			Number         string `json:"number"`
+			ExpMonth       string `json:"expMonth"`
+			ExpYear        string `json:"expYear"`
+			Code           string `json:"code"`
+		} `json:"card"`
+		Identity map[string]any `json:"identity"`
+	}
+)
+
+// parseBitwarden reads unencrypted Bitwarden JSON export.
+// Logins are credentials, cards are cards, secure notes and
+// identities are text. Card codes are hashed like the ones
+// typed in.
+func parseBitwarden(r io.Reader) ([]Item, error) {
+	var f bwFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to parse Bitwarden JSON: %w", err)
+	}
+
+	if f.Encrypted {
+		return nil, ErrEncrypted
+	}
+
+	items := make([]Item, 0, len(f.Items))
+
+	for _, it := range f.Items {
+		custom := make([]string, len(it.Fields))
+		for i, field := range it.Fields {
+			custom[i] = labeled(field.Name, field.Value)
+		}
+
+		switch {
+		case it.Type == bwLogin && it.Login != nil:
+			var uris []string
+			for _, u := range it.Login.URIs {
+				uris = append(uris, labeled("URL", u.URI))
+			}
+
+			items = append(items, Item{model.KeyCredentials, model.ItemCredentials{
+				Name: nameOf(it.Name, it.Login.Username),
+				Credentials: model.Credentials{
+					Login:    it.Login.Username,
+					Password: it.Login.Password,
+				},
+				Comment: comment(append(append(uris, it.Notes), custom...)...),
+			}})
+		case it.Type == bwCard && it.Card != nil:
+			card, err := it.card()
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, Item{model.KeyCards, card})
+		case it.Type == bwSecureNote, it.Type == bwIdentity:
+			items = append(items, Item{model.KeyText, model.ItemText{
+				Name: nameOf(it.Name),
+				Text: comment(append([]string{it.identity(), it.Notes}, custom...)...),
+			}})
+		}
+	}
+
+	return items, nil
+}
+
+func (it bwItem) card() (model.ItemCard, error) {
+	c := it.Card
+
+	card := model.ItemCard{
+		Name:    nameOf(it.Name, c.Brand),
+		Number:  strings.ReplaceAll(c.Number, " ", ""),
+		Comment: comment(labeled("Brand", c.Brand), it.Notes),
+	}
+
+	// Bitwarden keeps holder's full name,
+	// the first word is taken for the name
+	if names := strings.Fields(c.CardholderName); len(names) != 0 {
+		card.CardholderName = names[0]
+		card.CardholderSurename = strings.Join(names[1:], " ")
+	}
+
+	month, errM := strconv.Atoi(c.ExpMonth)
+	year, errY := strconv.Atoi(c.ExpYear)
+
+	if errM == nil && errY == nil && month >= 1 && month <= 12 {
+		if year < 100 {
+			year += 2000
+		}
+
+		card.Exp = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if c.Code != "" {
+		hash, err := argon2hash.GenerateFromPassword(c.Code, argon2hash.DefaultParams())
+		if err != nil {
+			return model.ItemCard{}, fmt.Errorf("failed to hash CVV code: %w", err)
+		}
+
+		card.CVVHash = hash
+	}
+
+	return card, nil
+}
+
+// identity returns identity fields as text lines.
+func (it bwItem) identity() string {
+	lines := make([]string, 0, len(bwIdentityFields))
+
+	for _, name := range bwIdentityFields {
+		if v, ok := it.Identity[name].(string); ok {
+			lines = append(lines, labeled(words(name), v))
+		}
+	}
+
+	return comment(lines...)
+}
+
+// words turns camel case name into words, e.g. "first name".
+func words(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i != 0 {
+				b.WriteByte(' ')
+			}
+
+			r += 'a' - 'A'
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
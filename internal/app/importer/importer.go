@@ -0,0 +1,418 @@
+// Package importer moves items exported by other password managers
+// into the vault. Supported exports are KeePass 2.x XML, unencrypted
+// Bitwarden JSON and 1Password CSV. Entries are mapped to stored
+// data items: logins to credentials, notes to text, cards to cards
+// and attachments to binary data.
+//
+// Items that are already in the vault or repeat in the export are
+// skipped. Credentials are told apart by name and login, other items
+// by name, attachments by name within the entry they belong to.
+//
+// Vault archives made by export are restored the same way.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// Supported export formats.
+const (
+	FormatKeePass   = "keepass"
+	FormatBitwarden = "bitwarden"
+	Format1Password = "1password"
+)
+
+// Statuses of imported items.
+const (
+	// StatusNew is set on dry run to items that would be added
+	StatusNew       = "new"
+	StatusAdded     = "added"
+	StatusDuplicate = "duplicate"
+	StatusFailed    = "failed"
+)
+
+// untitled names items that have no name in the export.
+const untitled = "untitled"
+
+var (
+	// ErrFormat is returned when the format of export is unknown.
+	ErrFormat = errors.New("unknown export format")
+	// ErrEncrypted is returned for encrypted exports,
+	// they are to be exported again unencrypted.
+	ErrEncrypted = errors.New("encrypted exports are not supported")
+)
+
+// Formats lists supported export formats.
+var Formats = []string{FormatKeePass, FormatBitwarden, Format1Password}
+
+type (
+	// Item is a data item read from an export.
+	Item struct {
+		DataType int
+		// Data is model.ItemCredentials, model.ItemText,
+		// model.ItemCard or model.ItemBinary
+		Data any
+	}
+
+	// Store is where items are imported to.
+	Store interface {
+		GetData(dataType int) (any, error)
+		Batch(ops []model.BatchOp) ([]error, error)
+	}
+
+	// Result is the outcome of importing a single item.
+	Result struct {
+		Item   Item
+		Status string
+		Err    error
+	}
+
+	// Report lists results of all items of an export.
+	Report struct {
+		DryRun  bool
+		Results []Result
+	}
+)
+
+// DetectFormat returns the format of export file by its extension.
+func DetectFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return FormatKeePass, nil
+	case ".json":
+		return FormatBitwarden, nil
+	case ".csv":
+		return Format1Password, nil
+	}
+
+	return "", fmt.Errorf("%w: can't tell format of %v, set it explicitly", ErrFormat, filepath.Base(path))
+}
+
+// ParseFile reads items of export file. If format is empty
+// it is detected by the file extension.
+func ParseFile(path, format string) ([]Item, error) {
+	if format == "" {
+		var err error
+		if format, err = DetectFormat(path); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(format, f)
+}
+
+// Parse reads items of export in format.
+func Parse(format string, r io.Reader) ([]Item, error) {
+	switch format {
+	case FormatKeePass:
+		return parseKeePass(r)
+	case FormatBitwarden:
+		return parseBitwarden(r)
+	case Format1Password:
+		return parse1Password(r)
+	}
+
+	return nil, fmt.Errorf("%w %q, expected one of: %v", ErrFormat, format, strings.Join(Formats, ", "))
+}
+
+// Import adds items to store skipping duplicates. New items are added
+// in one batch. On dry run nothing is added, the report tells what
+// would be. An item that fails to be added does not stop the import,
+// the error is kept in its result. Notes are linked to the items
+// that are there already in place of skipped attachments.
+func Import(store Store, items []Item, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Results: make([]Result, 0, len(items))}
+
+	// IDs of items in the store or added, by dedupe keys
+	seen := make(map[string]string)
+	loaded := make(map[int]bool)
+	// IDs of skipped duplicates mapped to IDs of items kept
+	replaced := make(map[string]string)
+
+	var (
+		ops []model.BatchOp
+		// results ops are made for
+		added []int
+	)
+
+	for _, item := range items {
+		if !loaded[item.DataType] {
+			data, err := store.GetData(item.DataType)
+			if err != nil {
+				return report, err
+			}
+
+			for k, id := range keys(data) {
+				seen[k] = id
+			}
+
+			loaded[item.DataType] = true
+		}
+
+		res := Result{Item: item, Status: StatusNew}
+
+		k := item.key()
+		kept, dup := seen[k]
+
+		switch {
+		case dup:
+			res.Status = StatusDuplicate
+
+			if id := itemID(item.Data); id != "" && kept != "" {
+				replaced[id] = kept
+			}
+		case dryRun:
+			seen[k] = itemID(item.Data)
+		default:
+			seen[k] = itemID(item.Data)
+
+			ops = append(ops, model.BatchOp{Op: model.BatchCreate, DataType: item.DataType, Item: item.Data})
+			added = append(added, len(report.Results))
+		}
+
+		report.Results = append(report.Results, res)
+	}
+
+	if len(ops) == 0 {
+		return report, nil
+	}
+
+	for i, op := range ops {
+		if note, ok := op.Item.(model.ItemText); ok {
+			ops[i].Item = relink(note, replaced)
+		}
+	}
+
+	errs, err := store.Batch(ops)
+
+	for i, n := range added {
+		res := &report.Results[n]
+
+		res.Status, res.Err = StatusAdded, err
+		if err == nil {
+			res.Err = errs[i]
+		}
+
+		if res.Err != nil {
+			res.Status = StatusFailed
+		}
+	}
+
+	return report, nil
+}
+
+// Name returns the name of item.
+func (i Item) Name() string {
+	name, _ := info(i.Data)
+
+	return name
+}
+
+// Login returns the login of credentials, for an attachment
+// it's the comment that names the entry it belongs to.
+func (i Item) Login() string {
+	_, login := info(i.Data)
+
+	return login
+}
+
+func (i Item) key() string {
+	return fmt.Sprintf("%v\x00%v\x00%v", i.DataType, i.Name(), i.Login())
+}
+
+// String returns a line describing the result.
+func (r Result) String() string {
+	s := fmt.Sprintf("%-9v  %-11v  %v", r.Status, model.GetItemTitle(r.Item.DataType), r.Item.Name())
+	if r.Item.DataType == model.KeyCredentials && r.Item.Login() != "" {
+		s += fmt.Sprintf(" (%v)", r.Item.Login())
+	}
+
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+
+	return s
+}
+
+// Count returns the number of results with status.
+func (r Report) Count(status string) int {
+	n := 0
+
+	for _, res := range r.Results {
+		if res.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Summary returns counts of results by status.
+func (r Report) Summary() string {
+	counts := make(map[string]int)
+	for _, res := range r.Results {
+		counts[res.Status]++
+	}
+
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = fmt.Sprintf("%v %v", counts[status], status)
+	}
+
+	if len(parts) == 0 {
+		return "nothing to import"
+	}
+
+	s := strings.Join(parts, ", ")
+	if r.DryRun {
+		s += " (dry run, nothing is imported)"
+	}
+
+	return s
+}
+
+// keys returns IDs of items returned by Store.GetData
+// by their dedupe keys.
+func keys(data any) map[string]string {
+	var items []Item
+
+	switch v := data.(type) {
+	case []model.ItemCredentials:
+		for _, item := range v {
+			items = append(items, Item{model.KeyCredentials, item})
+		}
+	case []model.ItemText:
+		for _, item := range v {
+			items = append(items, Item{model.KeyText, item})
+		}
+	case []model.ItemCard:
+		for _, item := range v {
+			items = append(items, Item{model.KeyCards, item})
+		}
+	case []model.ItemBinary:
+		for _, item := range v {
+			items = append(items, Item{model.KeyBinary, item})
+		}
+	}
+
+	res := make(map[string]string, len(items))
+	for _, item := range items {
+		res[item.key()] = itemID(item.Data)
+	}
+
+	return res
+}
+
+// itemID returns ID of item data, items read from exports of
+// other managers have none.
+func itemID(data any) string {
+	switch v := data.(type) {
+	case model.ItemCredentials:
+		return v.ID
+	case model.ItemText:
+		return v.ID
+	case model.ItemCard:
+		return v.ID
+	case model.ItemBinary:
+		return v.ID
+	}
+
+	return ""
+}
+
+// relink returns note with attachments of replaced IDs
+// linked to the items kept in their place.
+func relink(note model.ItemText, replaced map[string]string) model.ItemText {
+	if len(note.Attachments) == 0 || len(replaced) == 0 {
+		return note
+	}
+
+	attachments := make([]string, len(note.Attachments))
+	for i, id := range note.Attachments {
+		attachments[i] = id
+		if kept, ok := replaced[id]; ok {
+			attachments[i] = kept
+		}
+	}
+
+	note.Attachments = attachments
+
+	return note
+}
+
+// info returns fields item is told apart by.
+func info(data any) (name, login string) {
+	switch v := data.(type) {
+	case model.ItemCredentials:
+		return v.Name, v.Credentials.Login
+	case model.ItemText:
+		return v.Name, ""
+	case model.ItemCard:
+		return v.Name, ""
+	case model.ItemBinary:
+		return v.Name, v.Comment
+	}
+
+	return "", ""
+}
+
+// comment joins non-empty lines of item comment.
+func comment(lines ...string) string {
+	var res []string
+
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+
+	return strings.Join(res, "\n")
+}
+
+// labeled returns "label: value" unless value is empty.
+func labeled(label, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return label + ": " + value
+}
+
+// nameOf returns name or, if it's empty, the first of fallbacks
+// that is set.
+func nameOf(name string, fallbacks ...string) string {
+	for _, s := range append([]string{name}, fallbacks...) {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+
+	return untitled
+}
+
+// attachmentOf returns comment of attachments of entry name,
+// it tells attachments of different entries apart.
+func attachmentOf(name string) string {
+	return "attachment of " + name
+}
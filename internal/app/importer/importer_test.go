@@ -0,0 +1,243 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+// fakeStore keeps items added by Import.
+type fakeStore struct {
+	creds    []model.ItemCredentials
+	binaries []model.ItemBinary
+	added    []Item
+	fail     string
+	// batches counts Batch calls
+	batches int
+}
+
+func (s *fakeStore) GetData(dataType int) (any, error) {
+	switch dataType {
+	case model.KeyCredentials:
+		return s.creds, nil
+	case model.KeyText:
+		return []model.ItemText{}, nil
+	case model.KeyCards:
+		return []model.ItemCard{}, nil
+	case model.KeyBinary:
+		return append([]model.ItemBinary{}, s.binaries...), nil
+	}
+
+	return nil, errors.New("unknown data type")
+}
+
+func (s *fakeStore) Batch(ops []model.BatchOp) ([]error, error) {
+	s.batches++
+
+	errs := make([]error, len(ops))
+
+	for i, op := range ops {
+		item := Item{op.DataType, op.Item}
+		if item.Name() == s.fail {
+			errs[i] = errors.New("quota exceeded")
+
+			continue
+		}
+
+		s.added = append(s.added, item)
+	}
+
+	return errs, nil
+}
+
+func TestKeePass(t *testing.T) {
+	items, err := ParseFile("./testdata/keepass.xml", "")
+	require.NoError(t, err)
+	require.Len(t, items, 5, "history and recycle bin are left out")
+
+	assert.Equal(t, Item{model.KeyCredentials, model.ItemCredentials{
+		Name:        "mail",
+		Credentials: model.Credentials{Login: "me@example.com", Password: "Tr0ub4dor&3"},
+		Comment:     "URL: https://mail.example.com\nwork account\nRecovery code: 1234-5678",
+	}}, items[0])
+
+	assert.Equal(t, "build server", items[1].Name())
+
+	key, ok := items[2].Data.(model.ItemBinary)
+	require.True(t, ok)
+	assert.Equal(t, "id_ed25519.pub", key.Name)
+	assert.Equal(t, ".pub", key.Extention)
+	assert.Equal(t, "attachment of build server", key.Comment)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("ssh-ed25519 AAAA key")), key.Data,
+		"compressed binary is unpacked")
+	assert.Equal(t, 20, key.Size)
+
+	inlined := items[3].Data.(model.ItemBinary)
+	assert.Equal(t, "aW5saW5lZA==", inlined.Data)
+
+	assert.Equal(t, Item{model.KeyText, model.ItemText{
+		Name: "wifi",
+		Text: "guest network key is on the fridge",
+	}}, items[4])
+}
+
+func TestBitwarden(t *testing.T) {
+	items, err := ParseFile("./testdata/bitwarden.json", "")
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+
+	assert.Equal(t, model.ItemCredentials{
+		Name:        "mail",
+		Credentials: model.Credentials{Login: "me@example.com", Password: "Tr0ub4dor&3"},
+		Comment:     "URL: https://mail.example.com\nwork account\nRecovery code: 1234-5678",
+	}, items[0].Data)
+
+	assert.Equal(t, model.ItemText{Name: "wifi", Text: "guest network key is on the fridge"}, items[1].Data)
+
+	card, ok := items[2].Data.(model.ItemCard)
+	require.True(t, ok)
+	assert.Equal(t, "4111111111111111", card.Number)
+	assert.Equal(t, "John", card.CardholderName)
+	assert.Equal(t, "Ronald Smith", card.CardholderSurename)
+	assert.Equal(t, time.Date(2027, time.July, 1, 0, 0, 0, 0, time.UTC), card.Exp)
+	assert.Equal(t, "Brand: Visa", card.Comment)
+
+	match, err := argon2hash.ComparePasswordAndHash("123", card.CVVHash)
+	require.NoError(t, err)
+	assert.True(t, match, "card code is hashed")
+
+	assert.Equal(t, model.ItemText{
+		Name: "passport",
+		Text: "first name: John\nlast name: Smith\npassport number: X123",
+	}, items[3].Data)
+
+	_, err = Parse(FormatBitwarden, strings.NewReader(`{"encrypted": true, "items": []}`))
+	assert.ErrorIs(t, err, ErrEncrypted)
+}
+
+func Test1Password(t *testing.T) {
+	items, err := ParseFile("./testdata/1password.csv", "")
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	assert.Equal(t, model.ItemCredentials{
+		Name:        "mail",
+		Credentials: model.Credentials{Login: "me@example.com", Password: "Tr0ub4dor&3"},
+		Comment:     "URL: https://mail.example.com\nwork account\nsecond line\nTags: work",
+	}, items[0].Data)
+
+	assert.Equal(t, model.ItemText{Name: "wifi", Text: "guest network key is on the fridge"}, items[1].Data)
+	assert.Equal(t, "https://bank.example.com", items[2].Name(), "untitled row is named by URL")
+
+	_, err = Parse(Format1Password, strings.NewReader("a,b\n1,2\n"))
+	assert.ErrorContains(t, err, "no title column")
+}
+
+func TestImport(t *testing.T) {
+	items, err := ParseFile("./testdata/bitwarden.json", FormatBitwarden)
+	require.NoError(t, err)
+
+	store := &fakeStore{
+		creds: []model.ItemCredentials{{ID: "1", Name: "wifi", Credentials: model.Credentials{Login: "admin"}}},
+		fail:  "passport",
+	}
+
+	report, err := Import(store, items, true)
+	require.NoError(t, err)
+	assert.Empty(t, store.added, "nothing is added on dry run")
+	assert.Zero(t, store.batches)
+	assert.Equal(t, 4, report.Count(StatusNew))
+	assert.Equal(t, 1, report.Count(StatusDuplicate), "repeated login is a duplicate")
+	assert.Equal(t, "1 duplicate, 4 new (dry run, nothing is imported)", report.Summary())
+
+	store.creds = append(store.creds, model.ItemCredentials{
+		Name:        "mail",
+		Credentials: model.Credentials{Login: "me@example.com"},
+	})
+
+	report, err = Import(store, items, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{StatusDuplicate, StatusAdded, StatusAdded, StatusFailed, StatusDuplicate},
+		statuses(report))
+	assert.Len(t, store.added, 2)
+	assert.Equal(t, 1, store.batches, "new items are added in one batch")
+	assert.Equal(t, "failed     Text data    passport: quota exceeded", report.Results[3].String())
+	assert.Equal(t, "duplicate  Credentials  mail (me@example.com)", report.Results[0].String())
+
+	_, err = DetectFormat("export.kdbx")
+	assert.ErrorIs(t, err, ErrFormat)
+}
+
+func statuses(r Report) []string {
+	res := make([]string, len(r.Results))
+	for i, result := range r.Results {
+		res[i] = result.Status
+	}
+
+	return res
+}
+
+func TestArchive(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := backup.NewWriter(&buf, "correct horse")
+	require.NoError(t, err)
+	require.NoError(t, w.Add(model.KeyText, model.ItemText{ID: "1", Name: "wifi", Text: "key"}))
+	require.NoError(t, w.Add(model.KeyCards, model.ItemCard{ID: "2", Name: "visa", Number: "4111111111111111"}))
+	require.NoError(t, w.Add(model.KeyText, model.ItemText{ID: "3", Name: "lease", Attachments: []string{"4", "5"}}))
+	require.NoError(t, w.Add(model.KeyBinary, model.ItemBinary{ID: "4", Name: "lease.pdf"}))
+	require.NoError(t, w.Close())
+
+	_, err = ParseArchive(bytes.NewReader(buf.Bytes()), "wrong horse")
+	assert.Error(t, err)
+
+	items, err := ParseArchive(bytes.NewReader(buf.Bytes()), "correct horse")
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+
+	binary := items[3].Data.(model.ItemBinary)
+	assert.NotEmpty(t, binary.ID)
+	assert.NotEqual(t, "4", binary.ID, "binary items get new IDs")
+
+	assert.Equal(t, []Item{
+		{model.KeyText, model.ItemText{Name: "wifi", Text: "key"}},
+		{model.KeyCards, model.ItemCard{Name: "visa", Number: "4111111111111111"}},
+		{model.KeyText, model.ItemText{Name: "lease", Attachments: []string{binary.ID}}},
+		{model.KeyBinary, model.ItemBinary{ID: binary.ID, Name: "lease.pdf"}},
+	}, items, "IDs are dropped, links to missing attachments too")
+}
+
+func TestRestoreKeepsAttachment(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := backup.NewWriter(&buf, "correct horse")
+	require.NoError(t, err)
+	require.NoError(t, w.Add(model.KeyText, model.ItemText{ID: "1", Name: "lease", Attachments: []string{"4"}}))
+	require.NoError(t, w.Add(model.KeyBinary, model.ItemBinary{ID: "4", Name: "lease.pdf"}))
+	require.NoError(t, w.Close())
+
+	items, err := ParseArchive(bytes.NewReader(buf.Bytes()), "correct horse")
+	require.NoError(t, err)
+
+	// the note has been deleted since export, its attachment is still there
+	store := &fakeStore{binaries: []model.ItemBinary{{ID: "4", Name: "lease.pdf"}}}
+
+	report, err := Import(store, items, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StatusAdded, StatusDuplicate}, statuses(report))
+
+	require.Len(t, store.added, 1)
+	assert.Equal(t, Item{model.KeyText, model.ItemText{Name: "lease", Attachments: []string{"4"}}},
+		store.added[0], "note is linked to the attachment in the vault")
+}
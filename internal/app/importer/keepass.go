@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// standard strings of KeePass entry, the rest are custom fields
+const (
+	kpTitle    = "Title"
+	kpUserName = "UserName"
+	kpPassword = "Password"
+	kpURL      = "URL"
+	kpNotes    = "Notes"
+)
+
+type (
+	kpFile struct {
+		Meta struct {
+			RecycleBinUUID string `xml:"RecycleBinUUID"`
+			// Binaries are attachments entries refer to
+			Binaries []kpBinary `xml:"Binaries>Binary"`
+		} `xml:"Meta"`
+		Root struct {
+			Groups []kpGroup `xml:"Group"`
+		} `xml:"Root"`
+	}
+
+	kpBinary struct {
+		ID         string `xml:"ID,attr"`
+		Compressed bool   `xml:"Compressed,attr"`
+		Data       string `xml:",chardata"`
+	}
+
+	kpGroup struct {
+		UUID    string    `xml:"UUID"`
+		Name    string    `xml:"Name"`
+		Entries []kpEntry `xml:"Entry"`
+		Groups  []kpGroup `xml:"Group"`
+	}
+
+	// kpEntry is an entry without its history,
+	// older versions of entries are not imported
+	kpEntry struct {
+		Strings []struct {
+			Key   string `xml:"Key"`
+			Value string `xml:"Value"`
+		} `xml:"String"`
+		Binaries []struct {
+			Key   string `xml:"Key"`
+			Value struct {
+				Ref  string `xml:"Ref,attr"`
+				Data string `xml:",chardata"`
+			} `xml:"Value"`
+		} `xml:"Binary"`
+	}
+)
+
+// parseKeePass reads KeePass 2.x XML export. Entries with login
+// or password are credentials, entries with notes only are text.
+// Entries of the recycle bin are left out.
+func parseKeePass(r io.Reader) ([]Item, error) {
+	var f kpFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to parse KeePass XML: %w", err)
+	}
+
+	binaries := make(map[string]kpBinary, len(f.Meta.Binaries))
+	for _, b := range f.Meta.Binaries {
+		binaries[b.ID] = b
+	}
+
+	var (
+		items []Item
+		walk  func(g kpGroup) error
+	)
+
+	walk = func(g kpGroup) error {
+		if g.UUID != "" && g.UUID == f.Meta.RecycleBinUUID {
+			return nil
+		}
+
+		for _, e := range g.Entries {
+			res, err := e.items(binaries)
+			if err != nil {
+				return err
+			}
+
+			items = append(items, res...)
+		}
+
+		for _, sub := range g.Groups {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, g := range f.Root.Groups {
+		if err := walk(g); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+func (e kpEntry) items(binaries map[string]kpBinary) ([]Item, error) {
+	fields := make(map[string]string)
+
+	var custom []string
+
+	for _, s := range e.Strings {
+		fields[s.Key] = s.Value
+
+		switch s.Key {
+		case kpTitle, kpUserName, kpPassword, kpURL, kpNotes:
+		default:
+			custom = append(custom, labeled(s.Key, s.Value))
+		}
+	}
+
+	name := nameOf(fields[kpTitle], fields[kpURL], fields[kpUserName])
+
+	var items []Item
+
+	switch {
+	case fields[kpUserName] != "" || fields[kpPassword] != "":
+		items = append(items, Item{model.KeyCredentials, model.ItemCredentials{
+			Name: name,
+			Credentials: model.Credentials{
+				Login:    fields[kpUserName],
+				Password: fields[kpPassword],
+			},
+			Comment: comment(append([]string{labeled("URL", fields[kpURL]), fields[kpNotes]}, custom...)...),
+		}})
+	case fields[kpNotes] != "":
+		items = append(items, Item{model.KeyText, model.ItemText{
+			Name:    name,
+			Text:    fields[kpNotes],
+			Comment: comment(append([]string{labeled("URL", fields[kpURL])}, custom...)...),
+		}})
+	}
+
+	for _, b := range e.Binaries {
+		// attachments are either referenced or inlined
+		bin, ok := binaries[b.Value.Ref]
+		if b.Value.Ref == "" {
+			bin, ok = kpBinary{Data: b.Value.Data}, true
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("attachment %v of %v refers to missing binary %v", b.Key, name, b.Value.Ref)
+		}
+
+		data, err := bin.decode()
+		if err != nil {
+			return nil, fmt.Errorf("attachment %v of %v: %w", b.Key, name, err)
+		}
+
+		items = append(items, Item{model.KeyBinary, model.ItemBinary{
+			Name:      b.Key,
+			Extention: filepath.Ext(b.Key),
+			Size:      len(data),
+			Data:      base64.StdEncoding.EncodeToString(data),
+			Comment:   attachmentOf(name),
+		}})
+	}
+
+	return items, nil
+}
+
+// decode returns contents of attachment.
+func (b kpBinary) decode() ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode binary: %w", err)
+	}
+
+	if !b.Compressed {
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress binary: %w", err)
+	}
+
+	res, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress binary: %w", err)
+	}
+
+	return res, nil
+}
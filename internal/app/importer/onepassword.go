@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// op1Columns maps columns of 1Password CSV export, named differently
+// by different versions, to entry fields. Other columns are kept
+// in the comment.
+var op1Columns = map[string]string{
+	"title":      kpTitle,
+	"name":       kpTitle,
+	"url":        kpURL,
+	"urls":       kpURL,
+	"website":    kpURL,
+	"username":   kpUserName,
+	"login":      kpUserName,
+	"password":   kpPassword,
+	"notes":      kpNotes,
+	"notesplain": kpNotes,
+}
+
+// op1Skipped lists columns that are not worth keeping.
+var op1Skipped = map[string]bool{
+	"favorite": true,
+	"archived": true,
+	"uuid":     true,
+}
+
+// parse1Password reads 1Password CSV export, the first row is
+// expected to name the columns. Rows with login or password are
+// credentials, rows with notes only are text.
+func parse1Password(r io.Reader) ([]Item, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse 1Password CSV: %w", err)
+	}
+
+	hasTitle := false
+
+	for i, col := range header {
+		header[i] = strings.TrimSpace(col)
+		hasTitle = hasTitle || op1Columns[strings.ToLower(header[i])] == kpTitle
+	}
+
+	if !hasTitle {
+		return nil, fmt.Errorf("failed to parse 1Password CSV: no title column in the first row")
+	}
+
+	var items []Item
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse 1Password CSV: %w", err)
+		}
+
+		fields := make(map[string]string)
+
+		var custom []string
+
+		for i, v := range row {
+			if i >= len(header) {
+				break
+			}
+
+			col := strings.ToLower(header[i])
+
+			switch key, ok := op1Columns[col]; {
+			case ok:
+				fields[key] = v
+			case !op1Skipped[col]:
+				custom = append(custom, labeled(header[i], v))
+			}
+		}
+
+		name := nameOf(fields[kpTitle], fields[kpURL], fields[kpUserName])
+
+		switch {
+		case fields[kpUserName] != "" || fields[kpPassword] != "":
+			items = append(items, Item{model.KeyCredentials, model.ItemCredentials{
+				Name: name,
+				Credentials: model.Credentials{
+					Login:    fields[kpUserName],
+					Password: fields[kpPassword],
+				},
+				Comment: comment(append([]string{labeled("URL", fields[kpURL]), fields[kpNotes]}, custom...)...),
+			}})
+		case fields[kpNotes] != "":
+			items = append(items, Item{model.KeyText, model.ItemText{
+				Name:    name,
+				Text:    fields[kpNotes],
+				Comment: comment(append([]string{labeled("URL", fields[kpURL])}, custom...)...),
+			}})
+		}
+	}
+
+	return items, nil
+}
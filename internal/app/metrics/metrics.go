@@ -0,0 +1,224 @@
+// Package metrics collects server metrics and exposes them
+// in Prometheus format.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	chimw "github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const (
+	namespace = "ghostorange"
+
+	// scrapeTimeout limits time spent on querying storage
+	// while metrics are collected
+	scrapeTimeout = 5 * time.Second
+)
+
+type (
+	dbStater interface {
+		Stats() sql.DBStats
+	}
+
+	itemCounter interface {
+		TotalCount(ctx context.Context, dataType int) (int, error)
+	}
+
+	// itemsCollector reports number of stored items
+	// of every data type at scrape time.
+	itemsCollector struct {
+		counter itemCounter
+		desc    *prometheus.Desc
+	}
+)
+
+var (
+	registry = prometheus.NewRegistry()
+	factory  = promauto.With(registry)
+
+	requests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of served HTTP requests.",
+	}, []string{"method", "path", "status"})
+
+	latency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time spent serving HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path"})
+
+	authAttempts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "attempts_total",
+		Help:      "Number of login and registration attempts.",
+	}, []string{"action", "result"})
+
+	hashDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "argon2",
+		Name:      "hash_duration_seconds",
+		Help:      "Time spent hashing and verifying passwords and CVV codes.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"op"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Handler returns handler that serves collected metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// InstrumentMW returns middleware that counts requests and measures
+// their duration labelled with the route pattern.
+func InstrumentMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		path := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if p := rctx.RoutePattern(); p != "" && !strings.HasSuffix(p, "/*") {
+				path = p
+			}
+		}
+
+		requests.WithLabelValues(r.Method, path, strconv.Itoa(status)).Inc()
+		latency.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveAuth counts an authentication attempt of action
+// (login, register or export) that ended with err.
+func ObserveAuth(action string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	authAttempts.WithLabelValues(action, result).Inc()
+}
+
+// ObserveHash records duration of argon2 operation op
+// (generate or compare) started at start.
+func ObserveHash(op string, start time.Time) {
+	hashDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+// RegisterStorage registers collectors of storage s statistics.
+// Connection pool stats are collected if s exposes sql.DBStats,
+// item totals if s is able to count all stored items.
+func RegisterStorage(s any) error {
+	if db, ok := s.(dbStater); ok {
+		if err := registerDBStats(db); err != nil {
+			return err
+		}
+	}
+
+	if counter, ok := s.(itemCounter); ok {
+		return registry.Register(&itemsCollector{
+			counter: counter,
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, "storage", "items"),
+				"Number of stored items by data type.",
+				[]string{"data_type"}, nil),
+		})
+	}
+
+	return nil
+}
+
+func registerDBStats(db dbStater) error {
+	gauge := func(name, help string, f func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return f(db.Stats()) })
+	}
+
+	counter := func(name, help string, f func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return f(db.Stats()) })
+	}
+
+	cs := []prometheus.Collector{
+		gauge("max_open_connections", "Maximum number of open connections to the database.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("open_connections", "Number of established connections both in use and idle.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("in_use_connections", "Number of connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("idle_connections", "Number of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("wait_count_total", "Total number of connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+	}
+
+	for _, c := range cs {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ic *itemsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ic.desc
+}
+
+func (ic *itemsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	for i := 0; i < model.KeyLimit; i++ {
+		n, err := ic.counter.TotalCount(ctx, i)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(ic.desc, err)
+
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(ic.desc,
+			prometheus.GaugeValue,
+			float64(n),
+			strings.ToLower(model.GetItemTitle(i)))
+	}
+}
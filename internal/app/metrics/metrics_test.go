@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStorage struct{}
+
+func (fakeStorage) Stats() sql.DBStats {
+	return sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2}
+}
+
+func (fakeStorage) TotalCount(ctx context.Context, dataType int) (int, error) {
+	return dataType * 10, nil
+}
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(InstrumentMW)
+	r.Get("/v1/data/cards/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/data/cards/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/data/cards/2", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	ObserveAuth("login", nil)
+	ObserveAuth("login", io.EOF)
+
+	require.NoError(t, RegisterStorage(fakeStorage{}))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`ghostorange_http_requests_total{method="GET",path="/v1/data/cards/{id}",status="200"} 2`,
+		`ghostorange_http_requests_total{method="GET",path="unmatched",status="404"} 1`,
+		`ghostorange_auth_attempts_total{action="login",result="failure"} 1`,
+		`ghostorange_auth_attempts_total{action="login",result="success"} 1`,
+		`ghostorange_db_open_connections 3`,
+		`ghostorange_storage_items{data_type="text data"} 10`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// kinds of batch operations
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+
+	// MaxBatchOps is the most operations a batch may hold.
+	MaxBatchOps = 1000
+)
+
+type (
+	// BatchOp is an operation of a batch. Create and update
+	// require Item, delete requires ID.
+	BatchOp struct {
+		Op       string `json:"op"`
+		DataType int    `json:"data_type"`
+		ID       string `json:"id,omitempty"`
+		Item     any    `json:"item,omitempty"`
+	}
+
+	// BatchResult is the outcome of a batch operation.
+	// ID is the ID of the item the operation applies to,
+	// Error is set if the operation has not been applied.
+	BatchResult struct {
+		ID    string `json:"id"`
+		Error *Error `json:"error,omitempty"`
+	}
+
+	// Batch is a list of operations applied together.
+	Batch struct {
+		Ops []BatchOp `json:"ops"`
+	}
+
+	// BatchResults lists results in the order of operations.
+	BatchResults struct {
+		Results []BatchResult `json:"results"`
+	}
+)
+
+// Validate checks that op is complete.
+func (op BatchOp) Validate() error {
+	if op.DataType < 0 || op.DataType >= KeyLimit {
+		return fmt.Errorf("unsupported data type %v", op.DataType)
+	}
+
+	switch op.Op {
+	case BatchCreate, BatchUpdate:
+		if op.Item == nil {
+			return fmt.Errorf("%v requires item", op.Op)
+		}
+
+		if !matchesType(op.DataType, op.Item) {
+			return fmt.Errorf("item of type %T is not %v", op.Item, GetItemTitle(op.DataType))
+		}
+
+		if op.Op == BatchUpdate && op.ItemID() == "" {
+			return errors.New("update requires item ID")
+		}
+	case BatchDelete:
+		if op.ID == "" {
+			return errors.New("delete requires ID")
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	return nil
+}
+
+// ItemID returns ID of the item op applies to.
+func (op BatchOp) ItemID() string {
+	switch v := op.Item.(type) {
+	case ItemCredentials:
+		return v.ID
+	case ItemText:
+		return v.ID
+	case ItemBinary:
+		return v.ID
+	case ItemCard:
+		return v.ID
+	}
+
+	return op.ID
+}
+
+// WithItemID returns op with ID of its item set to id.
+func (op BatchOp) WithItemID(id string) BatchOp {
+	switch v := op.Item.(type) {
+	case ItemCredentials:
+		v.ID = id
+		op.Item = v
+	case ItemText:
+		v.ID = id
+		op.Item = v
+	case ItemBinary:
+		v.ID = id
+		op.Item = v
+	case ItemCard:
+		v.ID = id
+		op.Item = v
+	default:
+		op.ID = id
+	}
+
+	return op
+}
+
+func matchesType(dataType int, item any) bool {
+	switch item.(type) {
+	case ItemCredentials:
+		return dataType == KeyCredentials
+	case ItemText:
+		return dataType == KeyText
+	case ItemBinary:
+		return dataType == KeyBinary
+	case ItemCard:
+		return dataType == KeyCards
+	}
+
+	return false
+}
+
+// Err returns the error of result or nil if the operation
+// has been applied.
+func (r BatchResult) Err() error {
+	if r.Error == nil {
+		return nil
+	}
+
+	return r.Error
+}
+
+// UnmarshalJSON decodes the item as a value of data type
+// specific item type, e.g. ItemText.
+func (op *BatchOp) UnmarshalJSON(b []byte) error {
+	type batchOp BatchOp
+
+	var v struct {
+		batchOp
+		Item json.RawMessage `json:"item,omitempty"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*op = BatchOp(v.batchOp)
+
+	if len(v.Item) == 0 || string(v.Item) == "null" {
+		return nil
+	}
+
+	item, err := DecodeItemJSON(op.DataType, v.Item)
+	if err != nil {
+		return err
+	}
+
+	op.Item = item
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package model
+
+import "fmt"
+
+const (
+	// error codes used in error responses
+	ErrCodeBadRequest     = "bad_request"
+	ErrCodeUnauthorized   = "unauthorized"
+	ErrCodeInvalidSession = "invalid_session"
+	ErrCodeInvalidCVV     = "invalid_cvv"
+	ErrCodeUserExists     = "user_exists"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeTooLarge       = "too_large"
+	ErrCodeQuotaExceeded  = "quota_exceeded"
+	ErrCodeInternal       = "internal"
+)
+
+// Error is an envelope of every error response.
+// It also implements error interface so clients can
+// return it as is and branch on it with errors.Is.
+type Error struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+var (
+	ErrBadRequest     = &Error{Code: ErrCodeBadRequest, Message: "bad request"}
+	ErrUnauthorized   = &Error{Code: ErrCodeUnauthorized, Message: "wrong login or password"}
+	ErrInvalidSession = &Error{Code: ErrCodeInvalidSession, Message: "session is not valid"}
+	ErrInvalidCVV     = &Error{Code: ErrCodeInvalidCVV, Message: "CVV code is not valid"}
+	ErrUserExists     = &Error{Code: ErrCodeUserExists, Message: "user already exists"}
+	ErrNotFound       = &Error{Code: ErrCodeNotFound, Message: "item not found"}
+	ErrTooLarge       = &Error{Code: ErrCodeTooLarge, Message: "request is too large"}
+	ErrQuotaExceeded  = &Error{Code: ErrCodeQuotaExceeded, Message: "storage quota exceeded"}
+	ErrInternal       = &Error{Code: ErrCodeInternal, Message: "internal server error"}
+)
+
+func (e *Error) Error() string {
+	if e.Details == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%v: %v", e.Message, e.Details)
+}
+
+// Is reports whether target is an *Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code == e.Code
+}
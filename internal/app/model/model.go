@@ -43,6 +43,8 @@ type (
 		Text    string `json:"text"`
 		Name    string `json:"name"`
 		Comment string `json:"comment"`
+		// Attachments are IDs of binary items attached to the note
+		Attachments []string `json:"attachments,omitempty"`
 	}
 
 	ItemBinary struct {
@@ -65,6 +67,23 @@ type (
 		Comment            string    `json:"comment"`
 	}
 
+	// UsageEntry describes storage consumption
+	// of a single data type.
+	UsageEntry struct {
+		DataType int   `json:"data_type"`
+		Count    int   `json:"count"`
+		Bytes    int64 `json:"bytes"`
+	}
+
+	// Usage describes user's storage consumption
+	// and applied quotas. Zero limit means no limit.
+	Usage struct {
+		Entries    []UsageEntry `json:"entries"`
+		TotalBytes int64        `json:"total_bytes"`
+		ItemsLimit int          `json:"items_limit"`
+		BytesLimit int64        `json:"bytes_limit"`
+	}
+
 	Item interface {
 		ItemCredentials |
 			ItemText |
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,3 +35,31 @@ func TestEncodeDecodeItems(t *testing.T) {
 	assert.EqualValues(t, cases, val)
 }
 
+func TestBatchOp(t *testing.T) {
+	var batch Batch
+
+	err := json.Unmarshal([]byte(`{"ops": [
+		{"op": "create", "data_type": 1, "item": {"id": "", "text": "text", "name": "note", "comment": ""}},
+		{"op": "delete", "data_type": 0, "id": "1"}
+	]}`), &batch)
+	require.NoError(t, err)
+
+	assert.Equal(t, []BatchOp{
+		{Op: BatchCreate, DataType: KeyText, Item: ItemText{Text: "text", Name: "note"}},
+		{Op: BatchDelete, DataType: KeyCredentials, ID: "1"},
+	}, batch.Ops)
+
+	for _, op := range batch.Ops {
+		assert.NoError(t, op.Validate())
+	}
+
+	assert.Equal(t, "id", batch.Ops[0].WithItemID("id").ItemID())
+	assert.Empty(t, batch.Ops[0].ItemID(), "op is not changed")
+
+	assert.Error(t, BatchOp{Op: BatchUpdate, DataType: KeyText, Item: ItemText{}}.Validate(),
+		"update without ID")
+	assert.Error(t, BatchOp{Op: BatchCreate, DataType: KeyCards, Item: ItemText{}}.Validate(),
+		"item of another type")
+	assert.Error(t, BatchOp{Op: BatchDelete, DataType: KeyText}.Validate())
+	assert.Error(t, BatchOp{Op: "move", DataType: KeyText, ID: "1"}.Validate())
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Session is a client session saved to be resumed
+// without asking user's password again.
+type Session struct {
+	Login     string    `json:"login"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// CacheKey unlocks user's local cache
+	CacheKey []byte `json:"cache_key,omitempty"`
+}
+
+// Valid reports whether the session has not expired by now.
+func (s Session) Valid(now time.Time) bool {
+	return s.Token != "" && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// kinds of item changes reported by sync
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+type (
+	// Change describes the latest change of an item.
+	// Item holds current item data and is omitted
+	// for deleted items.
+	Change struct {
+		Seq       int64     `json:"seq"`
+		DataType  int       `json:"data_type"`
+		ID        string    `json:"id"`
+		Kind      string    `json:"kind"`
+		ChangedAt time.Time `json:"changed_at"`
+		Item      any       `json:"item,omitempty"`
+	}
+
+	// Changes is a page of user's changes in the order
+	// they were committed. Cursor is to be passed to get
+	// the changes that follow.
+	Changes struct {
+		Changes []Change `json:"changes"`
+		Cursor  string   `json:"cursor"`
+		HasMore bool     `json:"has_more"`
+	}
+
+	// SyncStatus describes the state of client's local cache.
+	SyncStatus struct {
+		Online    bool      `json:"online"`
+		Pending   int       `json:"pending"`
+		Conflicts int       `json:"conflicts"`
+		SyncedAt  time.Time `json:"synced_at"`
+	}
+
+	// Conflict is a local change that could not be applied
+	// to the server during synchronisation. Remote is nil when
+	// the item no longer exists on the server. Deleted is set if
+	// the local change deletes the item, Local is its last local
+	// version then.
+	Conflict struct {
+		ID         int64     `json:"id"`
+		DataType   int       `json:"data_type"`
+		ItemID     string    `json:"item_id"`
+		Name       string    `json:"name"`
+		Reason     string    `json:"reason"`
+		Local      any       `json:"local"`
+		Remote     any       `json:"remote"`
+		Deleted    bool      `json:"deleted"`
+		QueuedAt   time.Time `json:"queued_at"`
+		DetectedAt time.Time `json:"detected_at"`
+	}
+)
+
+// UnmarshalJSON decodes the item as a value of data type
+// specific item type, e.g. ItemText.
+func (c *Change) UnmarshalJSON(b []byte) error {
+	type change Change
+
+	var v struct {
+		change
+		Item json.RawMessage `json:"item,omitempty"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*c = Change(v.change)
+
+	if len(v.Item) == 0 || string(v.Item) == "null" {
+		return nil
+	}
+
+	item, err := DecodeItemJSON(c.DataType, v.Item)
+	if err != nil {
+		return err
+	}
+
+	c.Item = item
+
+	return nil
+}
@@ -0,0 +1,326 @@
+package pb
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+var changeKinds = map[string]ChangeKind{
+	model.ChangeCreated: ChangeKind_CHANGE_KIND_CREATED,
+	model.ChangeUpdated: ChangeKind_CHANGE_KIND_UPDATED,
+	model.ChangeDeleted: ChangeKind_CHANGE_KIND_DELETED,
+}
+
+// NewItem returns Item that holds data of one of model item types.
+func NewItem(data any) (*Item, error) {
+	switch v := data.(type) {
+	case model.ItemCredentials:
+		return &Item{Item: &Item_Credentials{Credentials: &CredentialsItem{
+			Id: v.ID,
+			Credentials: &Credentials{
+				Login:    v.Credentials.Login,
+				Password: v.Credentials.Password,
+			},
+			Name:    v.Name,
+			Comment: v.Comment,
+		}}}, nil
+	case model.ItemText:
+		return &Item{Item: &Item_Text{Text: &Text{
+			Id:          v.ID,
+			Text:        v.Text,
+			Name:        v.Name,
+			Comment:     v.Comment,
+			Attachments: v.Attachments,
+		}}}, nil
+	case model.ItemBinary:
+		return &Item{Item: &Item_Binary{Binary: &Binary{
+			Id:        v.ID,
+			Size:      int64(v.Size),
+			Extention: v.Extention,
+			Data:      v.Data,
+			Name:      v.Name,
+			Comment:   v.Comment,
+		}}}, nil
+	case model.ItemCard:
+		return &Item{Item: &Item_Card{Card: NewCard(v)}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported item type %T", data)
+}
+
+// NewCard returns Card that holds item.
+func NewCard(item model.ItemCard) *Card {
+	return &Card{
+		Id:             item.ID,
+		Number:         item.Number,
+		ExpirationDate: timestamppb.New(item.Exp),
+		HolderName:     item.CardholderName,
+		HolderSurename: item.CardholderSurename,
+		CvvHash:        item.CVVHash,
+		Name:           item.Name,
+		Comment:        item.Comment,
+	}
+}
+
+// Model returns data type and value of model item type
+// the item holds.
+func (x *Item) Model() (int, any, error) {
+	switch v := x.GetItem().(type) {
+	case *Item_Credentials:
+		return model.KeyCredentials, model.ItemCredentials{
+			ID: v.Credentials.GetId(),
+			Credentials: model.Credentials{
+				Login:    v.Credentials.GetCredentials().GetLogin(),
+				Password: v.Credentials.GetCredentials().GetPassword(),
+			},
+			Name:    v.Credentials.GetName(),
+			Comment: v.Credentials.GetComment(),
+		}, nil
+	case *Item_Text:
+		return model.KeyText, model.ItemText{
+			ID:          v.Text.GetId(),
+			Text:        v.Text.GetText(),
+			Name:        v.Text.GetName(),
+			Comment:     v.Text.GetComment(),
+			Attachments: v.Text.GetAttachments(),
+		}, nil
+	case *Item_Binary:
+		return model.KeyBinary, model.ItemBinary{
+			ID:        v.Binary.GetId(),
+			Size:      int(v.Binary.GetSize()),
+			Extention: v.Binary.GetExtention(),
+			Data:      v.Binary.GetData(),
+			Name:      v.Binary.GetName(),
+			Comment:   v.Binary.GetComment(),
+		}, nil
+	case *Item_Card:
+		return model.KeyCards, v.Card.Model(), nil
+	}
+
+	return 0, nil, fmt.Errorf("item is empty")
+}
+
+// Model returns model.ItemCard the card holds.
+func (x *Card) Model() model.ItemCard {
+	return model.ItemCard{
+		ID:                 x.GetId(),
+		Number:             x.GetNumber(),
+		Exp:                timeOf(x.GetExpirationDate()),
+		CardholderName:     x.GetHolderName(),
+		CardholderSurename: x.GetHolderSurename(),
+		CVVHash:            x.GetCvvHash(),
+		Name:               x.GetName(),
+		Comment:            x.GetComment(),
+	}
+}
+
+// NewItems returns Items that hold data, a slice of model items
+// as returned by storage.
+func NewItems(data any) (*Items, error) {
+	switch v := data.(type) {
+	case []model.ItemCredentials:
+		return newItems(v)
+	case []model.ItemText:
+		return newItems(v)
+	case []model.ItemBinary:
+		return newItems(v)
+	case []model.ItemCard:
+		return newItems(v)
+	}
+
+	return nil, fmt.Errorf("unsupported items type %T", data)
+}
+
+func newItems[T model.Item](data []T) (*Items, error) {
+	res := &Items{Items: make([]*Item, 0, len(data))}
+
+	for _, v := range data {
+		item, err := NewItem(v)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Items = append(res.Items, item)
+	}
+
+	return res, nil
+}
+
+// Model returns a slice of model items of dataType,
+// e.g. []model.ItemText, the way GetData of adapters does.
+func (x *Items) Model(dataType int) (any, error) {
+	switch dataType {
+	case model.KeyCredentials:
+		return itemsModel[model.ItemCredentials](x.GetItems())
+	case model.KeyText:
+		return itemsModel[model.ItemText](x.GetItems())
+	case model.KeyBinary:
+		return itemsModel[model.ItemBinary](x.GetItems())
+	case model.KeyCards:
+		return itemsModel[model.ItemCard](x.GetItems())
+	}
+
+	return nil, fmt.Errorf("unsupported data type %v", dataType)
+}
+
+func itemsModel[T model.Item](items []*Item) ([]T, error) {
+	res := make([]T, 0, len(items))
+
+	for _, item := range items {
+		_, v, err := item.Model()
+		if err != nil {
+			return nil, err
+		}
+
+		t, ok := v.(T)
+		if !ok {
+			return nil, fmt.Errorf("unexpected item type %T", v)
+		}
+
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+// NewChange returns Change that describes c.
+func NewChange(c model.Change) (*Change, error) {
+	res := &Change{
+		Seq:       c.Seq,
+		DataType:  DataType(c.DataType),
+		Id:        c.ID,
+		Kind:      changeKinds[c.Kind],
+		ChangedAt: timestamppb.New(c.ChangedAt),
+	}
+
+	if c.Item != nil {
+		item, err := NewItem(c.Item)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Item = item
+	}
+
+	return res, nil
+}
+
+// Model returns model.Change the change describes.
+func (x *Change) Model() (model.Change, error) {
+	res := model.Change{
+		Seq:       x.GetSeq(),
+		DataType:  int(x.GetDataType()),
+		ID:        x.GetId(),
+		ChangedAt: timeOf(x.GetChangedAt()),
+	}
+
+	for kind, v := range changeKinds {
+		if v == x.GetKind() {
+			res.Kind = kind
+		}
+	}
+
+	if x.GetItem() != nil {
+		_, item, err := x.GetItem().Model()
+		if err != nil {
+			return res, err
+		}
+
+		res.Item = item
+	}
+
+	return res, nil
+}
+
+// NewChanges returns Changes that hold c.
+func NewChanges(c model.Changes) (*Changes, error) {
+	res := &Changes{
+		Changes: make([]*Change, 0, len(c.Changes)),
+		Cursor:  c.Cursor,
+		HasMore: c.HasMore,
+	}
+
+	for _, v := range c.Changes {
+		change, err := NewChange(v)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Changes = append(res.Changes, change)
+	}
+
+	return res, nil
+}
+
+// Model returns model.Changes the changes hold.
+func (x *Changes) Model() (model.Changes, error) {
+	res := model.Changes{
+		Changes: make([]model.Change, 0, len(x.GetChanges())),
+		Cursor:  x.GetCursor(),
+		HasMore: x.GetHasMore(),
+	}
+
+	for _, v := range x.GetChanges() {
+		c, err := v.Model()
+		if err != nil {
+			return res, err
+		}
+
+		res.Changes = append(res.Changes, c)
+	}
+
+	return res, nil
+}
+
+// NewUsage returns Usage that describes u.
+func NewUsage(u model.Usage) *Usage {
+	res := &Usage{
+		Entries:    make([]*UsageEntry, 0, len(u.Entries)),
+		TotalBytes: u.TotalBytes,
+		ItemsLimit: int64(u.ItemsLimit),
+		BytesLimit: u.BytesLimit,
+	}
+
+	for _, e := range u.Entries {
+		res.Entries = append(res.Entries, &UsageEntry{
+			DataType: DataType(e.DataType),
+			Count:    int64(e.Count),
+			Bytes:    e.Bytes,
+		})
+	}
+
+	return res
+}
+
+// Model returns model.Usage the usage describes.
+func (x *Usage) Model() model.Usage {
+	res := model.Usage{
+		Entries:    make([]model.UsageEntry, 0, len(x.GetEntries())),
+		TotalBytes: x.GetTotalBytes(),
+		ItemsLimit: int(x.GetItemsLimit()),
+		BytesLimit: x.GetBytesLimit(),
+	}
+
+	for _, e := range x.GetEntries() {
+		res.Entries = append(res.Entries, model.UsageEntry{
+			DataType: int(e.GetDataType()),
+			Count:    int(e.GetCount()),
+			Bytes:    e.GetBytes(),
+		})
+	}
+
+	return res
+}
+
+// timeOf returns time ts holds or zero time if ts is not set.
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
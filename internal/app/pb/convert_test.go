@@ -0,0 +1,84 @@
+package pb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+func TestItemRoundTrip(t *testing.T) {
+	tests := []struct {
+		dataType int
+		item     any
+	}{
+		{model.KeyCredentials, model.ItemCredentials{ID: "1", Name: "mail",
+			Credentials: model.Credentials{Login: "login", Password: "password"}}},
+		{model.KeyText, model.ItemText{ID: "2", Text: "text", Name: "note", Comment: "comment",
+			Attachments: []string{"3"}}},
+		{model.KeyBinary, model.ItemBinary{ID: "3", Size: 4, Extention: "bin", Data: "ZGF0YQ==", Name: "file"}},
+		{model.KeyCards, model.ItemCard{ID: "4", Number: "1001", CVVHash: "hash", Name: "card",
+			Exp: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)}},
+		{model.KeyCards, model.ItemCard{ID: "5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(model.GetItemTitle(tt.dataType), func(t *testing.T) {
+			item, err := NewItem(tt.item)
+			require.NoError(t, err)
+
+			dataType, got, err := item.Model()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.dataType, dataType)
+			assert.Equal(t, tt.item, got)
+		})
+	}
+
+	_, err := NewItem(model.Credentials{})
+	assert.Error(t, err)
+
+	_, _, err = (&Item{}).Model()
+	assert.Error(t, err)
+}
+
+func TestItemsModel(t *testing.T) {
+	items, err := NewItems([]model.ItemText{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+
+	got, err := items.Model(model.KeyText)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ItemText{{ID: "1"}, {ID: "2"}}, got)
+
+	_, err = items.Model(model.KeyCards)
+	assert.Error(t, err, "items of another data type are decoded")
+
+	got, err = (&Items{}).Model(model.KeyCards)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ItemCard{}, got)
+}
+
+func TestChangesRoundTrip(t *testing.T) {
+	ts := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	want := model.Changes{
+		Changes: []model.Change{
+			{Seq: 1, DataType: model.KeyText, ID: "1", Kind: model.ChangeCreated, ChangedAt: ts,
+				Item: model.ItemText{ID: "1"}},
+			{Seq: 2, DataType: model.KeyText, ID: "1", Kind: model.ChangeUpdated, ChangedAt: ts,
+				Item: model.ItemText{ID: "1", Text: "text"}},
+			{Seq: 3, DataType: model.KeyText, ID: "1", Kind: model.ChangeDeleted, ChangedAt: ts},
+		},
+		Cursor:  "3",
+		HasMore: true,
+	}
+
+	changes, err := NewChanges(want)
+	require.NoError(t, err)
+
+	got, err := changes.Model()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,534 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: ghostorange.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	GhostOrange_Register_FullMethodName   = "/ghostorange.v1.GhostOrange/Register"
+	GhostOrange_Login_FullMethodName      = "/ghostorange.v1.GhostOrange/Login"
+	GhostOrange_GetUsage_FullMethodName   = "/ghostorange.v1.GhostOrange/GetUsage"
+	GhostOrange_Count_FullMethodName      = "/ghostorange.v1.GhostOrange/Count"
+	GhostOrange_GetData_FullMethodName    = "/ghostorange.v1.GhostOrange/GetData"
+	GhostOrange_AddData_FullMethodName    = "/ghostorange.v1.GhostOrange/AddData"
+	GhostOrange_UpdateData_FullMethodName = "/ghostorange.v1.GhostOrange/UpdateData"
+	GhostOrange_DeleteData_FullMethodName = "/ghostorange.v1.GhostOrange/DeleteData"
+	GhostOrange_RevealCard_FullMethodName = "/ghostorange.v1.GhostOrange/RevealCard"
+	GhostOrange_Sync_FullMethodName       = "/ghostorange.v1.GhostOrange/Sync"
+	GhostOrange_Events_FullMethodName     = "/ghostorange.v1.GhostOrange/Events"
+)
+
+// GhostOrangeClient is the client API for GhostOrange service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GhostOrangeClient interface {
+	// Register adds a new user and opens a session.
+	Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Session, error)
+	// Login opens a session after verifying login and password.
+	Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Session, error)
+	// GetUsage returns user's storage consumption and quotas.
+	GetUsage(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Usage, error)
+	// Count returns the number of user's items of data type.
+	Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountResponse, error)
+	// GetData lists user's items of data type.
+	GetData(ctx context.Context, in *GetDataRequest, opts ...grpc.CallOption) (*Items, error)
+	// AddData creates an item.
+	AddData(ctx context.Context, in *Item, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// UpdateData replaces an item with the same ID.
+	UpdateData(ctx context.Context, in *Item, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteData deletes an item.
+	DeleteData(ctx context.Context, in *DeleteDataRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RevealCard returns card details after verifying CVV code.
+	RevealCard(ctx context.Context, in *RevealCardRequest, opts ...grpc.CallOption) (*Card, error)
+	// Sync lists changes of user's items made after the change
+	// since cursor points to. Empty cursor lists all items.
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*Changes, error)
+	// Events streams changes of user's items without item data
+	// until the client cancels or the server shuts down.
+	Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (GhostOrange_EventsClient, error)
+}
+
+type ghostOrangeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGhostOrangeClient(cc grpc.ClientConnInterface) GhostOrangeClient {
+	return &ghostOrangeClient{cc}
+}
+
+func (c *ghostOrangeClient) Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Session, error) {
+	out := new(Session)
+	err := c.cc.Invoke(ctx, GhostOrange_Register_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Session, error) {
+	out := new(Session)
+	err := c.cc.Invoke(ctx, GhostOrange_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) GetUsage(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Usage, error) {
+	out := new(Usage)
+	err := c.cc.Invoke(ctx, GhostOrange_GetUsage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountResponse, error) {
+	out := new(CountResponse)
+	err := c.cc.Invoke(ctx, GhostOrange_Count_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) GetData(ctx context.Context, in *GetDataRequest, opts ...grpc.CallOption) (*Items, error) {
+	out := new(Items)
+	err := c.cc.Invoke(ctx, GhostOrange_GetData_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) AddData(ctx context.Context, in *Item, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GhostOrange_AddData_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) UpdateData(ctx context.Context, in *Item, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GhostOrange_UpdateData_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) DeleteData(ctx context.Context, in *DeleteDataRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GhostOrange_DeleteData_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) RevealCard(ctx context.Context, in *RevealCardRequest, opts ...grpc.CallOption) (*Card, error) {
+	out := new(Card)
+	err := c.cc.Invoke(ctx, GhostOrange_RevealCard_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*Changes, error) {
+	out := new(Changes)
+	err := c.cc.Invoke(ctx, GhostOrange_Sync_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ghostOrangeClient) Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (GhostOrange_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GhostOrange_ServiceDesc.Streams[0], GhostOrange_Events_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ghostOrangeEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GhostOrange_EventsClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type ghostOrangeEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ghostOrangeEventsClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GhostOrangeServer is the server API for GhostOrange service.
+// All implementations must embed UnimplementedGhostOrangeServer
+// for forward compatibility
+type GhostOrangeServer interface {
+	// Register adds a new user and opens a session.
+	Register(context.Context, *Credentials) (*Session, error)
+	// Login opens a session after verifying login and password.
+	Login(context.Context, *Credentials) (*Session, error)
+	// GetUsage returns user's storage consumption and quotas.
+	GetUsage(context.Context, *emptypb.Empty) (*Usage, error)
+	// Count returns the number of user's items of data type.
+	Count(context.Context, *CountRequest) (*CountResponse, error)
+	// GetData lists user's items of data type.
+	GetData(context.Context, *GetDataRequest) (*Items, error)
+	// AddData creates an item.
+	AddData(context.Context, *Item) (*emptypb.Empty, error)
+	// UpdateData replaces an item with the same ID.
+	UpdateData(context.Context, *Item) (*emptypb.Empty, error)
+	// DeleteData deletes an item.
+	DeleteData(context.Context, *DeleteDataRequest) (*emptypb.Empty, error)
+	// RevealCard returns card details after verifying CVV code.
+	RevealCard(context.Context, *RevealCardRequest) (*Card, error)
+	// Sync lists changes of user's items made after the change
+	// since cursor points to. Empty cursor lists all items.
+	Sync(context.Context, *SyncRequest) (*Changes, error)
+	// Events streams changes of user's items without item data
+	// until the client cancels or the server shuts down.
+	Events(*emptypb.Empty, GhostOrange_EventsServer) error
+	mustEmbedUnimplementedGhostOrangeServer()
+}
+
+// UnimplementedGhostOrangeServer must be embedded to have forward compatible implementations.
+type UnimplementedGhostOrangeServer struct {
+}
+
+func (UnimplementedGhostOrangeServer) Register(context.Context, *Credentials) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedGhostOrangeServer) Login(context.Context, *Credentials) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedGhostOrangeServer) GetUsage(context.Context, *emptypb.Empty) (*Usage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedGhostOrangeServer) Count(context.Context, *CountRequest) (*CountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Count not implemented")
+}
+func (UnimplementedGhostOrangeServer) GetData(context.Context, *GetDataRequest) (*Items, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetData not implemented")
+}
+func (UnimplementedGhostOrangeServer) AddData(context.Context, *Item) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddData not implemented")
+}
+func (UnimplementedGhostOrangeServer) UpdateData(context.Context, *Item) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateData not implemented")
+}
+func (UnimplementedGhostOrangeServer) DeleteData(context.Context, *DeleteDataRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteData not implemented")
+}
+func (UnimplementedGhostOrangeServer) RevealCard(context.Context, *RevealCardRequest) (*Card, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevealCard not implemented")
+}
+func (UnimplementedGhostOrangeServer) Sync(context.Context, *SyncRequest) (*Changes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
+func (UnimplementedGhostOrangeServer) Events(*emptypb.Empty, GhostOrange_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedGhostOrangeServer) mustEmbedUnimplementedGhostOrangeServer() {}
+
+// UnsafeGhostOrangeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GhostOrangeServer will
+// result in compilation errors.
+type UnsafeGhostOrangeServer interface {
+	mustEmbedUnimplementedGhostOrangeServer()
+}
+
+func RegisterGhostOrangeServer(s grpc.ServiceRegistrar, srv GhostOrangeServer) {
+	s.RegisterService(&GhostOrange_ServiceDesc, srv)
+}
+
+func _GhostOrange_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).Register(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).Login(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).GetUsage(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_Count_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).Count(ctx, req.(*CountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_GetData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).GetData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_GetData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).GetData(ctx, req.(*GetDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_AddData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Item)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).AddData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_AddData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).AddData(ctx, req.(*Item))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_UpdateData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Item)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).UpdateData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_UpdateData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).UpdateData(ctx, req.(*Item))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_DeleteData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).DeleteData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_DeleteData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).DeleteData(ctx, req.(*DeleteDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_RevealCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevealCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).RevealCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_RevealCard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).RevealCard(ctx, req.(*RevealCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GhostOrangeServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GhostOrange_Sync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GhostOrangeServer).Sync(ctx, req.(*SyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GhostOrange_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GhostOrangeServer).Events(m, &ghostOrangeEventsServer{stream})
+}
+
+type GhostOrange_EventsServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type ghostOrangeEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ghostOrangeEventsServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// GhostOrange_ServiceDesc is the grpc.ServiceDesc for GhostOrange service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GhostOrange_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ghostorange.v1.GhostOrange",
+	HandlerType: (*GhostOrangeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _GhostOrange_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _GhostOrange_Login_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _GhostOrange_GetUsage_Handler,
+		},
+		{
+			MethodName: "Count",
+			Handler:    _GhostOrange_Count_Handler,
+		},
+		{
+			MethodName: "GetData",
+			Handler:    _GhostOrange_GetData_Handler,
+		},
+		{
+			MethodName: "AddData",
+			Handler:    _GhostOrange_AddData_Handler,
+		},
+		{
+			MethodName: "UpdateData",
+			Handler:    _GhostOrange_UpdateData_Handler,
+		},
+		{
+			MethodName: "DeleteData",
+			Handler:    _GhostOrange_DeleteData_Handler,
+		},
+		{
+			MethodName: "RevealCard",
+			Handler:    _GhostOrange_RevealCard_Handler,
+		},
+		{
+			MethodName: "Sync",
+			Handler:    _GhostOrange_Sync_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _GhostOrange_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ghostorange.proto",
+}
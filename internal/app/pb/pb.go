@@ -0,0 +1,5 @@
+// Package pb contains gRPC service and messages generated
+// from api/ghostorange.proto.
+package pb
+
+//go:generate protoc -I ../../../api --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ghostorange.proto
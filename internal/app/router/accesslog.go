@@ -0,0 +1,129 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	chimw "github.com/go-chi/chi/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/usa4ev/ghostorange/internal/pkg/redact"
+)
+
+// maxLoggedBody is the number of request body bytes
+// written to debug access log.
+const maxLoggedBody = 4 << 10
+
+type (
+	logEntryKey struct{}
+
+	// logEntry holds request details that are only known
+	// to handlers but are worth logging.
+	logEntry struct {
+		err error
+	}
+
+	// bodyCapture keeps first bytes of request body
+	// as the handler reads it.
+	bodyCapture struct {
+		io.ReadCloser
+		buf bytes.Buffer
+	}
+)
+
+func (bc *bodyCapture) Read(p []byte) (int, error) {
+	n, err := bc.ReadCloser.Read(p)
+
+	if free := maxLoggedBody - bc.buf.Len(); free > 0 && n > 0 {
+		if free > n {
+			free = n
+		}
+
+		bc.buf.Write(p[:free])
+	}
+
+	return n, err
+}
+
+// AccessLogMW returns middleware that writes an entry to lg
+// for every served request. With debug level enabled request
+// bodies are logged as well. Secrets are redacted from bodies
+// and errors.
+func AccessLogMW(lg *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &logEntry{}
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			var body *bodyCapture
+			if lg.Core().Enabled(zapcore.DebugLevel) && r.Body != nil {
+				body = &bodyCapture{ReadCloser: r.Body}
+				r.Body = body
+			}
+
+			ctx := context.WithValue(r.Context(), logEntryKey{}, entry)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			fields := []zap.Field{
+				zap.String("request_id", chimw.GetReqID(r.Context())),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", status),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("remote_addr", r.RemoteAddr),
+			}
+
+			if entry.err != nil {
+				fields = append(fields, zap.String("error", redact.String(entry.err.Error())))
+			}
+
+			if body != nil && body.buf.Len() > 0 {
+				fields = append(fields,
+					zap.ByteString("body", redactBody(r.Header.Get("Content-Type"), body.buf.Bytes())))
+			}
+
+			switch {
+			case status >= http.StatusInternalServerError:
+				lg.Error("request served", fields...)
+			case status >= http.StatusBadRequest:
+				lg.Warn("request served", fields...)
+			default:
+				lg.Info("request served", fields...)
+			}
+		})
+	}
+}
+
+// LogError attaches err to the access log entry of r.
+// It has no effect if r is not served by AccessLogMW.
+func LogError(r *http.Request, err error) {
+	if entry, ok := r.Context().Value(logEntryKey{}).(*logEntry); ok {
+		entry.err = err
+	}
+}
+
+func redactBody(contentType string, body []byte) []byte {
+	// Plain bodies carry nothing but secrets, e.g. CVV codes
+	if !strings.HasPrefix(contentType, "application/json") {
+		return []byte(redact.Placeholder)
+	}
+
+	if len(body) == maxLoggedBody {
+		// Truncated document can not be parsed
+		return []byte(redact.String(string(body)))
+	}
+
+	return redact.JSON(body)
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLogMW(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	h := AccessLogMW(zap.New(core))(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.ReadAll(r.Body)
+			LogError(r, errors.New("failed to store card 4111111111111111"))
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+	body := `{"credentials":{"login":"bob","password":"dragon"},"name":"mail"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/data?data_type=0", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, 1, logs.Len())
+
+	entry := logs.All()[0]
+	fields := entry.ContextMap()
+
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	assert.EqualValues(t, http.StatusInternalServerError, fields["status"])
+	assert.Equal(t, "/v1/data", fields["path"])
+	assert.NotContains(t, fields["error"], "4111111111111111")
+	assert.NotContains(t, fields["body"], "dragon")
+	assert.Contains(t, fields["body"], "bob")
+}
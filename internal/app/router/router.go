@@ -19,8 +19,11 @@ type (
 	}
 )
 
-func NewRouter(h handled) http.Handler {
+// NewRouter returns a router that serves handlers of h.
+// Middlewares mws are applied to every request.
+func NewRouter(h handled, mws ...func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
+	r.Use(mws...)
 	r.Route("/", defaultRoute(h))
 
 	return r
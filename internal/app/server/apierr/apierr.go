@@ -0,0 +1,121 @@
+// Package apierr composes JSON error responses.
+// Errors returned by storage and auth are mapped to
+// model.Error codes, anything unknown is reported as an internal
+// error without details so no internals leak to clients.
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	chimw "github.com/go-chi/chi/middleware"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth"
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/router"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+)
+
+// Error is an error with response status and code attached.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%v: %v", e.Message, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// BadRequest returns an error that is reported
+// with http.StatusBadRequest and given message.
+func BadRequest(message string, err error) *Error {
+	return &Error{
+		Status:  http.StatusBadRequest,
+		Code:    model.ErrCodeBadRequest,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// Internal returns an error that is reported with
+// http.StatusInternalServerError. Message and err are never sent
+// to the client, but are kept for logging.
+func Internal(message string, err error) *Error {
+	return &Error{
+		Status:  http.StatusInternalServerError,
+		Code:    model.ErrCodeInternal,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// Write writes JSON encoded model.Error that describes err.
+// The err itself is attached to the request access log entry.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	router.LogError(r, err)
+
+	status, res := Resolve(err)
+	res.RequestID = chimw.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(res)
+}
+
+// Resolve returns response status and error envelope for err.
+func Resolve(err error) (int, model.Error) {
+	var (
+		apiErr *Error
+		maxErr *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &apiErr) && apiErr.Code != model.ErrCodeInternal:
+		res := model.Error{Code: apiErr.Code, Message: apiErr.Message}
+		if apiErr.Err != nil {
+			res.Details = apiErr.Err.Error()
+		}
+
+		return apiErr.Status, res
+	case errors.Is(err, auth.ErrUnathorized):
+		return http.StatusUnauthorized,
+			model.Error{Code: model.ErrCodeUnauthorized, Message: auth.ErrUnathorized.Error()}
+	case errors.Is(err, auth.ErrUserAlreadyExists):
+		return http.StatusConflict,
+			model.Error{Code: model.ErrCodeUserExists, Message: auth.ErrUserAlreadyExists.Error()}
+	case errors.Is(err, auth.ErrInvalidCVV):
+		return http.StatusUnauthorized,
+			model.Error{Code: model.ErrCodeInvalidCVV, Message: auth.ErrInvalidCVV.Error()}
+	case errors.Is(err, session.ErrInvalidToken):
+		return http.StatusUnauthorized,
+			model.Error{Code: model.ErrCodeInvalidSession, Message: session.ErrInvalidToken.Error()}
+	case errors.Is(err, strgerrors.ErrNotFound):
+		return http.StatusNotFound,
+			model.Error{Code: model.ErrCodeNotFound, Message: strgerrors.ErrNotFound.Error()}
+	case errors.Is(err, strgerrors.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage,
+			model.Error{Code: model.ErrCodeQuotaExceeded, Message: err.Error()}
+	case errors.As(err, &maxErr):
+		return http.StatusRequestEntityTooLarge,
+			model.Error{Code: model.ErrCodeTooLarge, Message: "request body is too large",
+				Details: fmt.Sprintf("limit is %v bytes", maxErr.Limit)}
+	}
+
+	return http.StatusInternalServerError,
+		model.Error{Code: model.ErrCodeInternal, Message: "internal server error"}
+}
@@ -0,0 +1,62 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// ErrorDomain is the domain of google.rpc.ErrorInfo
+// attached to gRPC error statuses.
+const ErrorDomain = "ghostorange"
+
+// grpcCodes maps error codes to gRPC status codes.
+var grpcCodes = map[string]codes.Code{
+	model.ErrCodeBadRequest:     codes.InvalidArgument,
+	model.ErrCodeUnauthorized:   codes.Unauthenticated,
+	model.ErrCodeInvalidSession: codes.Unauthenticated,
+	model.ErrCodeInvalidCVV:     codes.PermissionDenied,
+	model.ErrCodeUserExists:     codes.AlreadyExists,
+	model.ErrCodeNotFound:       codes.NotFound,
+	model.ErrCodeTooLarge:       codes.ResourceExhausted,
+	model.ErrCodeQuotaExceeded:  codes.ResourceExhausted,
+	model.ErrCodeInternal:       codes.Internal,
+}
+
+// Status returns gRPC status that describes err the same way
+// Write does. The error code is attached as google.rpc.ErrorInfo
+// reason, details as "details" metadata entry.
+func Status(err error) *status.Status {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+
+	_, res := Resolve(err)
+
+	st := status.New(grpcCode(res.Code), res.Message)
+
+	info := &errdetails.ErrorInfo{Reason: res.Code, Domain: ErrorDomain}
+	if res.Details != "" {
+		info.Metadata = map[string]string{"details": res.Details}
+	}
+
+	if withInfo, err := st.WithDetails(info); err == nil {
+		st = withInfo
+	}
+
+	return st
+}
+
+// grpcCode returns gRPC status code for error code.
+func grpcCode(code string) codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+
+	return codes.Unknown
+}
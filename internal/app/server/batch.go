@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/router"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+)
+
+// Batch applies JSON encoded model.Batch in one transaction and
+// responds with model.BatchResults. An operation the storage
+// refuses does not fail the batch, its error is reported in its
+// result. A malformed operation fails the whole batch.
+func (srv *Server) Batch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
+
+		return
+	}
+
+	defer r.Body.Close()
+	msg, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierr.Write(w, r, decodeErr(err))
+
+		return
+	}
+
+	var batch model.Batch
+	if err := json.Unmarshal(msg, &batch); err != nil {
+		apierr.Write(w, r, apierr.BadRequest("failed to decode JSON", err))
+
+		return
+	}
+
+	if len(batch.Ops) > model.MaxBatchOps {
+		apierr.Write(w, r, apierr.BadRequest(
+			fmt.Sprintf("batch holds more than %v operations", model.MaxBatchOps), nil))
+
+		return
+	}
+
+	for i, op := range batch.Ops {
+		if err := op.Validate(); err != nil {
+			apierr.Write(w, r, apierr.BadRequest(fmt.Sprintf("bad operation %v", i), err))
+
+			return
+		}
+
+		// IDs are set here, so they can be reported
+		if op.Op == model.BatchCreate && op.ItemID() == "" {
+			batch.Ops[i] = op.WithItemID(uuid.NewString())
+		}
+	}
+
+	errs, err := srv.dataStrg.Batch(r.Context(), userID, batch.Ops)
+	if err != nil {
+		apierr.Write(w, r, err)
+
+		return
+	}
+
+	res := model.BatchResults{Results: make([]model.BatchResult, len(batch.Ops))}
+
+	for i, op := range batch.Ops {
+		res.Results[i].ID = op.ItemID()
+
+		if errs[i] == nil {
+			continue
+		}
+
+		status, e := apierr.Resolve(errs[i])
+		if status == http.StatusInternalServerError {
+			router.LogError(r, errs[i])
+		}
+
+		res.Results[i].Error = &e
+	}
+
+	msg, err = json.Marshal(res)
+	if err != nil {
+		apierr.Write(w, r, apierr.Internal("failed to encode data", err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", CTJSON)
+	w.Write(msg)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+)
+
+const (
+	CTEventStream = "text/event-stream"
+
+	// EventChange is the type of events that announce item changes
+	EventChange = "change"
+
+	// heartbeatInterval is how often an idle stream is written to,
+	// so proxies do not close it and dead clients are detected
+	heartbeatInterval = 30 * time.Second
+)
+
+// Events streams changes of user's items as server-sent events
+// of EventChange type. Every event carries JSON encoded model.Change
+// without item data, clients are expected to sync to get it.
+// The stream ends when the server shuts down or the client
+// falls behind, then clients are expected to reconnect and sync,
+// since changes might have been missed.
+func (srv *Server) Events(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
+
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("response writer does not support streaming", nil))
+
+		return
+	}
+
+	events, err := srv.dataStrg.Subscribe(r.Context(), userID)
+	if err != nil {
+		apierr.Write(w, r, apierr.Internal("failed to subscribe to changes", err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", CTEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers proxied responses otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case c, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(c)
+			if err != nil {
+				srv.logger.Error("failed to encode change", zap.Error(err))
+
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", c.Seq, EventChange, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-srv.closing:
+			return
+		case <-r.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
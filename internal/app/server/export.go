@@ -0,0 +1,185 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth"
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/metrics"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/router"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+)
+
+// CTOctetStream is the content type of vault archives.
+const CTOctetStream = "application/octet-stream"
+
+// archiveWriter starts the archive with the first item, so
+// failures until then are still reported with status.
+type archiveWriter struct {
+	w          http.ResponseWriter
+	passphrase string
+	aw         *backup.Writer
+}
+
+func (a *archiveWriter) started() bool {
+	return a.aw != nil
+}
+
+func (a *archiveWriter) start() error {
+	a.w.Header().Set("Content-Type", CTOctetStream)
+	a.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"ghostorange-%v%v\"",
+		time.Now().UTC().Format("2006-01-02"), backup.FileExt))
+
+	aw, err := backup.NewWriter(a.w, a.passphrase)
+	if err != nil {
+		return apierr.Internal("failed to start archive", err)
+	}
+
+	a.aw = aw
+
+	return nil
+}
+
+func (a *archiveWriter) add(dataType int, item any) error {
+	if !a.started() {
+		if err := a.start(); err != nil {
+			return err
+		}
+	}
+
+	return a.aw.Add(dataType, item)
+}
+
+func (a *archiveWriter) close() error {
+	if !a.started() {
+		if err := a.start(); err != nil {
+			return err
+		}
+	}
+
+	return a.aw.Close()
+}
+
+// Export responds with all user's items as a vault archive
+// encrypted with the passphrase of X-Export-Passphrase header.
+// Unlike lists, the archive holds full card numbers, so user's
+// login and password are required with basic authentication
+// on top of the session. Items are written as they are loaded,
+// all items of a data type at once, see exportItems.
+func (srv *Server) Export(w http.ResponseWriter, r *http.Request) {
+	passphrase := r.Header.Get(backup.HeaderPassphrase)
+	if err := backup.CheckPassphrase(passphrase); err != nil {
+		apierr.Write(w, r, apierr.BadRequest(err.Error(), nil))
+
+		return
+	}
+
+	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
+
+		return
+	}
+
+	if err := srv.checkPassword(r, userID); err != nil {
+		apierr.Write(w, r, err)
+
+		return
+	}
+
+	aw := &archiveWriter{w: w, passphrase: passphrase}
+
+	err := srv.exportItems(r, userID, aw)
+	if err == nil {
+		err = aw.close()
+	}
+
+	switch {
+	case err == nil:
+	case !aw.started():
+		apierr.Write(w, r, err)
+	default:
+		// the archive is left without its last chunk,
+		// so the client tells it's incomplete
+		router.LogError(r, err)
+	}
+}
+
+// checkPassword verifies login and password of basic authentication
+// of r belong to the user of the session.
+func (srv *Server) checkPassword(r *http.Request, userID string) error {
+	login, password, ok := r.BasicAuth()
+	if !ok {
+		return &apierr.Error{
+			Status:  http.StatusUnauthorized,
+			Code:    model.ErrCodeUnauthorized,
+			Message: "login and password are required",
+		}
+	}
+
+	id, err := auth.Login(r.Context(), login, password, srv.usrStrg)
+	metrics.ObserveAuth("export", err)
+
+	if err != nil {
+		return err
+	}
+
+	if id != userID {
+		return auth.ErrUnathorized
+	}
+
+	return nil
+}
+
+// exportItems writes all user's items to aw. Items of a data type
+// are loaded with one storage call, binary data included, as storage
+// does not page them. Cards are listed masked, so full ones are
+// loaded one by one.
+func (srv *Server) exportItems(r *http.Request, userID string, aw *archiveWriter) error {
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		data, err := srv.dataStrg.GetData(r.Context(), dataType)
+		if err != nil {
+			return apierr.Internal("failed to get data from storage", err)
+		}
+
+		switch v := data.(type) {
+		case []model.ItemCredentials:
+			for _, item := range v {
+				if err := aw.add(dataType, item); err != nil {
+					return err
+				}
+			}
+		case []model.ItemText:
+			for _, item := range v {
+				if err := aw.add(dataType, item); err != nil {
+					return err
+				}
+			}
+		case []model.ItemBinary:
+			for _, item := range v {
+				if err := aw.add(dataType, item); err != nil {
+					return err
+				}
+			}
+		case []model.ItemCard:
+			for _, item := range v {
+				card, err := srv.dataStrg.GetCardInfo(r.Context(), item.ID, userID)
+				if err != nil {
+					return err
+				}
+
+				if err := aw.add(dataType, card); err != nil {
+					return err
+				}
+			}
+		default:
+			return apierr.Internal(fmt.Sprintf("unexpected data of type %T", data), nil)
+		}
+	}
+
+	return nil
+}
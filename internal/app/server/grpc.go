@@ -0,0 +1,400 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth"
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/metrics"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/pb"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+	"github.com/usa4ev/ghostorange/internal/pkg/redact"
+)
+
+// MDAuthorization is the metadata key of gRPC calls
+// that carries "Bearer <token>" session token.
+const MDAuthorization = "authorization"
+
+// publicMethods can be called without a session.
+var publicMethods = map[string]bool{
+	pb.GhostOrange_Register_FullMethodName: true,
+	pb.GhostOrange_Login_FullMethodName:    true,
+}
+
+type (
+	// grpcService implements gRPC API on top of the same storage
+	// and sessions REST API handlers use.
+	grpcService struct {
+		pb.UnimplementedGhostOrangeServer
+
+		srv *Server
+	}
+
+	// authorisedStream is a server stream with session in its context.
+	authorisedStream struct {
+		grpc.ServerStream
+
+		ctx context.Context
+	}
+)
+
+func (s *authorisedStream) Context() context.Context {
+	return s.ctx
+}
+
+// newGRPCServer returns gRPC server that serves gRPC API of srv.
+func newGRPCServer(srv *Server) *grpc.Server {
+	gs := grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(srv.cfg.MaxBodySize())),
+		grpc.UnaryInterceptor(srv.unaryInterceptor),
+		grpc.StreamInterceptor(srv.streamInterceptor))
+
+	pb.RegisterGhostOrangeServer(gs, &grpcService{srv: srv})
+
+	return gs
+}
+
+// unaryInterceptor authorises calls, turns errors into
+// statuses and logs served calls.
+func (srv *Server) unaryInterceptor(ctx context.Context, req any,
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	var res any
+
+	ctx, err := authorise(ctx, info.FullMethod)
+	if err == nil {
+		res, err = handler(ctx, req)
+	}
+
+	srv.logCall(ctx, info.FullMethod, start, err)
+
+	if err != nil {
+		return nil, apierr.Status(err).Err()
+	}
+
+	return res, nil
+}
+
+// streamInterceptor authorises streams, turns errors into
+// statuses and logs served streams.
+func (srv *Server) streamInterceptor(impl any, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+
+	ctx, err := authorise(ss.Context(), info.FullMethod)
+	if err == nil {
+		err = handler(impl, &authorisedStream{ServerStream: ss, ctx: ctx})
+	}
+
+	srv.logCall(ctx, info.FullMethod, start, err)
+
+	if err != nil {
+		return apierr.Status(err).Err()
+	}
+
+	return nil
+}
+
+// logCall writes an entry to the server log for a served call.
+func (srv *Server) logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := codes.OK
+	if err != nil {
+		code = apierr.Status(err).Code()
+	}
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, zap.String("remote_addr", p.Addr.String()))
+	}
+
+	if err != nil {
+		fields = append(fields, zap.String("error", redact.String(err.Error())))
+	}
+
+	switch code {
+	case codes.OK, codes.Canceled:
+		srv.logger.Info("call served", fields...)
+	case codes.Internal, codes.Unknown:
+		srv.logger.Error("call served", fields...)
+	default:
+		srv.logger.Warn("call served", fields...)
+	}
+}
+
+// authorise returns ctx enriched with user ID of the session
+// the call carries token of. Public methods are let through as is.
+func authorise(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get(MDAuthorization)
+	if len(values) == 0 {
+		return ctx, &apierr.Error{
+			Status:  http.StatusUnauthorized,
+			Code:    model.ErrCodeInvalidSession,
+			Message: "no authorization metadata set",
+		}
+	}
+
+	userID, err := session.Verify(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return ctx, err
+	}
+
+	return session.CtxWithSession(ctx, userID), nil
+}
+
+func (s *grpcService) Register(ctx context.Context, in *pb.Credentials) (*pb.Session, error) {
+	userID, err := auth.RegisterUser(ctx, in.GetLogin(), in.GetPassword(), s.srv.usrStrg)
+	metrics.ObserveAuth("register", err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openSession(userID)
+}
+
+func (s *grpcService) Login(ctx context.Context, in *pb.Credentials) (*pb.Session, error) {
+	userID, err := auth.Login(ctx, in.GetLogin(), in.GetPassword(), s.srv.usrStrg)
+	metrics.ObserveAuth("login", err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openSession(userID)
+}
+
+func (s *grpcService) openSession(userID string) (*pb.Session, error) {
+	token, expiresAt, err := session.Open(userID, s.srv.cfg.SessionLifetime())
+	if err != nil {
+		return nil, apierr.Internal("failed to open new session", err)
+	}
+
+	return &pb.Session{Token: token, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *grpcService) GetUsage(ctx context.Context, _ *emptypb.Empty) (*pb.Usage, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.srv.dataStrg.Usage(ctx, userID)
+	if err != nil {
+		return nil, apierr.Internal("failed to get usage from storage", err)
+	}
+
+	return pb.NewUsage(usage), nil
+}
+
+func (s *grpcService) Count(ctx context.Context, in *pb.CountRequest) (*pb.CountResponse, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataType, err := dataTypeOf(in.GetDataType())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.srv.dataStrg.Count(ctx, dataType, userID)
+	if err != nil {
+		return nil, apierr.Internal("failed to get data from storage", err)
+	}
+
+	return &pb.CountResponse{Count: int64(res)}, nil
+}
+
+func (s *grpcService) GetData(ctx context.Context, in *pb.GetDataRequest) (*pb.Items, error) {
+	dataType, err := dataTypeOf(in.GetDataType())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.srv.dataStrg.GetData(ctx, dataType)
+	if err != nil {
+		return nil, apierr.Internal("failed to get data from storage", err)
+	}
+
+	res, err := pb.NewItems(data)
+	if err != nil {
+		return nil, apierr.Internal("failed to encode data", err)
+	}
+
+	return res, nil
+}
+
+// AddData adds new item to storage. Items with existing ID
+// are replaced the same way REST API does.
+func (s *grpcService) AddData(ctx context.Context, in *pb.Item) (*emptypb.Empty, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataType, item, err := in.Model()
+	if err != nil {
+		return nil, apierr.BadRequest("failed to decode item", err)
+	}
+
+	if err := s.srv.dataStrg.AddData(ctx, dataType, userID, item); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *grpcService) UpdateData(ctx context.Context, in *pb.Item) (*emptypb.Empty, error) {
+	return s.AddData(ctx, in)
+}
+
+func (s *grpcService) DeleteData(ctx context.Context, in *pb.DeleteDataRequest) (*emptypb.Empty, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataType, err := dataTypeOf(in.GetDataType())
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetId() == "" {
+		return nil, apierr.BadRequest("item id is missing", nil)
+	}
+
+	if err := s.srv.dataStrg.DeleteData(ctx, dataType, userID, in.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *grpcService) RevealCard(ctx context.Context, in *pb.RevealCardRequest) (*pb.Card, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetId() == "" {
+		return nil, apierr.BadRequest("item id is missing", nil)
+	}
+
+	card, err := s.srv.revealCard(ctx, userID, in.GetId(), in.GetCvv())
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.NewCard(card), nil
+}
+
+func (s *grpcService) Sync(ctx context.Context, in *pb.SyncRequest) (*pb.Changes, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(in.GetLimit())
+	if limit == 0 {
+		limit = syncPageSize
+	}
+
+	changes, err := s.srv.changes(ctx, userID, in.GetSince(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := pb.NewChanges(changes)
+	if err != nil {
+		return nil, apierr.Internal("failed to encode data", err)
+	}
+
+	return res, nil
+}
+
+// Events streams changes of user's items the same way
+// REST API streams server-sent events.
+func (s *grpcService) Events(_ *emptypb.Empty, stream pb.GhostOrange_EventsServer) error {
+	ctx := stream.Context()
+
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	events, err := s.srv.dataStrg.Subscribe(ctx, userID)
+	if err != nil {
+		return apierr.Internal("failed to subscribe to changes", err)
+	}
+
+	// headers tell the client the stream is established
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return nil
+	}
+
+	for {
+		select {
+		case c, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			msg, err := pb.NewChange(c)
+			if err != nil {
+				return apierr.Internal("failed to encode change", err)
+			}
+
+			if err := stream.Send(msg); err != nil {
+				return nil
+			}
+		case <-s.srv.closing:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func userIDFromCtx(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(session.CtxKeyUserID).(string)
+	if !ok {
+		return "", apierr.Internal("context is missing user ID", nil)
+	}
+
+	return userID, nil
+}
+
+// dataTypeOf returns model data type key of dataType
+// or an error if dataType is unknown.
+func dataTypeOf(dataType pb.DataType) (int, error) {
+	if dataType < 0 || int(dataType) >= model.KeyLimit {
+		return 0, apierr.BadRequest("bad data_type "+strconv.Itoa(int(dataType)), nil)
+	}
+
+	return int(dataType), nil
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,47 +9,52 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 
 	"github.com/usa4ev/ghostorange/internal/app/auth"
 	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/metrics"
 	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
 	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
 )
 
 const (
 	CTJSON  = "application/json"
 	CTPlain = "plain/text"
+
+	// syncPageSize is the default number of changes per sync response
+	syncPageSize = 500
+	// syncMaxPageSize is the maximum number of changes per sync response
+	syncMaxPageSize = 5000
 )
 
-// Register handler adds new user if one does not exist and opens a new session
+// Count responds with number of items of data_type
+// owned by the user.
 func (srv *Server) Count(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
 	if !ok {
-		http.Error(w, "request context is missing user ID", http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("request context is missing user ID", nil))
 
 		return
 	}
 
 	defer r.Body.Close()
 
-	strDataType := r.URL.Query().Get("data_type")
-	if strDataType == "" {
-		http.Error(w, "data_type parameter is required", http.StatusBadRequest)
-	}
+	dataType, err := dataTypeParam(r)
+	if err != nil {
+		apierr.Write(w, r, err)
 
-	dataType, err := strconv.Atoi(strDataType)
-	if err != nil || dataType >= model.KeyLimit {
-		http.Error(w, "bad data_type parameter", http.StatusBadRequest)
+		return
 	}
 
 	res, err := srv.dataStrg.Count(r.Context(), dataType, userID)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to get data from storage: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("failed to get data from storage", err))
+
+		return
 	}
 
 	w.Header().Set("content-type", CTJSON)
@@ -60,7 +66,7 @@ func (srv *Server) Count(w http.ResponseWriter, r *http.Request) {
 func (srv *Server) Register(w http.ResponseWriter, r *http.Request) {
 	ct := r.Header.Get("Content-Type")
 	if ct == "" || strings.Compare(ct, CTJSON) != 0 {
-		http.Error(w, fmt.Sprintf("unexpected Content-Type %v", ct), http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadRequest(fmt.Sprintf("unexpected Content-Type %v", ct), nil))
 
 		return
 	}
@@ -72,25 +78,23 @@ func (srv *Server) Register(w http.ResponseWriter, r *http.Request) {
 	dec := json.NewDecoder(r.Body)
 
 	if err := dec.Decode(&cred); err != nil {
-		http.Error(w, fmt.Sprintf("failed to decode a message: %v", err), http.StatusBadRequest)
+		apierr.Write(w, r, decodeErr(err))
 
 		return
 	}
 
 	userID, err := auth.RegisterUser(r.Context(), cred.Login, cred.Password, srv.usrStrg)
-	if errors.Is(err, auth.ErrUserAlreadyExists) {
-		http.Error(w, err.Error(), http.StatusConflict)
+	metrics.ObserveAuth("register", err)
 
-		return
-	} else if err != nil {
-		http.Error(w, fmt.Sprintf("failed to create user: %v", err), http.StatusInternalServerError)
+	if err != nil {
+		apierr.Write(w, r, err)
 
 		return
 	}
 
 	token, expiresAt, err := session.Open(userID, srv.cfg.SessionLifetime())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to open new session: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("failed to open new session", err))
 
 		return
 	}
@@ -117,7 +121,7 @@ func (srv *Server) Register(w http.ResponseWriter, r *http.Request) {
 func (srv *Server) Login(w http.ResponseWriter, r *http.Request) {
 	ct := r.Header.Get("Content-Type")
 	if ct == "" || strings.Compare(ct, CTJSON) != 0 {
-		http.Error(w, fmt.Sprintf("unexpected Content-Type %v", ct), http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadRequest(fmt.Sprintf("unexpected Content-Type %v", ct), nil))
 
 		return
 	}
@@ -129,19 +133,16 @@ func (srv *Server) Login(w http.ResponseWriter, r *http.Request) {
 	dec := json.NewDecoder(r.Body)
 
 	if err := dec.Decode(&cred); err != nil {
-		http.Error(w, fmt.Sprintf("failed to decode a message: %v", err), http.StatusBadRequest)
+		apierr.Write(w, r, decodeErr(err))
 
 		return
 	}
 
 	userID, err := auth.Login(r.Context(), cred.Login, cred.Password, srv.usrStrg)
+	metrics.ObserveAuth("login", err)
 
-	if errors.Is(err, auth.ErrUnathorized) {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-
-		return
-	} else if err != nil {
-		http.Error(w, fmt.Sprintf("authentication failed: %v", err), http.StatusInternalServerError)
+	if err != nil {
+		apierr.Write(w, r, err)
 
 		return
 	}
@@ -149,7 +150,7 @@ func (srv *Server) Login(w http.ResponseWriter, r *http.Request) {
 	token, expiresAt, err := session.Open(userID, srv.cfg.SessionLifetime())
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to open new session: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("failed to open new session", err))
 
 		return
 	}
@@ -174,36 +175,23 @@ func (srv *Server) Login(w http.ResponseWriter, r *http.Request) {
 // GetData responds with JSON encoded array of objects,
 // type depending on data_type query parameter.
 func (srv *Server) GetData(w http.ResponseWriter, r *http.Request) {
-	strDataType := r.URL.Query().Get("data_type")
-	if strDataType == "" {
-		http.Error(w, "data_type parameter is required", http.StatusBadRequest)
-
-		return
-	}
-
-	dataType, err := strconv.Atoi(strDataType)
-	if err != nil || dataType >= model.KeyLimit {
-		http.Error(w, "bad data_type parameter", http.StatusBadRequest)
+	dataType, err := dataTypeParam(r)
+	if err != nil {
+		apierr.Write(w, r, err)
 
 		return
 	}
 
 	data, err := srv.dataStrg.GetData(r.Context(), dataType)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to get data from storage: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("failed to get data from storage", err))
 
 		return
 	}
 
 	res, err := model.EncodeItemsJSON(data)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to encode data: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("failed to encode data", err))
 
 		return
 	}
@@ -214,22 +202,16 @@ func (srv *Server) GetData(w http.ResponseWriter, r *http.Request) {
 
 // AddData adds new object to storage.
 func (srv *Server) AddData(w http.ResponseWriter, r *http.Request) {
-	strDataType := r.URL.Query().Get("data_type")
-	if strDataType == "" {
-		http.Error(w, "data_type parameter is required", http.StatusBadRequest)
-
-		return
-	}
+	dataType, err := dataTypeParam(r)
+	if err != nil {
+		apierr.Write(w, r, err)
 
-	dataType, err := strconv.Atoi(strDataType)
-	if err != nil || dataType >= model.KeyLimit {
-		http.Error(w, "bad data_type parameter", http.StatusBadRequest)
 		return
 	}
 
 	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
 	if !ok {
-		http.Error(w, "context is missing user ID", http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
 
 		return
 	}
@@ -237,28 +219,21 @@ func (srv *Server) AddData(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	msg, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to read message: %v", err.Error()),
-			http.StatusBadRequest)
+		apierr.Write(w, r, decodeErr(err))
 
 		return
 	}
 
 	obj, err := model.DecodeItemJSON(dataType, msg)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to decode JSON: %v", err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.BadRequest("failed to decode JSON", err))
 
 		return
 	}
 
 	err = srv.dataStrg.AddData(r.Context(), dataType, userID, obj)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to store data: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, err)
 
 		return
 	}
@@ -266,19 +241,93 @@ func (srv *Server) AddData(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
-// GetData responds with JSON encoded model.ItemCard object
+// DeleteData deletes item with id from URL path.
+func (srv *Server) DeleteData(w http.ResponseWriter, r *http.Request) {
+	dataType, err := dataTypeParam(r)
+	if err != nil {
+		apierr.Write(w, r, err)
+
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		apierr.Write(w, r, apierr.BadRequest("item id if missing in request URL", nil))
+
+		return
+	}
+
+	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
+
+		return
+	}
+
+	if err := srv.dataStrg.DeleteData(r.Context(), dataType, userID, id); err != nil {
+		apierr.Write(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Sync responds with JSON encoded model.Changes that lists
+// items created, updated and deleted after the change
+// the since cursor points to. Empty cursor lists all items.
+func (srv *Server) Sync(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
+
+		return
+	}
+
+	limit := syncPageSize
+
+	if strLimit := r.URL.Query().Get("limit"); strLimit != "" {
+		var err error
+
+		limit, err = strconv.Atoi(strLimit)
+		if err != nil || limit <= 0 {
+			apierr.Write(w, r, badLimit())
+
+			return
+		}
+	}
+
+	res, err := srv.changes(r.Context(), userID, r.URL.Query().Get("since"), limit)
+	if err != nil {
+		apierr.Write(w, r, err)
+
+		return
+	}
+
+	msg, err := model.EncodeItemsJSON(res)
+	if err != nil {
+		apierr.Write(w, r, apierr.Internal("failed to encode data", err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", CTJSON)
+	w.Write(msg)
+}
+
+// CardData responds with JSON encoded model.ItemCard object
 // after verifying CVV code
 func (srv *Server) CardData(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		http.Error(w, "item id if missing in request URL", http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadRequest("item id if missing in request URL", nil))
 
 		return
 	}
 
 	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
 	if !ok {
-		http.Error(w, "context is missing user ID", http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
 
 		return
 	}
@@ -287,46 +336,49 @@ func (srv *Server) CardData(w http.ResponseWriter, r *http.Request) {
 
 	message, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to read request body: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, decodeErr(err))
 
 		return
 	}
 
-	cvv := string(message)
-	data, err := srv.dataStrg.GetCardInfo(r.Context(), id, userID)
+	data, err := srv.revealCard(r.Context(), userID, id, string(message))
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to get data from storage: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, err)
 
 		return
 	}
 
-	if ok, err := argon2hash.ComparePasswordAndHash(cvv, data.CVVHash); err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to validate CVV code: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+	res, err := model.EncodeItemsJSON(data)
+	if err != nil {
+		apierr.Write(w, r, apierr.Internal("failed to encode data", err))
 
 		return
-	} else if !ok {
-		http.Error(w,
-			"passed CVV code is not valid",
-			http.StatusUnauthorized)
+	}
+
+	w.Header().Set("Content-Type", CTJSON)
+	w.Write(res)
+}
+
+// Usage responds with JSON encoded model.Usage that describes
+// how much of the storage quota the user has consumed.
+func (srv *Server) Usage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(session.CtxKeyUserID).(string)
+	if !ok {
+		apierr.Write(w, r, apierr.Internal("context is missing user ID", nil))
 
 		return
 	}
 
-	res, err := model.EncodeItemsJSON(data)
+	usage, err := srv.dataStrg.Usage(r.Context(), userID)
+	if err != nil {
+		apierr.Write(w, r, apierr.Internal("failed to get usage from storage", err))
+
+		return
+	}
+
+	res, err := model.EncodeItemsJSON(usage)
 	if err != nil {
-		http.Error(w,
-			fmt.Sprintf("failed to encode data: %v",
-				err.Error()),
-			http.StatusInternalServerError)
+		apierr.Write(w, r, apierr.Internal("failed to encode data", err))
 
 		return
 	}
@@ -334,3 +386,94 @@ func (srv *Server) CardData(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", CTJSON)
 	w.Write(res)
 }
+
+// changes returns a page of up to limit user's changes made after
+// the change since cursor points to. Empty cursor lists all items.
+func (srv *Server) changes(ctx context.Context, userID, since string, limit int) (model.Changes, error) {
+	var (
+		seq int64
+		err error
+	)
+
+	if since != "" {
+		seq, err = strconv.ParseInt(since, 10, 64)
+		if err != nil || seq < 0 {
+			return model.Changes{}, apierr.BadRequest("bad since parameter", nil)
+		}
+	}
+
+	if limit <= 0 || limit > syncMaxPageSize {
+		return model.Changes{}, badLimit()
+	}
+
+	// one extra change tells whether there are more to come
+	changes, err := srv.dataStrg.Changes(ctx, userID, seq, limit+1)
+	if err != nil {
+		return model.Changes{}, apierr.Internal("failed to get changes from storage", err)
+	}
+
+	res := model.Changes{Changes: changes, Cursor: strconv.FormatInt(seq, 10)}
+
+	if len(changes) > limit {
+		res.Changes, res.HasMore = changes[:limit], true
+	}
+
+	if len(res.Changes) > 0 {
+		res.Cursor = strconv.FormatInt(res.Changes[len(res.Changes)-1].Seq, 10)
+	}
+
+	return res, nil
+}
+
+func badLimit() error {
+	return apierr.BadRequest(
+		fmt.Sprintf("limit parameter must be between 1 and %v", syncMaxPageSize), nil)
+}
+
+// revealCard returns user's card with id after verifying
+// CVV code against the stored hash.
+func (srv *Server) revealCard(ctx context.Context, userID, id, cvv string) (model.ItemCard, error) {
+	data, err := srv.dataStrg.GetCardInfo(ctx, id, userID)
+	if err != nil {
+		return data, err
+	}
+
+	start := time.Now()
+	ok, err := argon2hash.ComparePasswordAndHash(cvv, data.CVVHash)
+	metrics.ObserveHash("compare", start)
+
+	if err != nil {
+		return model.ItemCard{}, apierr.Internal("failed to validate CVV code", err)
+	} else if !ok {
+		return model.ItemCard{}, auth.ErrInvalidCVV
+	}
+
+	return data, nil
+}
+
+// dataTypeParam returns value of data_type query parameter
+// or an error if one is missing or invalid.
+func dataTypeParam(r *http.Request) (int, error) {
+	strDataType := r.URL.Query().Get("data_type")
+	if strDataType == "" {
+		return 0, apierr.BadRequest("data_type parameter is required", nil)
+	}
+
+	dataType, err := strconv.Atoi(strDataType)
+	if err != nil || dataType < 0 || dataType >= model.KeyLimit {
+		return 0, apierr.BadRequest("bad data_type parameter", nil)
+	}
+
+	return dataType, nil
+}
+
+// decodeErr returns an error for a failure occurred while reading
+// request body. Exceeding body size limit is reported as is.
+func decodeErr(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return err
+	}
+
+	return apierr.BadRequest("failed to decode a message", err)
+}
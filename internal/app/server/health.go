@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/pkg/encryption"
+)
+
+// readinessTimeout limits time spent on all readiness checks
+const readinessTimeout = 2 * time.Second
+
+type healthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Healthz responds with OK as long as the process is able to serve requests.
+func (srv *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthStatus{Status: "ok"})
+}
+
+// Readyz responds with OK if the server is able to serve data requests:
+// storage is reachable, its schema is up to date and keys are loaded.
+// Once shutdown begins it responds with http.StatusServiceUnavailable.
+func (srv *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	if srv.shuttingDown.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, healthStatus{Status: "shutting down"})
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	res := healthStatus{Status: "ok", Checks: make(map[string]string)}
+	status := http.StatusOK
+
+	checks := map[string]func(context.Context) error{
+		"storage":    srv.dataStrg.Ready,
+		"encryption": checkEncryption,
+		"session":    checkSession,
+	}
+
+	for name, check := range checks {
+		if err := check(ctx); err != nil {
+			res.Checks[name] = err.Error()
+			res.Status = "not ready"
+			status = http.StatusServiceUnavailable
+
+			continue
+		}
+
+		res.Checks[name] = "ok"
+	}
+
+	writeHealth(w, status, res)
+}
+
+func writeHealth(w http.ResponseWriter, status int, res healthStatus) {
+	w.Header().Set("Content-Type", CTJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(res)
+}
+
+// checkEncryption makes sure data can be encrypted
+// and decrypted back with the loaded key.
+func checkEncryption(ctx context.Context) error {
+	probe := []byte("ghostorange readiness probe")
+
+	encrypted, err := encryption.Encrypt(append([]byte(nil), probe...))
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := encryption.Decrypt(encrypted)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(probe, decrypted) {
+		return fmt.Errorf("decrypted probe does not match the original")
+	}
+
+	return nil
+}
+
+// checkSession makes sure session tokens can be signed
+// and verified with the loaded secret.
+func checkSession(ctx context.Context) error {
+	token, _, err := session.Open("readiness-probe", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	userID, err := session.Verify(token)
+	if err != nil {
+		return err
+	}
+
+	if userID != "readiness-probe" {
+		return fmt.Errorf("verified token does not match the original")
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	mockstorage "github.com/usa4ev/ghostorange/internal/app/storage/mock"
+)
+
+func TestReadyz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strg := mockstorage.NewMockStorage(ctrl)
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(), srvconfig.WithEnvVars(map[string]string{}))
+	srv := New(cfg, strg, zap.NewNop())
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		srv.httpsrv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		return rec.Code
+	}
+
+	strg.EXPECT().Ready(gomock.Any()).Return(nil)
+	assert.Equal(t, http.StatusOK, ready())
+
+	strg.EXPECT().Ready(gomock.Any()).Return(errors.New("database is not reachable"))
+	assert.Equal(t, http.StatusServiceUnavailable, ready())
+
+	assert.NoError(t, srv.Shutdown(context.Background()))
+	assert.Equal(t, http.StatusServiceUnavailable, ready())
+
+	rec := httptest.NewRecorder()
+	srv.httpsrv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -1,10 +1,11 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
 )
 
 // AuthMW returns middleware that enriches the request context with UserID
@@ -13,13 +14,16 @@ func AuthorisationMW(next http.Handler) http.Handler {
 		c, err := r.Cookie("Authorization")
 		if err != nil {
 			if err == http.ErrNoCookie {
-				w.WriteHeader(http.StatusUnauthorized)
-				fmt.Fprint(w, "No Authorization cookie set")
+				apierr.Write(w, r, &apierr.Error{
+					Status:  http.StatusUnauthorized,
+					Code:    model.ErrCodeInvalidSession,
+					Message: "no Authorization cookie set",
+				})
+
 				return
 			}
 
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "Authorization failure: %v", err)
+			apierr.Write(w, r, apierr.BadRequest("authorization failure", err))
 
 			return
 		}
@@ -29,8 +33,7 @@ func AuthorisationMW(next http.Handler) http.Handler {
 		userID, err := session.Verify(tokenString)
 
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			fmt.Fprintf(w, "Token verification failure: %v", err)
+			apierr.Write(w, r, err)
 			return
 		}
 
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+)
+
+// BodyLimitMW returns middleware that limits request body size.
+// Requests with bigger declared Content-Length are rejected right away,
+// otherwise reading the body beyond limit fails with *http.MaxBytesError.
+func BodyLimitMW(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				apierr.Write(w, r, &apierr.Error{
+					Status:  http.StatusRequestEntityTooLarge,
+					Code:    model.ErrCodeTooLarge,
+					Message: "request body is too large",
+					Err:     fmt.Errorf("limit is %v bytes", limit),
+				})
+
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	chimw "github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+// reRequestID matches request IDs accepted from clients and proxies
+var reRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMW returns middleware that assigns an ID to every request
+// and sends it back in X-Request-Id header. ID passed by a client
+// or a proxy in the same header is reused if it looks sane.
+// The ID is available with chimw.GetReqID.
+func RequestIDMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(chimw.RequestIDHeader)
+		if !reRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(chimw.RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), chimw.RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
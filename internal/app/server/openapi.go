@@ -0,0 +1,18 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPI is OpenAPI 3 document that describes every handler
+// of Handlers. Contract tests keep them in line.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// OpenAPI responds with OpenAPI document of REST API.
+func (srv *Server) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", CTJSON)
+	w.Write(openAPI)
+}
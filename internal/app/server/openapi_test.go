@@ -0,0 +1,328 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/server/openapitest"
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	mockstorage "github.com/usa4ev/ghostorange/internal/app/storage/mock"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+func TestOpenAPIDescribesHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(), srvconfig.WithEnvVars(map[string]string{}))
+	srv := New(cfg, mockstorage.NewMockStorage(ctrl), zap.NewNop())
+
+	doc := openapitest.New(t, openAPI).Doc()
+
+	served := make(map[string]bool)
+
+	for _, h := range srv.Handlers() {
+		served[h.Method+" "+h.Path] = true
+
+		item := doc.Paths.Find(h.Path)
+		if assert.NotNil(t, item, "%v is not documented", h.Path) {
+			assert.NotNil(t, item.GetOperation(h.Method), "%v %v is not documented", h.Method, h.Path)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			assert.True(t, served[method+" "+path], "%v %v is documented but not served", method, path)
+		}
+	}
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strg := mockstorage.NewMockStorage(ctrl)
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(),
+		srvconfig.WithEnvVars(map[string]string{"MAX_BODY_SIZE": "1024"}))
+	srv := New(cfg, strg, zap.NewNop())
+
+	ts := httptest.NewServer(srv.httpsrv.Handler)
+	defer ts.Close()
+
+	v := openapitest.New(t, openAPI)
+
+	token, _, err := session.Open("user1", time.Minute)
+	require.NoError(t, err)
+
+	cvvHash, err := argon2hash.GenerateFromPassword("123", argon2hash.DefaultParams())
+	require.NoError(t, err)
+
+	pwdHash, err := argon2hash.GenerateFromPassword("pwd", argon2hash.DefaultParams())
+	require.NoError(t, err)
+
+	basicAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pwd"))
+
+	card := model.ItemCard{ID: "card", Number: "1001", CVVHash: cvvHash, Name: "card",
+		Exp: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	ts1 := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	// /metrics is left out: storage metrics are registered globally
+	// by the first server of the test binary and outlive its mocks
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		ct     string
+		header map[string]string
+		// anonymous requests are sent without session cookie
+		anonymous bool
+		// bad requests are not expected to match the document
+		bad    bool
+		expect func()
+		want   int
+	}{
+		{name: "register", method: http.MethodPost, path: "/v1/users/register",
+			body: `{"login":"user","password":"pwd"}`, ct: CTJSON, anonymous: true,
+			expect: func() {
+				strg.EXPECT().UserExists(gomock.Any(), "user").Return(false, nil)
+				strg.EXPECT().AddUser(gomock.Any(), "user", gomock.Any()).Return("user1", nil)
+			},
+			want: http.StatusOK},
+		{name: "register existing user", method: http.MethodPost, path: "/v1/users/register",
+			body: `{"login":"user","password":"pwd"}`, ct: CTJSON, anonymous: true,
+			expect: func() {
+				strg.EXPECT().UserExists(gomock.Any(), "user").Return(true, nil)
+			},
+			want: http.StatusConflict},
+		{name: "register malformed", method: http.MethodPost, path: "/v1/users/register",
+			body: `{"login":`, ct: CTJSON, anonymous: true, bad: true,
+			want: http.StatusBadRequest},
+		{name: "login wrong password", method: http.MethodPost, path: "/v1/users/login",
+			body: `{"login":"user","password":"wrong"}`, ct: CTJSON, anonymous: true,
+			expect: func() {
+				strg.EXPECT().GetPasswordHash(gomock.Any(), "user").Return("", "", nil)
+			},
+			want: http.StatusUnauthorized},
+		{name: "usage", method: http.MethodGet, path: "/v1/users/me/usage",
+			expect: func() {
+				strg.EXPECT().Usage(gomock.Any(), "user1").Return(model.Usage{
+					Entries:    []model.UsageEntry{{DataType: model.KeyText, Count: 1, Bytes: 10}},
+					TotalBytes: 10, ItemsLimit: 100, BytesLimit: 1000}, nil)
+			},
+			want: http.StatusOK},
+		{name: "generate password", method: http.MethodGet, path: "/v1/passwords/generate?length=12&symbols=false",
+			want: http.StatusOK},
+		{name: "generate passphrase", method: http.MethodGet, path: "/v1/passwords/generate?words=5&separator=+",
+			want: http.StatusOK},
+		{name: "generate too short password", method: http.MethodGet, path: "/v1/passwords/generate?length=2",
+			want: http.StatusBadRequest},
+		{name: "get credentials", method: http.MethodGet, path: "/v1/data?data_type=0",
+			expect: func() {
+				strg.EXPECT().GetData(gomock.Any(), model.KeyCredentials).Return([]model.ItemCredentials{
+					{ID: "1", Name: "mail", Credentials: model.Credentials{Login: "login", Password: "pwd"}}}, nil)
+			},
+			want: http.StatusOK},
+		{name: "get texts", method: http.MethodGet, path: "/v1/data?data_type=1",
+			expect: func() {
+				strg.EXPECT().GetData(gomock.Any(), model.KeyText).Return([]model.ItemText{
+					{ID: "1", Name: "note", Text: "text"}}, nil)
+			},
+			want: http.StatusOK},
+		{name: "get binaries", method: http.MethodGet, path: "/v1/data?data_type=2",
+			expect: func() {
+				strg.EXPECT().GetData(gomock.Any(), model.KeyBinary).Return([]model.ItemBinary{
+					{ID: "1", Name: "file", Size: 4, Data: "ZGF0YQ=="}}, nil)
+			},
+			want: http.StatusOK},
+		{name: "get cards", method: http.MethodGet, path: "/v1/data?data_type=3",
+			expect: func() {
+				strg.EXPECT().GetData(gomock.Any(), model.KeyCards).Return([]model.ItemCard{card}, nil)
+			},
+			want: http.StatusOK},
+		{name: "get unknown data type", method: http.MethodGet, path: "/v1/data?data_type=9",
+			bad: true, want: http.StatusBadRequest},
+		{name: "get without session", method: http.MethodGet, path: "/v1/data?data_type=0",
+			anonymous: true, want: http.StatusUnauthorized},
+		{name: "add text", method: http.MethodPost, path: "/v1/data?data_type=1",
+			body: `{"id":"","text":"text","name":"note","comment":""}`, ct: CTJSON,
+			expect: func() {
+				strg.EXPECT().AddData(gomock.Any(), model.KeyText, "user1", gomock.Any()).Return(nil)
+			},
+			want: http.StatusCreated},
+		{name: "add too large", method: http.MethodPost, path: "/v1/data?data_type=1",
+			body: `{"id":"","text":"` + strings.Repeat("a", 2048) + `","name":"note","comment":""}`, ct: CTJSON,
+			want: http.StatusRequestEntityTooLarge},
+		{name: "add over quota", method: http.MethodPost, path: "/v1/data?data_type=1",
+			body: `{"id":"","text":"text","name":"note","comment":""}`, ct: CTJSON,
+			expect: func() {
+				strg.EXPECT().AddData(gomock.Any(), model.KeyText, "user1", gomock.Any()).
+					Return(strgerrors.ErrQuotaExceeded)
+			},
+			want: http.StatusInsufficientStorage},
+		{name: "update missing", method: http.MethodPut, path: "/v1/data?data_type=1",
+			body: `{"id":"missing","text":"text","name":"note","comment":""}`, ct: CTJSON,
+			expect: func() {
+				strg.EXPECT().AddData(gomock.Any(), model.KeyText, "user1", gomock.Any()).
+					Return(strgerrors.ErrNotFound)
+			},
+			want: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, path: "/v1/data/1?data_type=1",
+			expect: func() {
+				strg.EXPECT().DeleteData(gomock.Any(), model.KeyText, "user1", "1").Return(nil)
+			},
+			want: http.StatusNoContent},
+		{name: "delete missing", method: http.MethodDelete, path: "/v1/data/missing?data_type=1",
+			expect: func() {
+				strg.EXPECT().DeleteData(gomock.Any(), model.KeyText, "user1", "missing").
+					Return(strgerrors.ErrNotFound)
+			},
+			want: http.StatusNotFound},
+		{name: "batch", method: http.MethodPost, path: "/v1/data/batch",
+			body: `{"ops":[
+				{"op":"create","data_type":1,"item":{"id":"","text":"text","name":"note","comment":""}},
+				{"op":"update","data_type":0,"item":{"id":"1","credentials":{"login":"l","password":"p"},"name":"mail","comment":""}},
+				{"op":"delete","data_type":3,"id":"missing"}]}`, ct: CTJSON,
+			expect: func() {
+				strg.EXPECT().Batch(gomock.Any(), "user1", gomock.Len(3)).
+					Return([]error{nil, nil, strgerrors.ErrNotFound}, nil)
+			},
+			want: http.StatusOK},
+		{name: "batch bad operation", method: http.MethodPost, path: "/v1/data/batch",
+			body: `{"ops":[{"op":"delete","data_type":1}]}`, ct: CTJSON,
+			want: http.StatusBadRequest},
+		{name: "count", method: http.MethodGet, path: "/v1/data/count?data_type=0",
+			expect: func() {
+				strg.EXPECT().Count(gomock.Any(), model.KeyCredentials, "user1").Return(3, nil)
+			},
+			want: http.StatusOK},
+		{name: "reveal card", method: http.MethodGet, path: "/v1/data/cards/card",
+			body: "123", ct: CTPlain,
+			expect: func() {
+				strg.EXPECT().GetCardInfo(gomock.Any(), "card", "user1").Return(card, nil)
+			},
+			want: http.StatusOK},
+		{name: "reveal card wrong cvv", method: http.MethodGet, path: "/v1/data/cards/card",
+			body: "000", ct: CTPlain,
+			expect: func() {
+				strg.EXPECT().GetCardInfo(gomock.Any(), "card", "user1").Return(card, nil)
+			},
+			want: http.StatusUnauthorized},
+		{name: "reveal missing card", method: http.MethodGet, path: "/v1/data/cards/missing",
+			body: "123", ct: CTPlain,
+			expect: func() {
+				strg.EXPECT().GetCardInfo(gomock.Any(), "missing", "user1").
+					Return(model.ItemCard{}, strgerrors.ErrNotFound)
+			},
+			want: http.StatusNotFound},
+		{name: "sync", method: http.MethodGet, path: "/v1/sync?since=2&limit=2",
+			expect: func() {
+				strg.EXPECT().Changes(gomock.Any(), "user1", int64(2), 3).Return([]model.Change{
+					{Seq: 3, DataType: model.KeyText, ID: "1", Kind: model.ChangeCreated, ChangedAt: ts1,
+						Item: model.ItemText{ID: "1", Name: "note", Text: "text"}},
+					{Seq: 4, DataType: model.KeyText, ID: "1", Kind: model.ChangeDeleted, ChangedAt: ts1},
+					{Seq: 5, DataType: model.KeyCards, ID: "card", Kind: model.ChangeUpdated, ChangedAt: ts1,
+						Item: card},
+				}, nil)
+			},
+			want: http.StatusOK},
+		{name: "sync bad cursor", method: http.MethodGet, path: "/v1/sync?since=bad",
+			want: http.StatusBadRequest},
+		{name: "events", method: http.MethodGet, path: "/v1/events",
+			expect: func() {
+				changes := make(chan model.Change)
+				close(changes)
+
+				strg.EXPECT().Subscribe(gomock.Any(), "user1").Return((<-chan model.Change)(changes), nil)
+			},
+			want: http.StatusOK},
+		{name: "export", method: http.MethodGet, path: "/v1/export",
+			header: map[string]string{backup.HeaderPassphrase: "correct horse", "Authorization": basicAuth},
+			expect: func() {
+				strg.EXPECT().GetPasswordHash(gomock.Any(), "user").Return("user1", pwdHash, nil)
+				strg.EXPECT().GetData(gomock.Any(), model.KeyCredentials).Return([]model.ItemCredentials{
+					{ID: "1", Name: "mail", Credentials: model.Credentials{Login: "login", Password: "pwd"}}}, nil)
+				strg.EXPECT().GetData(gomock.Any(), model.KeyText).Return([]model.ItemText{}, nil)
+				strg.EXPECT().GetData(gomock.Any(), model.KeyBinary).Return([]model.ItemBinary{}, nil)
+				strg.EXPECT().GetData(gomock.Any(), model.KeyCards).Return([]model.ItemCard{card}, nil)
+				strg.EXPECT().GetCardInfo(gomock.Any(), "card", "user1").Return(card, nil)
+			},
+			want: http.StatusOK},
+		{name: "export without password", method: http.MethodGet, path: "/v1/export",
+			header: map[string]string{backup.HeaderPassphrase: "correct horse"}, bad: true,
+			want: http.StatusUnauthorized},
+		{name: "export password of another user", method: http.MethodGet, path: "/v1/export",
+			header: map[string]string{backup.HeaderPassphrase: "correct horse", "Authorization": basicAuth},
+			expect: func() {
+				strg.EXPECT().GetPasswordHash(gomock.Any(), "user").Return("user2", pwdHash, nil)
+			},
+			want: http.StatusUnauthorized},
+		{name: "export short passphrase", method: http.MethodGet, path: "/v1/export",
+			header: map[string]string{backup.HeaderPassphrase: "short"}, bad: true,
+			want: http.StatusBadRequest},
+		{name: "healthz", method: http.MethodGet, path: "/healthz", anonymous: true,
+			want: http.StatusOK},
+		{name: "readyz", method: http.MethodGet, path: "/readyz", anonymous: true,
+			expect: func() {
+				strg.EXPECT().Ready(gomock.Any()).Return(nil)
+			},
+			want: http.StatusOK},
+		{name: "openapi", method: http.MethodGet, path: "/v1/openapi.json", anonymous: true,
+			want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), tt.method,
+				ts.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+
+			if tt.ct != "" {
+				req.Header.Set("Content-Type", tt.ct)
+			}
+
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			if !tt.anonymous {
+				req.AddCookie(&http.Cookie{Name: "Authorization", Value: token})
+			}
+
+			if tt.expect != nil {
+				tt.expect()
+			}
+
+			if !tt.bad {
+				require.NoError(t, v.ValidateRequest(req))
+			}
+
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.want, res.StatusCode)
+			assert.NoError(t, v.ValidateResponse(req, res))
+
+			_, err = io.Copy(io.Discard, res.Body)
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package openapitest validates REST API traffic against
+// the server's OpenAPI document, so that clients, handlers and
+// the document can't diverge silently.
+package openapitest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/stretchr/testify/require"
+)
+
+const ctEventStream = "text/event-stream"
+
+func init() {
+	// card CVV is sent as plain/text, events are streamed
+	openapi3filter.RegisterBodyDecoder("plain/text", stringDecoder)
+	openapi3filter.RegisterBodyDecoder(ctEventStream, stringDecoder)
+}
+
+// Validator checks requests and responses against OpenAPI document.
+type Validator struct {
+	doc    *openapi3.T
+	router routers.Router
+}
+
+// New loads and validates OpenAPI document from data.
+func New(t testing.TB, data []byte) *Validator {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	require.NoError(t, err, "failed to load OpenAPI document")
+	require.NoError(t, doc.Validate(context.Background()), "OpenAPI document is invalid")
+
+	r, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err, "failed to route OpenAPI document")
+
+	return &Validator{doc: doc, router: r}
+}
+
+// Doc returns loaded OpenAPI document.
+func (v *Validator) Doc() *openapi3.T {
+	return v.doc
+}
+
+// ValidateRequest checks that req is documented and matches its operation.
+// The body of req is left unread.
+func (v *Validator) ValidateRequest(req *http.Request) error {
+	input, err := v.input(req)
+	if err != nil {
+		return err
+	}
+
+	body, err := peekBody(&req.Body)
+	if err != nil {
+		return err
+	}
+
+	// validator consumes the body of the request it is given
+	vreq := req.Clone(req.Context())
+	vreq.Body = io.NopCloser(bytes.NewReader(body))
+	input.Request = vreq
+
+	if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+		return fmt.Errorf("%v %v: %w", req.Method, req.URL.Path, err)
+	}
+
+	return nil
+}
+
+// ValidateResponse checks that res is a documented response to req.
+// The body of res is left unread. Streamed bodies are not validated.
+func (v *Validator) ValidateResponse(req *http.Request, res *http.Response) error {
+	input, err := v.input(req)
+	if err != nil {
+		return err
+	}
+
+	rinput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 res.StatusCode,
+		Header:                 res.Header,
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+		},
+	}
+
+	if strings.HasPrefix(res.Header.Get("Content-Type"), ctEventStream) {
+		rinput.Options.ExcludeResponseBody = true
+	} else {
+		body, err := peekBody(&res.Body)
+		if err != nil {
+			return err
+		}
+
+		rinput.SetBodyBytes(body)
+	}
+
+	if err := openapi3filter.ValidateResponse(req.Context(), rinput); err != nil {
+		return fmt.Errorf("%v %v responded %v: %w", req.Method, req.URL.Path, res.StatusCode, err)
+	}
+
+	return nil
+}
+
+// Transport returns a round tripper that fails t whenever a response
+// received through next does not match the document, or a request
+// does not match it and yet the server has not rejected it as bad.
+func (v *Validator) Transport(t testing.TB, next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		reqErr := v.ValidateRequest(req)
+
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			return res, err
+		}
+
+		if reqErr != nil && res.StatusCode != http.StatusBadRequest {
+			t.Errorf("request does not match OpenAPI document: %v", reqErr)
+		}
+
+		if err := v.ValidateResponse(req, res); err != nil {
+			t.Errorf("response does not match OpenAPI document: %v", err)
+		}
+
+		return res, nil
+	})
+}
+
+func (v *Validator) input(req *http.Request) (*openapi3filter.RequestValidationInput, error) {
+	route, params, err := v.router.FindRoute(req)
+	if err != nil {
+		return nil, fmt.Errorf("%v %v is not documented: %w", req.Method, req.URL.Path, err)
+	}
+
+	return &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}, nil
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// peekBody reads body and replaces it with an unread copy.
+func peekBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	*body = io.NopCloser(bytes.NewReader(data))
+
+	return data, nil
+}
+
+func stringDecoder(body io.Reader, _ http.Header, _ *openapi3.SchemaRef,
+	_ openapi3filter.EncodingFn) (interface{}, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/usa4ev/ghostorange/internal/app/server/apierr"
+	"github.com/usa4ev/ghostorange/internal/pkg/passgen"
+)
+
+// generatedPassword is the response of GeneratePassword.
+type generatedPassword struct {
+	Password string  `json:"password"`
+	Bits     float64 `json:"bits"`
+	Strength string  `json:"strength"`
+}
+
+// GeneratePassword responds with a random password or, if words
+// parameter is set, a passphrase, along with its estimated strength.
+// Passwords use all character classes unless some are turned off.
+func (srv *Server) GeneratePassword(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var (
+		pwd string
+		err error
+	)
+
+	if q.Get("words") != "" {
+		words, convErr := strconv.Atoi(q.Get("words"))
+		if convErr != nil {
+			apierr.Write(w, r, apierr.BadRequest("words parameter must be a number", convErr))
+
+			return
+		}
+
+		sep := "-"
+		if q.Has("separator") {
+			sep = q.Get("separator")
+		}
+
+		pwd, err = passgen.Passphrase(words, sep)
+	} else {
+		opts, optsErr := passwordOptions(q.Get)
+		if optsErr != nil {
+			apierr.Write(w, r, optsErr)
+
+			return
+		}
+
+		pwd, err = passgen.Password(opts)
+	}
+
+	if err != nil {
+		apierr.Write(w, r, apierr.BadRequest(err.Error(), err))
+
+		return
+	}
+
+	s := passgen.Estimate(pwd)
+
+	msg, err := json.Marshal(generatedPassword{Password: pwd, Bits: s.Bits, Strength: s.String()})
+	if err != nil {
+		apierr.Write(w, r, apierr.Internal("failed to encode password", err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", CTJSON)
+	w.Write(msg)
+}
+
+// passwordOptions returns generator options set by query parameters.
+func passwordOptions(get func(string) string) (passgen.Options, error) {
+	opts := passgen.DefaultOptions
+
+	if v := get("length"); v != "" {
+		length, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, apierr.BadRequest("length parameter must be a number", err)
+		}
+
+		opts.Length = length
+	}
+
+	for name, flag := range map[string]*bool{
+		"lower":             &opts.Lower,
+		"upper":             &opts.Upper,
+		"digits":            &opts.Digits,
+		"symbols":           &opts.Symbols,
+		"exclude_ambiguous": &opts.ExcludeAmbiguous,
+	} {
+		v := get(name)
+		if v == "" {
+			continue
+		}
+
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, apierr.BadRequest(name+" parameter must be true or false", err)
+		}
+
+		*flag = on
+	}
+
+	return opts, nil
+}
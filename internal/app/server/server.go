@@ -2,13 +2,20 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi"
 	chimw "github.com/go-chi/chi/middleware"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"github.com/usa4ev/ghostorange/internal/app/auth"
+	"github.com/usa4ev/ghostorange/internal/app/metrics"
 	"github.com/usa4ev/ghostorange/internal/app/router"
 	"github.com/usa4ev/ghostorange/internal/app/server/middleware"
 	"github.com/usa4ev/ghostorange/internal/app/storage"
@@ -17,42 +24,122 @@ import (
 type (
 	Server struct {
 		httpsrv  *http.Server
+		adminsrv *http.Server
+		grpcsrv  *grpc.Server
 		cfg      config
 		usrStrg  auth.UsrStorage
 		dataStrg storage.Storage
+		logger   *zap.Logger
+
+		// shuttingDown fails readiness checks once shutdown begins
+		shuttingDown atomic.Bool
+		// closing is closed when connections are about to be closed,
+		// so streams end and do not hold shutdown
+		closing chan struct{}
 	}
 
 	config interface {
 		SrvAddr() string
 		DBDSN() string
 		SessionLifetime() time.Duration
+		MaxBodySize() int64
+		MetricsAddr() string
+		GRPCAddr() string
+		DrainDelay() time.Duration
 	}
 )
 
-func New(c config, s storage.Storage) *Server {
+func New(c config, s storage.Storage, logger *zap.Logger) *Server {
 	srv := Server{cfg: c,
 		usrStrg:  s,
-		dataStrg: s}
-	r := router.NewRouter(&srv)
-	srv.httpsrv = &http.Server{Addr: c.SrvAddr(), Handler: r}
+		dataStrg: s,
+		logger:   logger,
+		closing:  make(chan struct{})}
+
+	// admin server is set before the router is built,
+	// so that metrics are not served on the public address
+	if c.MetricsAddr() != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+
+		srv.adminsrv = &http.Server{Addr: c.MetricsAddr(),
+			Handler:  mux,
+			ErrorLog: zap.NewStdLog(logger)}
+	}
+
+	r := router.NewRouter(&srv,
+		middleware.RequestIDMW,
+		router.AccessLogMW(logger),
+		metrics.InstrumentMW)
+	srv.httpsrv = &http.Server{Addr: c.SrvAddr(),
+		Handler:  r,
+		ErrorLog: zap.NewStdLog(logger)}
+	srv.httpsrv.RegisterOnShutdown(func() { close(srv.closing) })
+
+	if c.GRPCAddr() != "" {
+		srv.grpcsrv = newGRPCServer(&srv)
+	}
+
+	if err := metrics.RegisterStorage(s); err != nil {
+		logger.Warn("failed to register storage metrics", zap.Error(err))
+	}
 
 	return &srv
 }
 
 func (srv *Server) Handlers() []router.HandlerDesc {
-	return []router.HandlerDesc{
+	bodyLimit := middleware.BodyLimitMW(srv.cfg.MaxBodySize())
+
+	handlers := []router.HandlerDesc{
+		// GET: /healthz
+		{Method: "GET",
+			Path:    "/healthz",
+			Handler: http.HandlerFunc(srv.Healthz),
+		},
+
+		// GET: /readyz
+		{Method: "GET",
+			Path:    "/readyz",
+			Handler: http.HandlerFunc(srv.Readyz),
+		},
+
+		// GET: /v1/openapi.json
+		{Method: "GET",
+			Path:    "/v1/openapi.json",
+			Handler: http.HandlerFunc(srv.OpenAPI),
+			Middlewares: chi.Middlewares{
+				chimw.Compress(5, CTJSON)},
+		},
+
 		// POST: /users/register
 		{Method: "POST",
 			Path:        "/v1/users/register",
 			Handler:     http.HandlerFunc(srv.Register),
-			Middlewares: nil,
+			Middlewares: chi.Middlewares{bodyLimit},
 		},
 
 		// POST: /users/login
 		{Method: "POST",
 			Path:        "/v1/users/login",
 			Handler:     http.HandlerFunc(srv.Login),
-			Middlewares: nil,
+			Middlewares: chi.Middlewares{bodyLimit},
+		},
+
+		// GET: /users/me/usage
+		{Method: "GET",
+			Path:    "/v1/users/me/usage",
+			Handler: http.HandlerFunc(srv.Usage),
+			Middlewares: chi.Middlewares{
+				chimw.Compress(5, CTJSON),
+				middleware.AuthorisationMW},
+		},
+
+		// GET: /passwords/generate
+		{Method: "GET",
+			Path:    "/v1/passwords/generate",
+			Handler: http.HandlerFunc(srv.GeneratePassword),
+			Middlewares: chi.Middlewares{
+				middleware.AuthorisationMW},
 		},
 
 		// GET: /data?data_type={data_type}
@@ -69,6 +156,7 @@ func (srv *Server) Handlers() []router.HandlerDesc {
 			Path:    "/v1/data",
 			Handler: http.HandlerFunc(srv.AddData),
 			Middlewares: chi.Middlewares{
+				bodyLimit,
 				chimw.Compress(5, CTJSON),
 				middleware.AuthorisationMW},
 		},
@@ -78,10 +166,57 @@ func (srv *Server) Handlers() []router.HandlerDesc {
 			Path:    "/v1/data",
 			Handler: http.HandlerFunc(srv.AddData),
 			Middlewares: chi.Middlewares{
+				bodyLimit,
 				chimw.Compress(5, CTJSON),
 				middleware.AuthorisationMW},
 		},
 
+		// POST: /data/batch
+		{Method: "POST",
+			Path:    "/v1/data/batch",
+			Handler: http.HandlerFunc(srv.Batch),
+			Middlewares: chi.Middlewares{
+				bodyLimit,
+				chimw.Compress(5, CTJSON),
+				middleware.AuthorisationMW},
+		},
+
+		// DELETE: /data/{id}?data_type={data_type}
+		{Method: "DELETE",
+			Path:    "/v1/data/{id}",
+			Handler: http.HandlerFunc(srv.DeleteData),
+			Middlewares: chi.Middlewares{
+				chimw.Compress(5, CTJSON),
+				middleware.AuthorisationMW},
+		},
+
+		// GET: /sync?since={cursor}&limit={limit}
+		{Method: "GET",
+			Path:    "/v1/sync",
+			Handler: http.HandlerFunc(srv.Sync),
+			Middlewares: chi.Middlewares{
+				chimw.Compress(5, CTJSON),
+				middleware.AuthorisationMW},
+		},
+
+		// GET: /events
+		// Not compressed, compressor buffers the stream.
+		{Method: "GET",
+			Path:    "/v1/events",
+			Handler: http.HandlerFunc(srv.Events),
+			Middlewares: chi.Middlewares{
+				middleware.AuthorisationMW},
+		},
+
+		// GET: /export
+		// Not compressed, the archive is encrypted.
+		{Method: "GET",
+			Path:    "/v1/export",
+			Handler: http.HandlerFunc(srv.Export),
+			Middlewares: chi.Middlewares{
+				middleware.AuthorisationMW},
+		},
+
 		// GET: /data/count?data_type={data_type}
 		{Method: "GET",
 			Path:    "/v1/data/count",
@@ -96,12 +231,26 @@ func (srv *Server) Handlers() []router.HandlerDesc {
 			Path:    "/v1/data/cards/{id}",
 			Handler: http.HandlerFunc(srv.CardData),
 			Middlewares: chi.Middlewares{
+				bodyLimit,
 				chimw.Compress(5, CTJSON),
 				middleware.AuthorisationMW},
 		},
 	}
+
+	if srv.adminsrv == nil {
+		// GET: /metrics
+		handlers = append(handlers, router.HandlerDesc{
+			Method:  "GET",
+			Path:    "/metrics",
+			Handler: metrics.Handler(),
+		})
+	}
+
+	return handlers
 }
 
+// Run listens on configured address and serves requests until
+// Shutdown is called. After Shutdown it returns http.ErrServerClosed.
 func (srv *Server) Run() error {
 	// Run the server
 	// if srv.cfg.UseTLS() {
@@ -109,11 +258,110 @@ func (srv *Server) Run() error {
 	// 		filepath.Join(srv.cfg.SslPath(), "example.crt"),
 	// 		filepath.Join(srv.cfg.SslPath(), "example.key"))
 	// } else {
-	return srv.httpsrv.ListenAndServe()
+	l, err := net.Listen("tcp", srv.cfg.SrvAddr())
+	if err != nil {
+		return err
+	}
+
+	return srv.Serve(l)
 	// }
 }
 
+// Serve serves requests accepted on l until Shutdown is called.
+// Admin and gRPC servers, if configured, are started as well.
+func (srv *Server) Serve(l net.Listener) error {
+	if srv.adminsrv != nil {
+		go func() {
+			srv.logger.Info("starting admin server", zap.String("address", srv.adminsrv.Addr))
+
+			err := srv.adminsrv.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				srv.logger.Error("admin server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	if srv.grpcsrv != nil {
+		go func() {
+			srv.logger.Info("starting gRPC server", zap.String("address", srv.cfg.GRPCAddr()))
+
+			err := srv.serveGRPC()
+			if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				srv.logger.Error("gRPC server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	srv.logger.Info("starting server", zap.String("address", l.Addr().String()))
+
+	return srv.httpsrv.Serve(l)
+}
+
+// Shutdown fails readiness checks and waits for configured drain delay,
+// so load balancers stop sending new requests. Then it stops accepting
+// connections and waits for in-flight requests and calls to complete
+// until ctx is done. Admin server is shut down last so metrics
+// can be scraped meanwhile.
 func (srv *Server) Shutdown(ctx context.Context) error {
-	srv.httpsrv.Shutdown(ctx)
-	return nil
+	srv.shuttingDown.Store(true)
+
+	if d := srv.cfg.DrainDelay(); d > 0 {
+		srv.logger.Info("draining traffic", zap.Duration("delay", d))
+
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+	}
+
+	srv.logger.Info("shutting down server")
+
+	err := srv.httpsrv.Shutdown(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	if srv.grpcsrv != nil {
+		if grpcErr := srv.stopGRPC(ctx); grpcErr != nil && err == nil {
+			err = grpcErr
+		}
+	}
+
+	if srv.adminsrv != nil {
+		if adminErr := srv.adminsrv.Shutdown(ctx); adminErr != nil && err == nil {
+			err = fmt.Errorf("failed to shut down admin server: %w", adminErr)
+		}
+	}
+
+	return err
+}
+
+func (srv *Server) serveGRPC() error {
+	l, err := net.Listen("tcp", srv.cfg.GRPCAddr())
+	if err != nil {
+		return err
+	}
+
+	return srv.grpcsrv.Serve(l)
+}
+
+// stopGRPC waits for in-flight calls to complete until ctx is done,
+// then closes remaining connections. Event streams end as soon as
+// closing is closed, so they do not hold it.
+func (srv *Server) stopGRPC(ctx context.Context) error {
+	stopped := make(chan struct{})
+
+	go func() {
+		srv.grpcsrv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		srv.grpcsrv.Stop()
+
+		return fmt.Errorf("failed to shut down gRPC server: %w", ctx.Err())
+	}
 }
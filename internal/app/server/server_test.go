@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	mockstorage "github.com/usa4ev/ghostorange/internal/app/storage/mock"
+)
+
+func TestShutdownCompletesInFlightRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strg := mockstorage.NewMockStorage(ctrl)
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(), srvconfig.WithEnvVars(map[string]string{}))
+	srv := New(cfg, strg, zap.NewNop())
+
+	// signal when the upload is accepted and being handled
+	active := make(chan struct{})
+	srv.httpsrv.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateActive {
+			close(active)
+		}
+	}
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+
+	token, _, err := session.Open("user1", time.Minute)
+	require.NoError(t, err)
+
+	want := model.ItemText{ID: "1", Name: "note", Text: "in-flight upload"}
+	strg.EXPECT().AddData(gomock.Any(), model.KeyText, "user1", want).Return(nil)
+
+	body, err := model.EncodeItemsJSON(want)
+	require.NoError(t, err)
+
+	pr, pw := io.Pipe()
+
+	req, err := http.NewRequest(http.MethodPost,
+		"http://"+l.Addr().String()+"/v1/data?data_type=1", pr)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: "Authorization", Value: token})
+	req.ContentLength = int64(len(body))
+
+	type result struct {
+		status int
+		err    error
+	}
+
+	resCh := make(chan result, 1)
+	go func() {
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			resCh <- result{err: err}
+
+			return
+		}
+		res.Body.Close()
+		resCh <- result{status: res.StatusCode}
+	}()
+
+	// send a half of the body so the request is being handled
+	half := len(body) / 2
+	_, err = pw.Write(body[:half])
+	require.NoError(t, err)
+	<-active
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(context.Background())
+	}()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned before in-flight request completed: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, err = pw.Write(body[half:])
+	require.NoError(t, err)
+	require.NoError(t, pw.Close())
+
+	res := <-resCh
+	require.NoError(t, res.err)
+	assert.Equal(t, http.StatusCreated, res.status)
+
+	assert.NoError(t, <-shutdown)
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strg := mockstorage.NewMockStorage(ctrl)
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(), srvconfig.WithEnvVars(map[string]string{}))
+	srv := New(cfg, strg, zap.NewNop())
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+
+	token, _, err := session.Open("user1", time.Minute)
+	require.NoError(t, err)
+
+	changes := make(chan model.Change, 1)
+	strg.EXPECT().Subscribe(gomock.Any(), "user1").Return((<-chan model.Change)(changes), nil)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+l.Addr().String()+"/v1/events", nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: "Authorization", Value: token})
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, CTEventStream, res.Header.Get("Content-Type"))
+
+	changes <- model.Change{Seq: 1, DataType: model.KeyText, ID: "1", Kind: model.ChangeCreated}
+
+	buf := make([]byte, 1024)
+	n, err := res.Body.Read(buf)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf[:n]), "event: change\ndata: {\"seq\":1,")
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(context.Background())
+	}()
+
+	select {
+	case err := <-shutdown:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown waits for the event stream")
+	}
+
+	_, err = io.ReadAll(res.Body)
+	assert.NoError(t, err, "stream must end gracefully")
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+}
+
+func TestMetricsAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strg := mockstorage.NewMockStorage(ctrl)
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(), srvconfig.WithEnvVars(map[string]string{
+		"METRICS_ADDRESS": "localhost:9090",
+	}))
+	srv := New(cfg, strg, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+
+	rec := httptest.NewRecorder()
+	srv.httpsrv.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "metrics are served on admin address only")
+
+	require.NotNil(t, srv.adminsrv)
+	mux, ok := srv.adminsrv.Handler.(*http.ServeMux)
+	require.True(t, ok)
+
+	_, pattern := mux.Handler(req)
+	assert.Equal(t, "/metrics", pattern)
+}
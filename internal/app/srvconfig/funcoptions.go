@@ -21,6 +21,19 @@ func setConfigOptions(opts []configOption) *configOptions {
 			"SERVER_ADDRESS":    os.Getenv("SERVER_ADDRESS"),
 			"DATABASE_DSN":      os.Getenv("DATABASE_DSN"),
 			"SESSION_LIFETIME":  os.Getenv("SESSION_LIFETIME"),
+			"MAX_BODY_SIZE":     os.Getenv("MAX_BODY_SIZE"),
+			"QUOTA_ITEMS":       os.Getenv("QUOTA_ITEMS"),
+			"QUOTA_BYTES":       os.Getenv("QUOTA_BYTES"),
+			"LOG_LEVEL":         os.Getenv("LOG_LEVEL"),
+			"LOG_FORMAT":        os.Getenv("LOG_FORMAT"),
+			"LOG_OUTPUT":        os.Getenv("LOG_OUTPUT"),
+			"METRICS_ADDRESS":   os.Getenv("METRICS_ADDRESS"),
+			"DRAIN_DELAY":       os.Getenv("DRAIN_DELAY"),
+			"SHUTDOWN_TIMEOUT":  os.Getenv("SHUTDOWN_TIMEOUT"),
+			"DB_MAX_OPEN_CONNS":    os.Getenv("DB_MAX_OPEN_CONNS"),
+			"DB_MAX_IDLE_CONNS":    os.Getenv("DB_MAX_IDLE_CONNS"),
+			"DB_CONN_MAX_LIFETIME": os.Getenv("DB_CONN_MAX_LIFETIME"),
+			"DB_QUERY_TIMEOUT":     os.Getenv("DB_QUERY_TIMEOUT"),
 			"CONFIG":            os.Getenv("CONFIG"),
 		},
 	}
@@ -35,7 +48,7 @@ func setConfigOptions(opts []configOption) *configOptions {
 	return configOptions
 }
 
-func withOsArgs(osArgs []string) configOption {
+func WithOsArgs(osArgs []string) configOption {
 	return func(o *configOptions) {
 		o.osArgs = osArgs
 	}
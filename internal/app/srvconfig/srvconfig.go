@@ -5,6 +5,8 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,10 +17,40 @@ const (
 	topPriority
 )
 
+const defaultMaxBodySize = 32 << 20
+
+// Storage drivers selected by database DSN.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+
+	// SchemeSQLite prefixes DSN of SQLite database,
+	// e.g. sqlite://ghostorange.db or sqlite://:memory:
+	SchemeSQLite = "sqlite://"
+	// SchemeMemory selects in-memory storage, DSN is just memory://
+	SchemeMemory = "memory://"
+)
+
 type Config struct {
 	srvAddr         string
 	dbDSN           string
 	sessionLifeTime time.Duration
+	maxBodySize     int64
+	quotaItems      int
+	quotaBytes      int64
+	logLevel        string
+	logFormat       string
+	logOutput       string
+	metricsAddr     string
+	grpcAddr        string
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+
+	dbMaxOpenConns    int
+	dbMaxIdleConns    int
+	dbConnMaxLifetime time.Duration
+	dbQueryTimeout    time.Duration
 }
 
 func New(opts ...configOption) *Config {
@@ -58,6 +90,48 @@ func fillCfg(configs []*Config) *Config {
 		if pCfg.sessionLifeTime != time.Duration(0) {
 			cfg.sessionLifeTime = pCfg.sessionLifeTime
 		}
+		if pCfg.maxBodySize != 0 {
+			cfg.maxBodySize = pCfg.maxBodySize
+		}
+		if pCfg.quotaItems != 0 {
+			cfg.quotaItems = pCfg.quotaItems
+		}
+		if pCfg.quotaBytes != 0 {
+			cfg.quotaBytes = pCfg.quotaBytes
+		}
+		if pCfg.logLevel != "" {
+			cfg.logLevel = pCfg.logLevel
+		}
+		if pCfg.logFormat != "" {
+			cfg.logFormat = pCfg.logFormat
+		}
+		if pCfg.logOutput != "" {
+			cfg.logOutput = pCfg.logOutput
+		}
+		if pCfg.metricsAddr != "" {
+			cfg.metricsAddr = pCfg.metricsAddr
+		}
+		if pCfg.grpcAddr != "" {
+			cfg.grpcAddr = pCfg.grpcAddr
+		}
+		if pCfg.drainDelay != time.Duration(0) {
+			cfg.drainDelay = pCfg.drainDelay
+		}
+		if pCfg.shutdownTimeout != time.Duration(0) {
+			cfg.shutdownTimeout = pCfg.shutdownTimeout
+		}
+		if pCfg.dbMaxOpenConns != 0 {
+			cfg.dbMaxOpenConns = pCfg.dbMaxOpenConns
+		}
+		if pCfg.dbMaxIdleConns != 0 {
+			cfg.dbMaxIdleConns = pCfg.dbMaxIdleConns
+		}
+		if pCfg.dbConnMaxLifetime != time.Duration(0) {
+			cfg.dbConnMaxLifetime = pCfg.dbConnMaxLifetime
+		}
+		if pCfg.dbQueryTimeout != time.Duration(0) {
+			cfg.dbQueryTimeout = pCfg.dbQueryTimeout
+		}
 	}
 
 	return cfg.setDefaults()
@@ -71,10 +145,106 @@ func (c Config) DBDSN() string {
 	return c.dbDSN
 }
 
+// DBDriver returns storage driver selected by DSN scheme:
+// DriverSQLite for sqlite:// DSNs, DriverMemory for memory://
+// and DriverPostgres otherwise.
+func (c Config) DBDriver() string {
+	if strings.HasPrefix(c.dbDSN, SchemeSQLite) {
+		return DriverSQLite
+	}
+
+	if strings.HasPrefix(c.dbDSN, SchemeMemory) {
+		return DriverMemory
+	}
+
+	return DriverPostgres
+}
+
 func (c Config) SessionLifetime() time.Duration {
 	return c.sessionLifeTime
 }
 
+// MaxBodySize returns the maximum size of a request body in bytes.
+func (c Config) MaxBodySize() int64 {
+	return c.maxBodySize
+}
+
+// QuotaItems returns the maximum number of items of each data type
+// a single user may store. Zero means no limit.
+func (c Config) QuotaItems() int {
+	return c.quotaItems
+}
+
+// QuotaBytes returns the maximum total size of data in bytes
+// a single user may store. Zero means no limit.
+func (c Config) QuotaBytes() int64 {
+	return c.quotaBytes
+}
+
+// LogLevel returns minimal level of server log entries:
+// debug, info, warn or error.
+func (c Config) LogLevel() string {
+	return c.logLevel
+}
+
+// LogFormat returns server log encoding: json or console.
+func (c Config) LogFormat() string {
+	return c.logFormat
+}
+
+// LogOutput returns path to write server log to,
+// stdout and stderr are accepted as well.
+func (c Config) LogOutput() string {
+	return c.logOutput
+}
+
+// MetricsAddr returns address of admin listener that serves metrics.
+// Empty address means metrics are served by the main listener.
+func (c Config) MetricsAddr() string {
+	return c.metricsAddr
+}
+
+// GRPCAddr returns address to serve gRPC API on.
+// Empty address means gRPC API is not served.
+func (c Config) GRPCAddr() string {
+	return c.grpcAddr
+}
+
+// DrainDelay returns time to wait on shutdown after readiness
+// starts failing, so load balancers stop sending new requests.
+func (c Config) DrainDelay() time.Duration {
+	return c.drainDelay
+}
+
+// ShutdownTimeout returns time given to in-flight requests
+// to complete on shutdown.
+func (c Config) ShutdownTimeout() time.Duration {
+	return c.shutdownTimeout
+}
+
+// DBMaxOpenConns returns the maximum number of open database connections.
+func (c Config) DBMaxOpenConns() int {
+	return c.dbMaxOpenConns
+}
+
+// DBMaxIdleConns returns the maximum number of idle database connections
+// kept in the pool.
+func (c Config) DBMaxIdleConns() int {
+	return c.dbMaxIdleConns
+}
+
+// DBConnMaxLifetime returns the maximum amount of time
+// a database connection may be reused.
+func (c Config) DBConnMaxLifetime() time.Duration {
+	return c.dbConnMaxLifetime
+}
+
+// DBQueryTimeout returns time given to a single database
+// query or transaction to complete.
+func (c Config) DBQueryTimeout() time.Duration {
+	return c.dbQueryTimeout
+}
+
 func (c *Config) setDefaults() *Config {
 	if c.srvAddr == "" {
 		c.srvAddr = "localhost:8080"
@@ -84,6 +254,42 @@ func (c *Config) setDefaults() *Config {
 		c.sessionLifeTime = time.Minute * 30
 	}
 
+	if c.maxBodySize == 0 {
+		c.maxBodySize = defaultMaxBodySize
+	}
+
+	if c.shutdownTimeout == time.Duration(0) {
+		c.shutdownTimeout = time.Second * 30
+	}
+
+	if c.dbMaxOpenConns == 0 {
+		c.dbMaxOpenConns = 20
+	}
+
+	if c.dbMaxIdleConns == 0 {
+		c.dbMaxIdleConns = 10
+	}
+
+	if c.dbConnMaxLifetime == time.Duration(0) {
+		c.dbConnMaxLifetime = time.Minute * 30
+	}
+
+	if c.dbQueryTimeout == time.Duration(0) {
+		c.dbQueryTimeout = time.Second * 10
+	}
+
+	if c.logLevel == "" {
+		c.logLevel = "info"
+	}
+
+	if c.logFormat == "" {
+		c.logFormat = "json"
+	}
+
+	if c.logOutput == "" {
+		c.logOutput = "stderr"
+	}
+
 	return c
 }
 
@@ -99,6 +305,48 @@ func fromEnv(envVars map[string]string) *Config {
 	if v := envVars["SESSION_LIFETIME"]; v != "" {
 		pc.sessionLifeTime,_ = time.ParseDuration(v)
 	}
+	if v := envVars["MAX_BODY_SIZE"]; v != "" {
+		pc.maxBodySize, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v := envVars["QUOTA_ITEMS"]; v != "" {
+		pc.quotaItems, _ = strconv.Atoi(v)
+	}
+	if v := envVars["QUOTA_BYTES"]; v != "" {
+		pc.quotaBytes, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v := envVars["LOG_LEVEL"]; v != "" {
+		pc.logLevel = v
+	}
+	if v := envVars["LOG_FORMAT"]; v != "" {
+		pc.logFormat = v
+	}
+	if v := envVars["LOG_OUTPUT"]; v != "" {
+		pc.logOutput = v
+	}
+	if v := envVars["METRICS_ADDRESS"]; v != "" {
+		pc.metricsAddr = v
+	}
+	if v := envVars["GRPC_ADDRESS"]; v != "" {
+		pc.grpcAddr = v
+	}
+	if v := envVars["DRAIN_DELAY"]; v != "" {
+		pc.drainDelay, _ = time.ParseDuration(v)
+	}
+	if v := envVars["SHUTDOWN_TIMEOUT"]; v != "" {
+		pc.shutdownTimeout, _ = time.ParseDuration(v)
+	}
+	if v := envVars["DB_MAX_OPEN_CONNS"]; v != "" {
+		pc.dbMaxOpenConns, _ = strconv.Atoi(v)
+	}
+	if v := envVars["DB_MAX_IDLE_CONNS"]; v != "" {
+		pc.dbMaxIdleConns, _ = strconv.Atoi(v)
+	}
+	if v := envVars["DB_CONN_MAX_LIFETIME"]; v != "" {
+		pc.dbConnMaxLifetime, _ = time.ParseDuration(v)
+	}
+	if v := envVars["DB_QUERY_TIMEOUT"]; v != "" {
+		pc.dbQueryTimeout, _ = time.ParseDuration(v)
+	}
 
 	return &pc
 }
@@ -110,6 +358,20 @@ func fromArgs(osArgs []string, filePath *string) *Config {
 		fs.StringVar(&pc.srvAddr, "a", "", "the service address")
 		fs.StringVar(&pc.dbDSN, "d", "", "db connection path")
 		fs.DurationVar(&pc.sessionLifeTime, "s", time.Duration(0), "session lifetime")
+		fs.Int64Var(&pc.maxBodySize, "b", 0, "max request body size in bytes")
+		fs.IntVar(&pc.quotaItems, "qi", 0, "max number of items of each data type per user")
+		fs.Int64Var(&pc.quotaBytes, "qb", 0, "max total size of stored data in bytes per user")
+		fs.StringVar(&pc.logLevel, "ll", "", "log level: debug, info, warn or error")
+		fs.StringVar(&pc.logFormat, "lf", "", "log format: json or console")
+		fs.StringVar(&pc.logOutput, "lo", "", "path to write log, stdout or stderr")
+		fs.StringVar(&pc.metricsAddr, "ma", "", "admin address to serve metrics on")
+		fs.StringVar(&pc.grpcAddr, "ga", "", "address to serve gRPC API on")
+		fs.DurationVar(&pc.drainDelay, "dd", time.Duration(0), "delay before shutdown to drain traffic")
+		fs.DurationVar(&pc.shutdownTimeout, "st", time.Duration(0), "time given to in-flight requests on shutdown")
+		fs.IntVar(&pc.dbMaxOpenConns, "dmo", 0, "max number of open db connections")
+		fs.IntVar(&pc.dbMaxIdleConns, "dmi", 0, "max number of idle db connections")
+		fs.DurationVar(&pc.dbConnMaxLifetime, "dml", time.Duration(0), "max lifetime of a db connection")
+		fs.DurationVar(&pc.dbQueryTimeout, "dqt", time.Duration(0), "db query timeout")
 		fs.StringVar(filePath, "c", *filePath, "path to JSON config file")
 		fs.Parse(osArgs)
 	}
@@ -129,6 +391,20 @@ func fromFile(filePath string) *Config {
 	pc.dbDSN = fileData.DatabaseDsn
 	pc.srvAddr = fileData.ServerAddress
 	pc.sessionLifeTime = time.Duration(fileData.SessionLifeTime)
+	pc.maxBodySize = fileData.MaxBodySize
+	pc.quotaItems = fileData.QuotaItems
+	pc.quotaBytes = fileData.QuotaBytes
+	pc.logLevel = fileData.LogLevel
+	pc.logFormat = fileData.LogFormat
+	pc.logOutput = fileData.LogOutput
+	pc.metricsAddr = fileData.MetricsAddress
+	pc.grpcAddr = fileData.GRPCAddress
+	pc.drainDelay = time.Duration(fileData.DrainDelay)
+	pc.shutdownTimeout = time.Duration(fileData.ShutdownTimeout)
+	pc.dbMaxOpenConns = fileData.DBMaxOpenConns
+	pc.dbMaxIdleConns = fileData.DBMaxIdleConns
+	pc.dbConnMaxLifetime = time.Duration(fileData.DBConnMaxLifetime)
+	pc.dbQueryTimeout = time.Duration(fileData.DBQueryTimeout)
 
 	return &pc
 }
@@ -137,6 +413,21 @@ type fileStruct struct {
 	ServerAddress   string `json:"server_address"`
 	DatabaseDsn     string `json:"database_dsn"`
 	SessionLifeTime int    `json:"session_lifetime"` // in minutes
+	MaxBodySize     int64  `json:"max_body_size"`    // in bytes
+	QuotaItems      int    `json:"quota_items"`      // per data type
+	QuotaBytes      int64  `json:"quota_bytes"`
+	LogLevel        string `json:"log_level"`
+	LogFormat       string `json:"log_format"`
+	LogOutput       string `json:"log_output"`
+	MetricsAddress  string `json:"metrics_address"`
+	GRPCAddress     string `json:"grpc_address"`
+	DrainDelay      int    `json:"drain_delay"`
+	ShutdownTimeout int    `json:"shutdown_timeout"`
+
+	DBMaxOpenConns    int `json:"db_max_open_conns"`
+	DBMaxIdleConns    int `json:"db_max_idle_conns"`
+	DBConnMaxLifetime int `json:"db_conn_max_lifetime"`
+	DBQueryTimeout    int `json:"db_query_timeout"`
 }
 
 func parseFile(p string) (*fileStruct, error) {
@@ -3,6 +3,7 @@ package srvconfig
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -10,12 +11,40 @@ func TestNewConfig(t *testing.T) {
 	osArgs := []string{
 		"-a", "localhost:5555",
 		"-d", "db",
-		"-s", "100ns",}
+		"-s", "100ns",
+		"-b", "100",
+		"-qi", "10",
+		"-qb", "1000",
+		"-ll", "debug",
+		"-lf", "console",
+		"-lo", "stdout",
+		"-ma", "localhost:9090",
+		"-ga", "localhost:9091",
+		"-dd", "5s",
+		"-st", "10s",
+		"-dmo", "50",
+		"-dmi", "5",
+		"-dml", "1h",
+		"-dqt", "3s",}
 
 	envVars := map[string]string{
 		"SERVER_ADDRESS":    "localhost:5555",
 		"SESSION_LIFETIME":   "100ns",
 		"DATABASE_DSN":      "db",
+		"MAX_BODY_SIZE":     "100",
+		"QUOTA_ITEMS":       "10",
+		"QUOTA_BYTES":       "1000",
+		"LOG_LEVEL":         "debug",
+		"LOG_FORMAT":        "console",
+		"LOG_OUTPUT":        "stdout",
+		"METRICS_ADDRESS":   "localhost:9090",
+		"GRPC_ADDRESS":      "localhost:9091",
+		"DRAIN_DELAY":       "5s",
+		"SHUTDOWN_TIMEOUT":  "10s",
+		"DB_MAX_OPEN_CONNS":    "50",
+		"DB_MAX_IDLE_CONNS":    "5",
+		"DB_CONN_MAX_LIFETIME": "1h",
+		"DB_QUERY_TIMEOUT":     "3s",
 	}
 
 	filePath := "./testdata/1.json"
@@ -27,20 +56,48 @@ func TestNewConfig(t *testing.T) {
 	}{
 		{
 			name: "flags only",
-			opts: []configOption{WithEnvVars(map[string]string{}), withOsArgs(osArgs)},
+			opts: []configOption{WithEnvVars(map[string]string{}), WithOsArgs(osArgs)},
 			want: Config{
 				srvAddr:       "localhost:5555",
 				dbDSN:         "db",
 				sessionLifeTime: 100,
+				maxBodySize:     100,
+				quotaItems:      10,
+				quotaBytes:      1000,
+				logLevel:        "debug",
+				logFormat:       "console",
+				logOutput:       "stdout",
+				metricsAddr:     "localhost:9090",
+				grpcAddr:        "localhost:9091",
+				drainDelay:      5 * time.Second,
+				shutdownTimeout: 10 * time.Second,
+				dbMaxOpenConns:    50,
+				dbMaxIdleConns:    5,
+				dbConnMaxLifetime: time.Hour,
+				dbQueryTimeout:    3 * time.Second,
 			},
 		},
 		{
 			name: "envs only",
-			opts: []configOption{IgnoreOsArgs(), withOsArgs([]string{}), WithEnvVars(envVars)},
+			opts: []configOption{IgnoreOsArgs(), WithOsArgs([]string{}), WithEnvVars(envVars)},
 			want: Config{
 				srvAddr:       "localhost:5555",
 				dbDSN:         "db",
 				sessionLifeTime: 100,
+				maxBodySize:     100,
+				quotaItems:      10,
+				quotaBytes:      1000,
+				logLevel:        "debug",
+				logFormat:       "console",
+				logOutput:       "stdout",
+				metricsAddr:     "localhost:9090",
+				grpcAddr:        "localhost:9091",
+				drainDelay:      5 * time.Second,
+				shutdownTimeout: 10 * time.Second,
+				dbMaxOpenConns:    50,
+				dbMaxIdleConns:    5,
+				dbConnMaxLifetime: time.Hour,
+				dbQueryTimeout:    3 * time.Second,
 			},
 		},
 		{
@@ -50,29 +107,71 @@ func TestNewConfig(t *testing.T) {
 				srvAddr:       "111",
 				dbDSN:         "111",
 				sessionLifeTime: 111,
+				maxBodySize:     111,
+				quotaItems:      111,
+				quotaBytes:      111,
+				logLevel:        "warn",
+				logFormat:       "console",
+				logOutput:       "111",
+				metricsAddr:     "111",
+				grpcAddr:        "111",
+				drainDelay:      111,
+				shutdownTimeout: 111,
+				dbMaxOpenConns:    111,
+				dbMaxIdleConns:    111,
+				dbConnMaxLifetime: 111,
+				dbQueryTimeout:    111,
 			},
 		},
 		{
 			name: "flags over file",
-			opts: []configOption{WithEnvVars(map[string]string{}), withOsArgs(osArgs), WithFile(filePath)},
+			opts: []configOption{WithEnvVars(map[string]string{}), WithOsArgs(osArgs), WithFile(filePath)},
 			want: Config{
 				srvAddr:       "localhost:5555",
 				dbDSN:         "db",
 				sessionLifeTime: 100,
+				maxBodySize:     100,
+				quotaItems:      10,
+				quotaBytes:      1000,
+				logLevel:        "debug",
+				logFormat:       "console",
+				logOutput:       "stdout",
+				metricsAddr:     "localhost:9090",
+				grpcAddr:        "localhost:9091",
+				drainDelay:      5 * time.Second,
+				shutdownTimeout: 10 * time.Second,
+				dbMaxOpenConns:    50,
+				dbMaxIdleConns:    5,
+				dbConnMaxLifetime: time.Hour,
+				dbQueryTimeout:    3 * time.Second,
 			},
 		},
 		{
 			name: "envs over file",
-			opts: []configOption{IgnoreOsArgs(), WithFile(filePath), withOsArgs([]string{}), WithEnvVars(envVars)},
+			opts: []configOption{IgnoreOsArgs(), WithFile(filePath), WithOsArgs([]string{}), WithEnvVars(envVars)},
 			want: Config{
 				srvAddr:       "localhost:5555",
 				dbDSN:         "db",
 				sessionLifeTime: 100,
+				maxBodySize:     100,
+				quotaItems:      10,
+				quotaBytes:      1000,
+				logLevel:        "debug",
+				logFormat:       "console",
+				logOutput:       "stdout",
+				metricsAddr:     "localhost:9090",
+				grpcAddr:        "localhost:9091",
+				drainDelay:      5 * time.Second,
+				shutdownTimeout: 10 * time.Second,
+				dbMaxOpenConns:    50,
+				dbMaxIdleConns:    5,
+				dbConnMaxLifetime: time.Hour,
+				dbQueryTimeout:    3 * time.Second,
 			},
 		},
 		{
 			name: "flags over vars",
-			opts: []configOption{withOsArgs(osArgs),
+			opts: []configOption{WithOsArgs(osArgs),
 				WithEnvVars(map[string]string{
 					"SERVER_ADDRESS":    "0:0",
 					"SESSION_LIFETIME":   "0",
@@ -82,6 +181,20 @@ func TestNewConfig(t *testing.T) {
 				srvAddr:       "localhost:5555",
 				dbDSN:         "db",
 				sessionLifeTime: 100,
+				maxBodySize:     100,
+				quotaItems:      10,
+				quotaBytes:      1000,
+				logLevel:        "debug",
+				logFormat:       "console",
+				logOutput:       "stdout",
+				metricsAddr:     "localhost:9090",
+				grpcAddr:        "localhost:9091",
+				drainDelay:      5 * time.Second,
+				shutdownTimeout: 10 * time.Second,
+				dbMaxOpenConns:    50,
+				dbMaxIdleConns:    5,
+				dbConnMaxLifetime: time.Hour,
+				dbQueryTimeout:    3 * time.Second,
 			},
 		},
 	}
@@ -91,7 +204,42 @@ func TestNewConfig(t *testing.T) {
 				t.Errorf("New().DBDSN() = %v, want %v", got.DBDSN(), tt.want.dbDSN)
 				t.Errorf("New().SrvAddr() = %v, want %v", got.SrvAddr(), tt.want.srvAddr)
 				t.Errorf("New().SessionLifetime() = %v, want %v", got.SessionLifetime(), tt.want.sessionLifeTime)
+				t.Errorf("New().MaxBodySize() = %v, want %v", got.MaxBodySize(), tt.want.maxBodySize)
+				t.Errorf("New().QuotaItems() = %v, want %v", got.QuotaItems(), tt.want.quotaItems)
+				t.Errorf("New().QuotaBytes() = %v, want %v", got.QuotaBytes(), tt.want.quotaBytes)
+				t.Errorf("New().LogLevel() = %v, want %v", got.LogLevel(), tt.want.logLevel)
+				t.Errorf("New().LogFormat() = %v, want %v", got.LogFormat(), tt.want.logFormat)
+				t.Errorf("New().LogOutput() = %v, want %v", got.LogOutput(), tt.want.logOutput)
+				t.Errorf("New().MetricsAddr() = %v, want %v", got.MetricsAddr(), tt.want.metricsAddr)
+				t.Errorf("New().GRPCAddr() = %v, want %v", got.GRPCAddr(), tt.want.grpcAddr)
+				t.Errorf("New().DrainDelay() = %v, want %v", got.DrainDelay(), tt.want.drainDelay)
+				t.Errorf("New().ShutdownTimeout() = %v, want %v", got.ShutdownTimeout(), tt.want.shutdownTimeout)
+				t.Errorf("New().DBMaxOpenConns() = %v, want %v", got.DBMaxOpenConns(), tt.want.dbMaxOpenConns)
+				t.Errorf("New().DBMaxIdleConns() = %v, want %v", got.DBMaxIdleConns(), tt.want.dbMaxIdleConns)
+				t.Errorf("New().DBConnMaxLifetime() = %v, want %v", got.DBConnMaxLifetime(), tt.want.dbConnMaxLifetime)
+				t.Errorf("New().DBQueryTimeout() = %v, want %v", got.DBQueryTimeout(), tt.want.dbQueryTimeout)
 			}		
 		})
 	}
 }
+
+func TestDBDriver(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{dsn: "user=postgres host=localhost", want: DriverPostgres},
+		{dsn: "postgres://postgres@localhost/db", want: DriverPostgres},
+		{dsn: "sqlite://ghostorange.db", want: DriverSQLite},
+		{dsn: "sqlite://:memory:", want: DriverSQLite},
+		{dsn: "memory://", want: DriverMemory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			if got := (Config{dbDSN: tt.dsn}).DBDriver(); got != tt.want {
+				t.Errorf("DBDriver() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package broker fans out change notifications to subscribers
+// within the process. Storages publish changes once they are
+// committed, server streams them to clients.
+package broker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// bufferSize is the number of changes a subscriber may lag behind.
+const bufferSize = 64
+
+type (
+	Broker struct {
+		mu   sync.Mutex
+		subs map[string]map[*subscriber]struct{} // by user ID
+	}
+
+	subscriber struct {
+		ch chan model.Change
+	}
+)
+
+// New returns Broker with no subscribers.
+func New() *Broker {
+	return &Broker{subs: make(map[string]map[*subscriber]struct{})}
+}
+
+// Subscribe returns a channel that receives changes of user's items
+// until ctx is done. A subscriber that does not keep up is dropped
+// rather than blocking publishers. Either way the channel is closed,
+// so the subscriber knows it might have missed changes.
+func (b *Broker) Subscribe(ctx context.Context, userID string) <-chan model.Change {
+	s := &subscriber{ch: make(chan model.Change, bufferSize)}
+
+	b.mu.Lock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*subscriber]struct{})
+	}
+
+	b.subs[userID][s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.drop(userID, s)
+	}()
+
+	return s.ch
+}
+
+// Publish sends the change to subscribers of user.
+func (b *Broker) Publish(userID string, c model.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs[userID] {
+		select {
+		case s.ch <- c:
+		default:
+			b.drop(userID, s)
+		}
+	}
+}
+
+// drop unsubscribes s and closes its channel unless it's done already.
+// Expected to be called holding the lock.
+func (b *Broker) drop(userID string, s *subscriber) {
+	if _, ok := b.subs[userID][s]; !ok {
+		return
+	}
+
+	delete(b.subs[userID], s)
+	close(s.ch)
+
+	if len(b.subs[userID]) == 0 {
+		delete(b.subs, userID)
+	}
+}
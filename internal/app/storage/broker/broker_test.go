@@ -0,0 +1,56 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+func TestBroker(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+
+	slow := b.Subscribe(ctx, "user")
+	other := b.Subscribe(ctx, "other")
+
+	for i := 0; i < bufferSize; i++ {
+		b.Publish("user", model.Change{Seq: int64(i + 1)})
+	}
+
+	fast := b.Subscribe(ctx, "user")
+
+	// buffer of slow subscriber is full, so it is dropped
+	b.Publish("user", model.Change{Seq: bufferSize + 1})
+
+	for i := 0; i < bufferSize; i++ {
+		c, ok := <-slow
+		require.True(t, ok)
+		assert.Equal(t, int64(i+1), c.Seq)
+	}
+
+	_, ok := <-slow
+	assert.False(t, ok, "slow subscriber must be dropped")
+
+	c := <-fast
+	assert.Equal(t, int64(bufferSize+1), c.Seq)
+	assert.Empty(t, other, "changes of another user are received")
+}
+
+func TestBrokerCancel(t *testing.T) {
+	b := New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch := b.Subscribe(ctx, "user")
+	cancel()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// publishing to nobody does not panic
+	b.Publish("user", model.Change{})
+	assert.Empty(t, b.subs)
+}
@@ -0,0 +1,686 @@
+// Package memdb implements a thread-safe storage that keeps
+// all data in memory. Data is lost when the process exits, so it
+// is meant for tests and trying the service out.
+package memdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth"
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/storage/broker"
+	"github.com/usa4ev/ghostorange/internal/app/storage/quota"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+	"github.com/usa4ev/ghostorange/internal/pkg/encryption"
+)
+
+type (
+	Database struct {
+		mu         sync.RWMutex
+		users      map[string]user   // by ID
+		usernames  map[string]string // user ID by username
+		items      []map[string]*record
+		changes    []map[string]*change // by data type and item ID
+		events     *broker.Broker
+		seq        int
+		quotaItems int
+		quotaBytes int64
+	}
+	config interface {
+		QuotaItems() int
+		QuotaBytes() int64
+	}
+
+	user struct {
+		username  string
+		hash      string
+		changeSeq int64
+	}
+
+	// change is the latest change of an item, see changes table
+	// of SQL implementations.
+	change struct {
+		userID     string
+		seq        int64
+		createdSeq int64
+		deleted    bool
+		ts         time.Time
+	}
+
+	// record is a stored item. Credentials are kept encrypted
+	// the same way SQL implementations store them.
+	record struct {
+		userID    string
+		seq       int
+		item      any
+		encrypted []byte
+		// payload is size of item data in bytes, see payloadColumn of SQL implementations
+		payload int
+	}
+)
+
+// New returns empty Database.
+func New(cfg config) *Database {
+	db := &Database{
+		users:      make(map[string]user),
+		usernames:  make(map[string]string),
+		items:      make([]map[string]*record, model.KeyLimit),
+		changes:    make([]map[string]*change, model.KeyLimit),
+		events:     broker.New(),
+		quotaItems: cfg.QuotaItems(),
+		quotaBytes: cfg.QuotaBytes(),
+	}
+
+	for i := range db.items {
+		db.items[i] = make(map[string]*record)
+		db.changes[i] = make(map[string]*change)
+	}
+
+	return db
+}
+
+// Ready always returns nil since there is nothing to wait for.
+func (db *Database) Ready(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing, data stays available until Database is collected.
+func (db *Database) Close() error {
+	return nil
+}
+
+// AddUser adds new user and return new user ID or error if addition failed
+func (db *Database) AddUser(ctx context.Context, username, hash string) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.usernames[username]; ok {
+		return "", auth.ErrUserAlreadyExists
+	}
+
+	id := uuid.NewString()
+	db.users[id] = user{username: username, hash: hash}
+	db.usernames[username] = id
+
+	return id, nil
+}
+
+// UserExists returns true if user found by given userName or false otherwise
+func (db *Database) UserExists(ctx context.Context, userName string) (bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	_, ok := db.usernames[userName]
+
+	return ok, nil
+}
+
+// GetPasswordHash returns user ID and pwd hash found by given userName or empty string as user ID if user not found
+func (db *Database) GetPasswordHash(ctx context.Context, userName string) (string, string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	id, ok := db.usernames[userName]
+	if !ok {
+		return "", "", nil
+	}
+
+	return id, db.users[id].hash, nil
+}
+
+func (db *Database) Count(ctx context.Context, dataType int, userID string) (int, error) {
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return 0, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var res int
+
+	for _, r := range db.items[dataType] {
+		if r.userID == userID {
+			res++
+		}
+	}
+
+	return res, nil
+}
+
+// TotalCount returns number of items of dataType owned by all users.
+func (db *Database) TotalCount(ctx context.Context, dataType int) (int, error) {
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return 0, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return len(db.items[dataType]), nil
+}
+
+// GetData returns slice of items of dataType owned by
+// the user whose ID is stored in ctx. Items are ordered
+// by the time they were added.
+func (db *Database) GetData(ctx context.Context, dataType int) (any, error) {
+	userID, ok := ctx.Value(session.CtxKeyUserID).(string)
+	if !ok {
+		return nil, fmt.Errorf("context is missing user ID")
+	}
+
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return nil, fmt.Errorf("attempted to load an unknown data type")
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	records := make([]*record, 0)
+
+	for _, r := range db.items[dataType] {
+		if r.userID == userID {
+			records = append(records, r)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].seq < records[j].seq })
+
+	return convert(dataType, records)
+}
+
+// convert returns records as a slice of dataType items
+// the way they are revealed to the owner.
+func convert(dataType int, records []*record) (any, error) {
+	switch dataType {
+	case model.KeyCredentials:
+		return load(records, func(r *record) (model.ItemCredentials, error) {
+			item := r.item.(model.ItemCredentials)
+
+			creds, err := decryptCred(r.encrypted)
+			if err != nil {
+				return item, fmt.Errorf("failed to decrypt credentials: %w", err)
+			}
+
+			item.Credentials = creds
+
+			return item, nil
+		})
+	case model.KeyText:
+		return load(records, func(r *record) (model.ItemText, error) {
+			return r.item.(model.ItemText), nil
+		})
+	case model.KeyBinary:
+		return load(records, func(r *record) (model.ItemBinary, error) {
+			return r.item.(model.ItemBinary), nil
+		})
+	default:
+		return load(records, func(r *record) (model.ItemCard, error) {
+			card := r.item.(model.ItemCard)
+
+			// Card list reveals masked number and description only
+			return model.ItemCard{
+				ID:      card.ID,
+				Number:  maskNumber(card.Number),
+				Name:    card.Name,
+				Comment: card.Comment,
+			}, nil
+		})
+	}
+}
+
+func load[T model.Item](records []*record, conv func(r *record) (T, error)) ([]T, error) {
+	res := make([]T, 0, len(records))
+
+	for _, r := range records {
+		item, err := conv(r)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, item)
+	}
+
+	return res, nil
+}
+
+// AddData adds a new item or updates an existing one
+// if data has ID of an item owned by userID.
+func (db *Database) AddData(ctx context.Context, dataType int, userID string, data any) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c, err := db.put(dataType, userID, data)
+	if err != nil {
+		return err
+	}
+
+	db.events.Publish(userID, c)
+
+	return nil
+}
+
+// DeleteData deletes item of dataType owned by user and
+// leaves a tombstone in the change log.
+func (db *Database) DeleteData(ctx context.Context, dataType int, userID, id string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c, err := db.delete(dataType, userID, id)
+	if err != nil {
+		return err
+	}
+
+	db.events.Publish(userID, c)
+
+	return nil
+}
+
+// Batch applies ops of user at once. An operation that fails
+// leaves no trace, its error is returned at its index.
+func (db *Database) Batch(ctx context.Context, userID string, ops []model.BatchOp) ([]error, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	errs := make([]error, len(ops))
+	changes := make([]model.Change, 0, len(ops))
+
+	for i, op := range ops {
+		var (
+			c   model.Change
+			err error
+		)
+
+		switch op.Op {
+		case model.BatchCreate, model.BatchUpdate:
+			c, err = db.put(op.DataType, userID, op.Item)
+		case model.BatchDelete:
+			c, err = db.delete(op.DataType, userID, op.ID)
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+
+		if err != nil {
+			errs[i] = err
+
+			continue
+		}
+
+		changes = append(changes, c)
+	}
+
+	for _, c := range changes {
+		db.events.Publish(userID, c)
+	}
+
+	return errs, nil
+}
+
+// put adds or updates an item. Expected to be called holding the lock.
+func (db *Database) put(dataType int, userID string, data any) (model.Change, error) {
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return model.Change{}, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	r, err := newRecord(dataType, userID, data)
+	if err != nil {
+		return model.Change{}, fmt.Errorf("failed to compose record: %w", err)
+	}
+
+	id := itemID(r.item)
+	if id == "" {
+		id = uuid.NewString()
+		r.item = withID(r.item, id)
+	}
+
+	prev, ok := db.items[dataType][id]
+	if ok && prev.userID != userID {
+		return model.Change{}, fmt.Errorf("%w: item is owned by another user", strgerrors.ErrNotFound)
+	}
+
+	if ok {
+		r.seq = prev.seq
+	} else {
+		db.seq++
+		r.seq = db.seq
+	}
+
+	// usage prior to the change, see checkQuotas
+	var before []model.UsageEntry
+
+	if db.quotaItems != 0 || db.quotaBytes != 0 {
+		before = db.usageEntries(userID)
+	}
+
+	db.items[dataType][id] = r
+
+	if err = db.checkQuotas(dataType, userID, before); err != nil {
+		// roll the change back
+		if ok {
+			db.items[dataType][id] = prev
+		} else {
+			delete(db.items[dataType], id)
+		}
+
+		return model.Change{}, err
+	}
+
+	return db.recordChange(dataType, userID, id, false), nil
+}
+
+// delete deletes an item. Expected to be called holding the lock.
+func (db *Database) delete(dataType int, userID, id string) (model.Change, error) {
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return model.Change{}, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	r, ok := db.items[dataType][id]
+	if !ok || r.userID != userID {
+		return model.Change{}, strgerrors.ErrNotFound
+	}
+
+	delete(db.items[dataType], id)
+
+	return db.recordChange(dataType, userID, id, true), nil
+}
+
+// recordChange takes the next change sequence number of the user,
+// logs the change of the item and returns it.
+// Expected to be called holding the lock.
+func (db *Database) recordChange(dataType int, userID, id string, deleted bool) model.Change {
+	u := db.users[userID]
+	u.changeSeq++
+	db.users[userID] = u
+
+	c, ok := db.changes[dataType][id]
+	if !ok || c.deleted {
+		c = &change{userID: userID, createdSeq: u.changeSeq}
+		db.changes[dataType][id] = c
+	}
+
+	c.seq, c.deleted, c.ts = u.changeSeq, deleted, time.Now()
+
+	return model.Change{
+		Seq:       c.seq,
+		DataType:  dataType,
+		ID:        id,
+		Kind:      changeKind(c, c.seq-1),
+		ChangedAt: c.ts,
+	}
+}
+
+// Subscribe returns a channel that receives changes of user's items
+// until ctx is done.
+func (db *Database) Subscribe(ctx context.Context, userID string) (<-chan model.Change, error) {
+	return db.events.Subscribe(ctx, userID), nil
+}
+
+// Changes returns up to limit changes of user's items
+// committed after the change with sequence number since,
+// in the order they were committed.
+func (db *Database) Changes(ctx context.Context, userID string, since int64, limit int) ([]model.Change, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	res := make([]model.Change, 0)
+
+	for dataType, changes := range db.changes {
+		for id, c := range changes {
+			if c.userID != userID || c.seq <= since {
+				continue
+			}
+
+			res = append(res, model.Change{
+				Seq:       c.seq,
+				DataType:  dataType,
+				ID:        id,
+				Kind:      changeKind(c, since),
+				ChangedAt: c.ts,
+			})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Seq < res[j].Seq })
+
+	if len(res) > limit {
+		res = res[:limit]
+	}
+
+	for i, c := range res {
+		if c.Kind == model.ChangeDeleted {
+			continue
+		}
+
+		data, err := convert(c.DataType, []*record{db.items[c.DataType][c.ID]})
+		if err != nil {
+			return nil, err
+		}
+
+		res[i].Item = reflect.ValueOf(data).Index(0).Interface()
+	}
+
+	return res, nil
+}
+
+func changeKind(c *change, since int64) string {
+	switch {
+	case c.deleted:
+		return model.ChangeDeleted
+	case c.createdSeq > since:
+		return model.ChangeCreated
+	}
+
+	return model.ChangeUpdated
+}
+
+// checkQuotas checks the change against configured limits, see
+// quota.Check. Expected to be called holding the lock after the data
+// is modified so the changes are taken into account.
+func (db *Database) checkQuotas(dataType int, userID string, before []model.UsageEntry) error {
+	if db.quotaItems == 0 && db.quotaBytes == 0 {
+		return nil
+	}
+
+	return quota.Check(before, db.usageEntries(userID), dataType, db.quotaItems, db.quotaBytes)
+}
+
+// Usage returns user's storage consumption by data type
+// along with configured quotas.
+func (db *Database) Usage(ctx context.Context, userID string) (model.Usage, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	res := model.Usage{
+		Entries:    db.usageEntries(userID),
+		ItemsLimit: db.quotaItems,
+		BytesLimit: db.quotaBytes,
+	}
+
+	for _, e := range res.Entries {
+		res.TotalBytes += e.Bytes
+	}
+
+	return res, nil
+}
+
+// usageEntries counts items and their size the same way
+// SQL implementations do: payload plus name and comment.
+func (db *Database) usageEntries(userID string) []model.UsageEntry {
+	res := make([]model.UsageEntry, model.KeyLimit)
+
+	for dataType, records := range db.items {
+		res[dataType].DataType = dataType
+
+		for _, r := range records {
+			if r.userID != userID {
+				continue
+			}
+
+			res[dataType].Count++
+			res[dataType].Bytes += r.size()
+		}
+	}
+
+	return res
+}
+
+func (db *Database) GetCardInfo(ctx context.Context, id, userID string) (model.ItemCard, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	r, ok := db.items[model.KeyCards][id]
+	if !ok || r.userID != userID {
+		return model.ItemCard{}, strgerrors.ErrNotFound
+	}
+
+	return r.item.(model.ItemCard), nil
+}
+
+// newRecord validates data and converts it to a record.
+func newRecord(dataType int, userID string, data any) (*record, error) {
+	r := &record{userID: userID}
+
+	switch dataType {
+	case model.KeyCredentials:
+		item, ok := data.(model.ItemCredentials)
+		if !ok {
+			return nil, fmt.Errorf("data type mismatch actual data type")
+		}
+
+		encrypted, err := encryptCred(item.Credentials)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Credentials = model.Credentials{}
+		r.item, r.encrypted, r.payload = item, encrypted, len(encrypted)
+	case model.KeyText:
+		item, ok := data.(model.ItemText)
+		if !ok {
+			return nil, fmt.Errorf("data type mismatch actual data type")
+		}
+
+		// the slice is copied so the stored item can't be changed
+		// through the caller's one
+		item.Attachments = append([]string(nil), item.Attachments...)
+
+		r.item, r.payload = item, len(item.Text)
+	case model.KeyBinary:
+		item, ok := data.(model.ItemBinary)
+		if !ok {
+			return nil, fmt.Errorf("data type mismatch actual data type")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(item.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		r.item, r.payload = item, len(data)
+	case model.KeyCards:
+		item, ok := data.(model.ItemCard)
+		if !ok {
+			return nil, fmt.Errorf("data type mismatch actual data type")
+		}
+
+		if len(item.Number) != 16 {
+			return nil, fmt.Errorf("card number must be 16 digits long")
+		}
+
+		r.item, r.payload = item, len(item.Number)
+	}
+
+	return r, nil
+}
+
+// size returns size of stored item in bytes.
+func (r *record) size() int64 {
+	var name, comment string
+
+	switch item := r.item.(type) {
+	case model.ItemCredentials:
+		name, comment = item.Name, item.Comment
+	case model.ItemText:
+		name, comment = item.Name, item.Comment
+	case model.ItemBinary:
+		name, comment = item.Name, item.Comment
+	case model.ItemCard:
+		name, comment = item.Name, item.Comment
+	}
+
+	return int64(r.payload + len(name) + len(comment))
+}
+
+func itemID(item any) string {
+	switch item := item.(type) {
+	case model.ItemCredentials:
+		return item.ID
+	case model.ItemText:
+		return item.ID
+	case model.ItemBinary:
+		return item.ID
+	case model.ItemCard:
+		return item.ID
+	}
+
+	return ""
+}
+
+func withID(item any, id string) any {
+	switch item := item.(type) {
+	case model.ItemCredentials:
+		item.ID = id
+		return item
+	case model.ItemText:
+		item.ID = id
+		return item
+	case model.ItemBinary:
+		item.ID = id
+		return item
+	case model.ItemCard:
+		item.ID = id
+		return item
+	}
+
+	return item
+}
+
+// maskNumber replaces 8 middle charachters of card number with *
+func maskNumber(number string) string {
+	return number[:4] + strings.Repeat("*", 8) + number[12:]
+}
+
+func decryptCred(encrypted []byte) (model.Credentials, error) {
+	var res model.Credentials
+
+	// Decrypt opens the data in place, so a copy keeps the stored value intact
+	b, err := encryption.Decrypt(append([]byte(nil), encrypted...))
+	if err != nil {
+		return res, err
+	}
+
+	err = json.NewDecoder(bytes.NewBuffer(b)).Decode(&res)
+
+	return res, err
+}
+
+func encryptCred(item model.Credentials) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	if err := json.NewEncoder(buf).Encode(item); err != nil {
+		return nil, err
+	}
+
+	return encryption.Encrypt(buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package memdb
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+)
+
+type testConfig struct {
+	quotaItems int
+}
+
+func (c testConfig) QuotaItems() int   { return c.quotaItems }
+func (c testConfig) QuotaBytes() int64 { return 0 }
+
+func TestCredentialsStoredEncrypted(t *testing.T) {
+	db := New(testConfig{})
+	ctx := context.Background()
+
+	userID, err := db.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+
+	item := model.ItemCredentials{Credentials: model.Credentials{Login: "login", Password: "secret-password"}}
+	require.NoError(t, db.AddData(ctx, model.KeyCredentials, userID, item))
+
+	require.Len(t, db.items[model.KeyCredentials], 1)
+
+	for _, r := range db.items[model.KeyCredentials] {
+		assert.Empty(t, r.item.(model.ItemCredentials).Credentials, "credentials are stored openly")
+		assert.False(t, bytes.Contains(r.encrypted, []byte("secret-password")), "password is not encrypted")
+	}
+}
+
+func TestConcurrentQuota(t *testing.T) {
+	const quota = 5
+
+	db := New(testConfig{quotaItems: quota})
+	ctx := context.Background()
+
+	userID, err := db.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			db.AddData(ctx, model.KeyText, userID, model.ItemText{Text: "text"})
+		}()
+	}
+
+	wg.Wait()
+
+	n, err := db.Count(ctx, model.KeyText, userID)
+	require.NoError(t, err)
+	assert.Equal(t, quota, n)
+}
+
+func TestQuotaLowered(t *testing.T) {
+	db := New(testConfig{})
+	ctx := context.Background()
+
+	userID, err := db.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+
+	item := model.ItemText{ID: "big", Text: strings.Repeat("a", 100)}
+	require.NoError(t, db.AddData(ctx, model.KeyText, userID, item))
+	require.NoError(t, db.AddData(ctx, model.KeyText, userID, model.ItemText{Text: "b"}))
+
+	// limits lowered below the data already stored
+	db.quotaItems, db.quotaBytes = 1, 50
+
+	item.Text = strings.Repeat("a", 60)
+	assert.NoError(t, db.AddData(ctx, model.KeyText, userID, item), "shrinking an item over quota")
+
+	item.Text = strings.Repeat("a", 70)
+	assert.ErrorIs(t, db.AddData(ctx, model.KeyText, userID, item), strgerrors.ErrQuotaExceeded)
+	assert.ErrorIs(t, db.AddData(ctx, model.KeyText, userID, model.ItemText{Text: "c"}), strgerrors.ErrQuotaExceeded)
+}
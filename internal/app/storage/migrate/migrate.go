@@ -0,0 +1,364 @@
+// Package migrate applies numbered up/down SQL migrations and keeps
+// track of applied versions in schema_migrations table.
+// It is shared by SQL storage implementations.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownSchema = errors.New("database schema is newer than supported")
+	ErrNoMigrations  = errors.New("no applied migrations to roll back")
+)
+
+type (
+	// Migration is a numbered pair of up and down SQL scripts.
+	Migration struct {
+		Version int
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	// Status describes a migration known to the binary
+	// or applied to the database.
+	Status struct {
+		Version   int
+		Name      string
+		Applied   bool
+		AppliedAt time.Time
+		// Unknown is set for migrations applied by a newer version
+		// of the service.
+		Unknown bool
+	}
+
+	// Dialect holds database specific statements.
+	Dialect struct {
+		// CreateTable creates schema_migrations table with version,
+		// name and applied_at columns if one does not exist.
+		CreateTable string
+		// Lock and Unlock are executed around migrations on the same
+		// connection to serialize concurrent migrators. Optional.
+		Lock   string
+		Unlock string
+	}
+
+	// Migrator applies migrations to a database.
+	Migrator struct {
+		db         *sql.DB
+		dialect    Dialect
+		migrations []Migration
+	}
+)
+
+// New returns Migrator that applies migrations found
+// in migrations directory of fsys.
+func New(db *sql.DB, fsys fs.FS, dialect Dialect) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Load reads migrations from migrations directory of fsys. File names
+// are expected to look like 0001_name.up.sql and 0001_name.down.sql.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, f := range files {
+		base := path.Base(f)
+
+		var direction string
+
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("unexpected migration file name %v", base)
+		}
+
+		strVersion, name, ok := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("unexpected migration file name %v", base)
+		}
+
+		version, err := strconv.Atoi(strVersion)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("bad migration version in file name %v", base)
+		}
+
+		b, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %v has different names: %v and %v",
+				version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	res := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %v must have both up and down scripts", m.Version)
+		}
+
+		res = append(res, *m)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+
+	return res, nil
+}
+
+// Latest returns version of the latest known migration.
+func (m *Migrator) Latest() int {
+	return latest(m.migrations)
+}
+
+// Up applies all pending migrations and returns their versions.
+// It fails with ErrUnknownSchema if database has been migrated
+// by a newer version of the service.
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	var applied []int
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = checkKnown(current, m.migrations); err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			if mg.Version <= current {
+				continue
+			}
+
+			err = exec(ctx, conn, mg.Up,
+				`INSERT INTO schema_migrations(version, name, applied_at) VALUES ($1, $2, $3)`,
+				mg.Version, mg.Name, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %v_%v: %w", mg.Version, mg.Name, err)
+			}
+
+			applied = append(applied, mg.Version)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the latest applied migration and returns its version.
+func (m *Migrator) Down(ctx context.Context) (int, error) {
+	var rolledBack int
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if current == 0 {
+			return ErrNoMigrations
+		}
+
+		if err = checkKnown(current, m.migrations); err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			if mg.Version != current {
+				continue
+			}
+
+			err = exec(ctx, conn, mg.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, mg.Version)
+			if err != nil {
+				return fmt.Errorf("failed to roll back migration %v_%v: %w", mg.Version, mg.Name, err)
+			}
+
+			rolledBack = mg.Version
+
+			return nil
+		}
+
+		return fmt.Errorf("migration %v is not found", current)
+	})
+
+	return rolledBack, err
+}
+
+// Status returns known and applied migrations ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if _, err := m.db.ExecContext(ctx, m.dialect.CreateTable); err != nil {
+		return nil, fmt.Errorf("failed to create table schema_migrations: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]Status)
+
+	for rows.Next() {
+		st := Status{Applied: true}
+
+		if err = rows.Scan(&st.Version, &st.Name, &st.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+
+		applied[st.Version] = st
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	res := make([]Status, 0, len(m.migrations))
+
+	for _, mg := range m.migrations {
+		st, ok := applied[mg.Version]
+		if !ok {
+			st = Status{Version: mg.Version, Name: mg.Name}
+		}
+
+		delete(applied, mg.Version)
+
+		res = append(res, st)
+	}
+
+	for _, st := range applied {
+		st.Unknown = true
+		res = append(res, st)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+
+	return res, nil
+}
+
+// Version returns the latest applied migration version
+// or 0 if none were applied.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	return version(ctx, m.db)
+}
+
+// withLock calls f holding the migrations lock on a dedicated connection.
+func (m *Migrator) withLock(ctx context.Context, f func(conn *sql.Conn) error) error {
+	// Locks may be held by a session, so all statements
+	// must be executed over the same connection.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect.Lock != "" {
+		if _, err = conn.ExecContext(ctx, m.dialect.Lock); err != nil {
+			return fmt.Errorf("failed to acquire migrations lock: %w", err)
+		}
+	}
+
+	if m.dialect.Unlock != "" {
+		defer conn.ExecContext(context.Background(), m.dialect.Unlock)
+	}
+
+	if _, err = conn.ExecContext(ctx, m.dialect.CreateTable); err != nil {
+		return fmt.Errorf("failed to create table schema_migrations: %w", err)
+	}
+
+	return f(conn)
+}
+
+// exec executes migration script and bookkeeping statement
+// in a single transaction.
+func exec(ctx context.Context, conn *sql.Conn, script, query string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func version(ctx context.Context, q rowQueryer) (int, error) {
+	var v int
+
+	err := q.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return v, nil
+}
+
+// checkKnown returns ErrUnknownSchema if version is newer
+// than the latest of migrations.
+func checkKnown(version int, migrations []Migration) error {
+	if l := latest(migrations); version > l {
+		return fmt.Errorf("%w: schema version %v, latest known %v",
+			ErrUnknownSchema, version, l)
+	}
+
+	return nil
+}
+
+func latest(migrations []Migration) int {
+	if len(migrations) == 0 {
+		return 0
+	}
+
+	return migrations[len(migrations)-1].Version
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("ordered", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0002_second.up.sql":   {Data: []byte("up2")},
+			"migrations/0002_second.down.sql": {Data: []byte("down2")},
+			"migrations/0001_first.up.sql":    {Data: []byte("up1")},
+			"migrations/0001_first.down.sql":  {Data: []byte("down1")},
+		}
+
+		migrations, err := Load(fsys)
+		require.NoError(t, err)
+
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "first", Up: "up1", Down: "down1"},
+			{Version: 2, Name: "second", Up: "up2", Down: "down2"},
+		}, migrations)
+		assert.Equal(t, 2, latest(migrations))
+	})
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{"migrations/0001_first.up.sql": {}},
+		},
+		{
+			name: "bad version",
+			fsys: fstest.MapFS{"migrations/first.up.sql": {}},
+		},
+		{
+			name: "bad suffix",
+			fsys: fstest.MapFS{"migrations/0001_first.sql": {}},
+		},
+		{
+			name: "names differ",
+			fsys: fstest.MapFS{
+				"migrations/0001_first.up.sql":   {Data: []byte("up")},
+				"migrations/0001_other.down.sql": {Data: []byte("down")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheckKnown(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}}
+
+	assert.NoError(t, checkKnown(0, migrations))
+	assert.NoError(t, checkKnown(2, migrations))
+	assert.ErrorIs(t, checkKnown(3, migrations), ErrUnknownSchema)
+}
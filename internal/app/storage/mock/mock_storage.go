@@ -7,7 +7,9 @@ package mockstorage
 import (
 	context "context"
 	model "github.com/usa4ev/ghostorange/internal/app/model"
+	migrate "github.com/usa4ev/ghostorange/internal/app/storage/migrate"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -64,6 +66,50 @@ func (mr *MockStorageMockRecorder) AddUser(ctx, username, hash interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockStorage)(nil).AddUser), ctx, username, hash)
 }
 
+// Batch mocks base method.
+func (m *MockStorage) Batch(ctx context.Context, userID string, ops []model.BatchOp) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", ctx, userID, ops)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *MockStorageMockRecorder) Batch(ctx, userID, ops interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockStorage)(nil).Batch), ctx, userID, ops)
+}
+
+// Changes mocks base method.
+func (m *MockStorage) Changes(ctx context.Context, userID string, since int64, limit int) ([]model.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changes", ctx, userID, since, limit)
+	ret0, _ := ret[0].([]model.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Changes indicates an expected call of Changes.
+func (mr *MockStorageMockRecorder) Changes(ctx, userID, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changes", reflect.TypeOf((*MockStorage)(nil).Changes), ctx, userID, since, limit)
+}
+
+// Close mocks base method.
+func (m *MockStorage) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockStorageMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
+}
+
 // Count mocks base method.
 func (m *MockStorage) Count(ctx context.Context, dataType int, user string) (int, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +125,20 @@ func (mr *MockStorageMockRecorder) Count(ctx, dataType, user interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockStorage)(nil).Count), ctx, dataType, user)
 }
 
+// DeleteData mocks base method.
+func (m *MockStorage) DeleteData(ctx context.Context, dataType int, userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteData", ctx, dataType, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteData indicates an expected call of DeleteData.
+func (mr *MockStorageMockRecorder) DeleteData(ctx, dataType, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockStorage)(nil).DeleteData), ctx, dataType, userID, id)
+}
+
 // GetCardInfo mocks base method.
 func (m *MockStorage) GetCardInfo(ctx context.Context, id, userID string) (model.ItemCard, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +185,65 @@ func (mr *MockStorageMockRecorder) GetPasswordHash(cxt, userName interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordHash", reflect.TypeOf((*MockStorage)(nil).GetPasswordHash), cxt, userName)
 }
 
+// Ready mocks base method.
+func (m *MockStorage) Ready(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockStorageMockRecorder) Ready(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockStorage)(nil).Ready), ctx)
+}
+
+// Subscribe mocks base method.
+func (m *MockStorage) Subscribe(ctx context.Context, userID string) (<-chan model.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, userID)
+	ret0, _ := ret[0].(<-chan model.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockStorageMockRecorder) Subscribe(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStorage)(nil).Subscribe), ctx, userID)
+}
+
+// TotalCount mocks base method.
+func (m *MockStorage) TotalCount(ctx context.Context, dataType int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalCount", ctx, dataType)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TotalCount indicates an expected call of TotalCount.
+func (mr *MockStorageMockRecorder) TotalCount(ctx, dataType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalCount", reflect.TypeOf((*MockStorage)(nil).TotalCount), ctx, dataType)
+}
+
+// Usage mocks base method.
+func (m *MockStorage) Usage(ctx context.Context, userID string) (model.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx, userID)
+	ret0, _ := ret[0].(model.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockStorageMockRecorder) Usage(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockStorage)(nil).Usage), ctx, userID)
+}
+
 // UserExists mocks base method.
 func (m *MockStorage) UserExists(ctx context.Context, username string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -140,6 +259,88 @@ func (mr *MockStorageMockRecorder) UserExists(ctx, username interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExists", reflect.TypeOf((*MockStorage)(nil).UserExists), ctx, username)
 }
 
+// MockMigrator is a mock of Migrator interface.
+type MockMigrator struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigratorMockRecorder
+}
+
+// MockMigratorMockRecorder is the mock recorder for MockMigrator.
+type MockMigratorMockRecorder struct {
+	mock *MockMigrator
+}
+
+// NewMockMigrator creates a new mock instance.
+func NewMockMigrator(ctrl *gomock.Controller) *MockMigrator {
+	mock := &MockMigrator{ctrl: ctrl}
+	mock.recorder = &MockMigratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMigrator) EXPECT() *MockMigratorMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockMigrator) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockMigratorMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockMigrator)(nil).Close))
+}
+
+// MigrateDown mocks base method.
+func (m *MockMigrator) MigrateDown(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateDown", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateDown indicates an expected call of MigrateDown.
+func (mr *MockMigratorMockRecorder) MigrateDown(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateDown", reflect.TypeOf((*MockMigrator)(nil).MigrateDown), ctx)
+}
+
+// MigrateUp mocks base method.
+func (m *MockMigrator) MigrateUp(ctx context.Context) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateUp", ctx)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateUp indicates an expected call of MigrateUp.
+func (mr *MockMigratorMockRecorder) MigrateUp(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateUp", reflect.TypeOf((*MockMigrator)(nil).MigrateUp), ctx)
+}
+
+// MigrationStatus mocks base method.
+func (m *MockMigrator) MigrationStatus(ctx context.Context) ([]migrate.Status, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationStatus", ctx)
+	ret0, _ := ret[0].([]migrate.Status)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrationStatus indicates an expected call of MigrationStatus.
+func (mr *MockMigratorMockRecorder) MigrationStatus(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationStatus", reflect.TypeOf((*MockMigrator)(nil).MigrationStatus), ctx)
+}
+
 // Mockconfig is a mock of config interface.
 type Mockconfig struct {
 	ctrl     *gomock.Controller
@@ -163,6 +364,20 @@ func (m *Mockconfig) EXPECT() *MockconfigMockRecorder {
 	return m.recorder
 }
 
+// DBConnMaxLifetime mocks base method.
+func (m *Mockconfig) DBConnMaxLifetime() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBConnMaxLifetime")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// DBConnMaxLifetime indicates an expected call of DBConnMaxLifetime.
+func (mr *MockconfigMockRecorder) DBConnMaxLifetime() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBConnMaxLifetime", reflect.TypeOf((*Mockconfig)(nil).DBConnMaxLifetime))
+}
+
 // DBDSN mocks base method.
 func (m *Mockconfig) DBDSN() string {
 	m.ctrl.T.Helper()
@@ -176,3 +391,87 @@ func (mr *MockconfigMockRecorder) DBDSN() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBDSN", reflect.TypeOf((*Mockconfig)(nil).DBDSN))
 }
+
+// DBDriver mocks base method.
+func (m *Mockconfig) DBDriver() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBDriver")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// DBDriver indicates an expected call of DBDriver.
+func (mr *MockconfigMockRecorder) DBDriver() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBDriver", reflect.TypeOf((*Mockconfig)(nil).DBDriver))
+}
+
+// DBMaxIdleConns mocks base method.
+func (m *Mockconfig) DBMaxIdleConns() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBMaxIdleConns")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DBMaxIdleConns indicates an expected call of DBMaxIdleConns.
+func (mr *MockconfigMockRecorder) DBMaxIdleConns() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBMaxIdleConns", reflect.TypeOf((*Mockconfig)(nil).DBMaxIdleConns))
+}
+
+// DBMaxOpenConns mocks base method.
+func (m *Mockconfig) DBMaxOpenConns() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBMaxOpenConns")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DBMaxOpenConns indicates an expected call of DBMaxOpenConns.
+func (mr *MockconfigMockRecorder) DBMaxOpenConns() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBMaxOpenConns", reflect.TypeOf((*Mockconfig)(nil).DBMaxOpenConns))
+}
+
+// DBQueryTimeout mocks base method.
+func (m *Mockconfig) DBQueryTimeout() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBQueryTimeout")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// DBQueryTimeout indicates an expected call of DBQueryTimeout.
+func (mr *MockconfigMockRecorder) DBQueryTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBQueryTimeout", reflect.TypeOf((*Mockconfig)(nil).DBQueryTimeout))
+}
+
+// QuotaBytes mocks base method.
+func (m *Mockconfig) QuotaBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuotaBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// QuotaBytes indicates an expected call of QuotaBytes.
+func (mr *MockconfigMockRecorder) QuotaBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuotaBytes", reflect.TypeOf((*Mockconfig)(nil).QuotaBytes))
+}
+
+// QuotaItems mocks base method.
+func (m *Mockconfig) QuotaItems() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuotaItems")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// QuotaItems indicates an expected call of QuotaItems.
+func (mr *MockconfigMockRecorder) QuotaItems() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuotaItems", reflect.TypeOf((*Mockconfig)(nil).QuotaItems))
+}
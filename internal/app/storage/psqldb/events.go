@@ -0,0 +1,132 @@
+package psqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx"
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const (
+	// changesChannel is the channel changes are announced on,
+	// so every server instance learns about them.
+	changesChannel = "ghostorange_changes"
+
+	// delays between attempts to restore the listening connection
+	minListenRetry = time.Second
+	maxListenRetry = 30 * time.Second
+)
+
+// notification is a payload sent to changesChannel.
+type notification struct {
+	UserID string       `json:"user_id"`
+	Change model.Change `json:"change"`
+}
+
+// Subscribe returns a channel that receives changes of user's items,
+// made by any server instance, until ctx is done.
+func (db *Database) Subscribe(ctx context.Context, userID string) (<-chan model.Change, error) {
+	return db.events.Subscribe(ctx, userID), nil
+}
+
+// notify announces the change. The notification is delivered
+// once tx is committed and is dropped if tx is rolled back.
+func notify(ctx context.Context, tx *sql.Tx, userID string, c model.Change) error {
+	payload, err := json.Marshal(notification{UserID: userID, Change: c})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, changesChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	return nil
+}
+
+// listen publishes changes announced on changesChannel to local
+// subscribers until ctx is done. It holds a dedicated connection
+// outside of the pool and reconnects if the connection is lost.
+// Changes announced while reconnecting are missed, clients catch up
+// with them on the next sync.
+func (db *Database) listen(ctx context.Context, dsn string) {
+	defer close(db.listenDone)
+
+	retry := minListenRetry
+
+	for {
+		connected := db.receive(ctx, dsn)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			retry = minListenRetry
+		}
+
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return
+		}
+
+		if retry *= 2; retry > maxListenRetry {
+			retry = maxListenRetry
+		}
+	}
+}
+
+// receive connects to the database and publishes received
+// notifications until the connection fails or ctx is done.
+// It reports whether listening has been started.
+// Failures are logged, as changes of other instances
+// are not delivered until listening is restored.
+func (db *Database) receive(ctx context.Context, dsn string) bool {
+	cfg, err := pgx.ParseConnectionString(dsn)
+	if err != nil {
+		db.logger.Warn("failed to parse DSN to listen for changes", zap.Error(err))
+
+		return false
+	}
+
+	conn, err := pgx.Connect(cfg)
+	if err != nil {
+		db.logger.Warn("failed to connect to listen for changes", zap.Error(err))
+
+		return false
+	}
+	defer conn.Close()
+
+	if err := conn.Listen(changesChannel); err != nil {
+		db.logger.Warn("failed to listen for changes", zap.Error(err))
+
+		return false
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				db.logger.Warn("lost connection listening for changes", zap.Error(err))
+			}
+
+			return true
+		}
+
+		var msg notification
+		if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+			// announced by an incompatible version, nothing to do about it
+			db.logger.Warn("failed to decode change notification", zap.Error(err))
+
+			continue
+		}
+
+		db.events.Publish(msg.UserID, msg.Change)
+	}
+}
@@ -0,0 +1,44 @@
+package psqldb
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/usa4ev/ghostorange/internal/app/storage/migrate"
+)
+
+// migrationsLockID is a key of the advisory lock taken while
+// migrations are applied, so concurrently starting instances
+// do not apply the same migration twice.
+const migrationsLockID = 7345120983
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+var dialect = migrate.Dialect{
+	CreateTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version int PRIMARY KEY,
+		name varchar(255) not null,
+		applied_at timestamptz not null);`,
+	Lock:   fmt.Sprintf(`SELECT pg_advisory_lock(%v)`, migrationsLockID),
+	Unlock: fmt.Sprintf(`SELECT pg_advisory_unlock(%v)`, migrationsLockID),
+}
+
+// MigrateUp applies all pending migrations and returns their versions.
+// It fails with migrate.ErrUnknownSchema if database has been migrated
+// by a newer version of the service.
+func (db *Database) MigrateUp(ctx context.Context) ([]int, error) {
+	return db.migrator.Up(ctx)
+}
+
+// MigrateDown rolls back the latest applied migration
+// and returns its version.
+func (db *Database) MigrateDown(ctx context.Context) (int, error) {
+	return db.migrator.Down(ctx)
+}
+
+// MigrationStatus returns known and applied migrations ordered by version.
+func (db *Database) MigrationStatus(ctx context.Context) ([]migrate.Status, error) {
+	return db.migrator.Status(ctx)
+}
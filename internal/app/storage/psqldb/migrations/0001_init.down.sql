@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS cards;
+DROP TABLE IF EXISTS binarydata;
+DROP TABLE IF EXISTS text;
+DROP TABLE IF EXISTS credentials;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,53 @@
+-- Initial schema. Tables may already exist in deployments
+-- created before migrations were introduced.
+CREATE TABLE IF NOT EXISTS users (
+	id VARCHAR(100) PRIMARY KEY,
+	username VARCHAR(256) not null,
+	pwdhash VARCHAR(256) not null);
+
+CREATE TABLE IF NOT EXISTS credentials (
+	id VARCHAR(100) PRIMARY KEY UNIQUE,
+	user_id VARCHAR(100) not null,
+	ts timestamptz not null,
+	encrypted bytea not null,
+	name varchar(100) not null,
+	comment varchar(1000) not null,
+	FOREIGN KEY (user_id)
+		REFERENCES users (id));
+
+CREATE TABLE IF NOT EXISTS text (
+	id VARCHAR(100) PRIMARY KEY UNIQUE,
+	user_id varchar(100) not null,
+	ts timestamptz not null,
+	text bytea not null,
+	name varchar(100) not null,
+	comment varchar(1000) not null,
+	FOREIGN KEY (user_id)
+		REFERENCES users (id));
+
+CREATE TABLE IF NOT EXISTS binarydata (
+	id VARCHAR(100) PRIMARY KEY UNIQUE,
+	user_id varchar(100) not null,
+	ts timestamptz not null,
+	data bytea not null,
+	extention bytea not null,
+	size int not null,
+	name varchar(255) not null,
+	comment varchar(1000) not null,
+	FOREIGN KEY (user_id)
+		REFERENCES users (id));
+
+CREATE TABLE IF NOT EXISTS cards (
+	id VARCHAR(100) PRIMARY KEY UNIQUE,
+	user_id varchar(100) not null,
+	ts timestamptz not null,
+	number char(16) not null,
+	full_number char(16) not null,
+	expires date not null,
+	cardholderName varchar(255) not null,
+	cardholderSurename varchar(255) not null,
+	cvvhash varchar(255) not null,
+	name varchar(255) not null,
+	comment varchar(1000) not null,
+	FOREIGN KEY (user_id)
+		REFERENCES users (id));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS changes;
+ALTER TABLE users DROP COLUMN change_seq;
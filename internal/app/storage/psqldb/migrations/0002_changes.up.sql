@@ -0,0 +1,33 @@
+-- Change log used by sync. Every item has a single row that
+-- holds the sequence number of its latest change. Deleted items
+-- keep their rows as tombstones.
+-- Sequence numbers are per user. They are taken from the user row
+-- within the transaction that changes data, so a user's changes
+-- are committed in the order of their numbers.
+ALTER TABLE users ADD COLUMN change_seq bigint not null DEFAULT 0;
+
+CREATE TABLE changes (
+	user_id VARCHAR(100) not null REFERENCES users (id),
+	data_type smallint not null,
+	item_id VARCHAR(100) not null,
+	seq bigint not null,
+	created_seq bigint not null,
+	deleted boolean not null,
+	ts timestamptz not null,
+	PRIMARY KEY (user_id, data_type, item_id));
+
+CREATE INDEX changes_user_seq ON changes (user_id, seq);
+
+-- Existing items are logged as created in the order of their ts.
+INSERT INTO changes (user_id, data_type, item_id, seq, created_seq, deleted, ts)
+SELECT user_id, data_type, id, n, n, FALSE, ts FROM (
+	SELECT user_id, data_type, id, ts,
+		ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY ts, data_type, id) AS n
+	FROM (
+		SELECT user_id, 0 AS data_type, id, ts FROM credentials
+		UNION ALL SELECT user_id, 1, id, ts FROM text
+		UNION ALL SELECT user_id, 2, id, ts FROM binarydata
+		UNION ALL SELECT user_id, 3, id, ts FROM cards) items) numbered;
+
+UPDATE users SET change_seq = (
+	SELECT COALESCE(MAX(seq), 0) FROM changes WHERE changes.user_id = users.id);
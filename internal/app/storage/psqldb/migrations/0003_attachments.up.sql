@@ -0,0 +1,3 @@
+-- Notes link binary items as attachments. IDs are kept
+-- as a JSON array, empty string means no attachments.
+ALTER TABLE text ADD COLUMN attachments TEXT not null DEFAULT '';
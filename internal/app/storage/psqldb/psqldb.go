@@ -8,124 +8,170 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/stdlib"
+	"go.uber.org/zap"
 
 	"github.com/usa4ev/ghostorange/internal/app/auth"
 	"github.com/usa4ev/ghostorange/internal/app/auth/session"
 	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/storage/broker"
+	"github.com/usa4ev/ghostorange/internal/app/storage/migrate"
+	"github.com/usa4ev/ghostorange/internal/app/storage/quota"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
 	"github.com/usa4ev/ghostorange/internal/pkg/encryption"
 )
 
 type (
 	Database struct {
 		*sql.DB
+		quotaItems   int
+		quotaBytes   int64
+		queryTimeout time.Duration
+		migrator     *migrate.Migrator
+
+		// statements prepared on start, indexed by data type
+		countStmts []*sql.Stmt
+		loadStmts  []*sql.Stmt
+
+		events *broker.Broker
+		logger *zap.Logger
+		// stopListen stops listening to changes, see listen
+		stopListen context.CancelFunc
+		listenDone chan struct{}
+	}
+	config interface {
+		DBDSN() string
+		QuotaItems() int
+		QuotaBytes() int64
+		DBMaxOpenConns() int
+		DBMaxIdleConns() int
+		DBConnMaxLifetime() time.Duration
+		DBQueryTimeout() time.Duration
+	}
+	queryer interface {
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 	}
 )
 
-func New(dsn string) (*Database, error) {
+// New connects to database, brings its schema up to date
+// and prepares statements.
+// It fails if the schema has been migrated by a newer version of the service.
+func New(cfg config, logger *zap.Logger) (*Database, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db.logger = logger
+
+	_, err = db.MigrateUp(context.Background())
+	if err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("cannot migrate Database: %w", err)
+	}
+
+	ctx, cancel := db.withTimeout(context.Background())
+	defer cancel()
+
+	if err = db.prepareStatements(ctx); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("cannot prepare statements: %w", err)
+	}
+
+	var listenCtx context.Context
+
+	listenCtx, db.stopListen = context.WithCancel(context.Background())
+	db.listenDone = make(chan struct{})
+
+	go db.listen(listenCtx, cfg.DBDSN())
+
+	return db, nil
+}
+
+// Open connects to database leaving its schema as is.
+func Open(cfg config) (*Database, error) {
 	var (
 		db  Database
 		err error
 	)
 
-	db.DB, err = sql.Open("pgx", dsn)
+	db.quotaItems = cfg.QuotaItems()
+	db.quotaBytes = cfg.QuotaBytes()
+	db.queryTimeout = cfg.DBQueryTimeout()
+	db.events = broker.New()
+
+	db.DB, err = sql.Open("pgx", cfg.DBDSN())
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to Database: %w", err)
 	}
 
-	err = db.initDB()
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns())
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns())
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime())
+
+	db.migrator, err = migrate.New(db.DB, migrationsFS, dialect)
 	if err != nil {
-		return nil, fmt.Errorf("cannot init Database: %w", err)
+		db.DB.Close()
+
+		return nil, err
 	}
 
 	return &db, nil
 }
 
-func (db Database) initDB() error {
-	query := `CREATE TABLE IF NOT EXISTS users (
-				id VARCHAR(100) PRIMARY KEY,
-				username VARCHAR(256) not null,
-                pwdhash VARCHAR(256) not null);`
+// Close stops listening to changes, closes prepared statements
+// and the connection pool.
+func (db *Database) Close() error {
+	if db.stopListen != nil {
+		db.stopListen()
+		<-db.listenDone
+	}
 
-	_, err := db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create table users, %v", err)
+	for _, stmts := range [][]*sql.Stmt{db.countStmts, db.loadStmts} {
+		for _, stmt := range stmts {
+			if stmt != nil {
+				stmt.Close()
+			}
+		}
 	}
 
-	query = `CREATE TABLE IF NOT EXISTS credentials (
-					id VARCHAR(100) PRIMARY KEY UNIQUE,
-					user_id VARCHAR(100) not null,
-					ts timestamptz not null,
-					encrypted bytea not null,
-					name varchar(100) not null,
-					comment varchar(1000) not null,
-					FOREIGN KEY (user_id)
-				REFERENCES users (id));`
+	return db.DB.Close()
+}
 
-	_, err = db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create table credentials, %v", err)
+// withTimeout returns ctx limited by configured query timeout.
+func (db *Database) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout == 0 {
+		return context.WithCancel(ctx)
 	}
 
-	query = `CREATE TABLE IF NOT EXISTS text (
-		id VARCHAR(100) PRIMARY KEY UNIQUE,
-		user_id varchar(100) not null,
-		ts timestamptz not null,
-		text bytea not null,
-		name varchar(100) not null,
-		comment varchar(1000) not null,
-		FOREIGN KEY (user_id)
-	REFERENCES users (id));`
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
 
-	_, err = db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create table text, %v", err)
+// Ready returns nil if database is reachable and all migrations are applied.
+func (db *Database) Ready(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database is not reachable: %w", err)
 	}
 
-	query = `CREATE TABLE IF NOT EXISTS binarydata (
-		id VARCHAR(100) PRIMARY KEY UNIQUE,
-		user_id varchar(100) not null,
-		ts timestamptz not null,
-		data bytea not null,
-		extention bytea not null,
-		size int not null,
-		name varchar(255) not null,
-		comment varchar(1000) not null,
-		FOREIGN KEY (user_id)
-	REFERENCES users (id));`
-
-	_, err = db.Exec(query)
+	version, err := db.migrator.Version(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create table binary, %v", err)
+		return err
 	}
 
-	query = `CREATE TABLE IF NOT EXISTS cards (
-		id VARCHAR(100) PRIMARY KEY UNIQUE,
-		user_id varchar(100) not null,
-		ts timestamptz not null,
-		number char(16) not null,
-		full_number char(16) not null,
-		expires date not null,
-		cardholderName varchar(255) not null,
-		cardholderSurename varchar(255) not null,
-		cvvhash varchar(255) not null,
-		name varchar(255) not null,
-		comment varchar(1000) not null,
-		FOREIGN KEY (user_id)
-	REFERENCES users (id));`
-
-	_, err = db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create table cards, %v", err)
+	if latest := db.migrator.Latest(); version != latest {
+		return fmt.Errorf("database schema version is %v, expected %v", version, latest)
 	}
 
-	return err
+	return nil
 }
 
-func (db Database) execInsUpdStatement(ctx context.Context, query string, args ...interface{}) (int64, error) {
-	tx, err := db.Begin()
+func (db *Database) execInsUpdStatement(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -150,7 +196,10 @@ func (db Database) execInsUpdStatement(ctx context.Context, query string, args .
 }
 
 // AddUser adds new row to Database and return new user ID or error if addition failed
-func (db Database) AddUser(ctx context.Context, username, hash string) (string, error) {
+func (db *Database) AddUser(ctx context.Context, username, hash string) (string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	id := uuid.New().String()
 
 	query := `INSERT INTO users(id, username, pwdhash) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING;`
@@ -167,7 +216,10 @@ func (db Database) AddUser(ctx context.Context, username, hash string) (string,
 }
 
 // UserExists returns true if user found by given userName or false otherwise
-func (db Database) UserExists(ctx context.Context, userName string) (bool, error) {
+func (db *Database) UserExists(ctx context.Context, userName string) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	var exists bool
 
 	query := "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)"
@@ -184,7 +236,10 @@ func (db Database) UserExists(ctx context.Context, userName string) (bool, error
 }
 
 // GetPasswordHash returns user ID and pwd hash found by given userName or empty string as user ID if user not found
-func (db Database) GetPasswordHash(ctx context.Context, userName string) (string, string, error) {
+func (db *Database) GetPasswordHash(ctx context.Context, userName string) (string, string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	var userID, hash string
 
 	query := "SELECT id, pwdhash FROM users WHERE username = $1"
@@ -201,21 +256,39 @@ func (db Database) GetPasswordHash(ctx context.Context, userName string) (string
 }
 
 func (db *Database) Count(ctx context.Context, dataType int, userID string) (int, error) {
-	stmt, err := db.prepCountStmnt(dataType)
+	if dataType < 0 || dataType >= len(db.countStmts) {
+		return 0, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	var res int
+
+	err := db.countStmts[dataType].QueryRowContext(ctx, userID).Scan(&res)
 	if err != nil {
 		return 0,
-			fmt.Errorf(
-				"failed to prepare db statement for datatype %v: %w",
+			fmt.Errorf("failed to execute db statement for datatype %v: %w",
 				model.GetItemTitle(dataType),
 				err)
 	}
 
+	return res, nil
+}
+
+// TotalCount returns number of items of dataType owned by all users.
+func (db *Database) TotalCount(ctx context.Context, dataType int) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	var res int
 
-	err = stmt.QueryRowContext(ctx, userID).Scan(&res)
+	query := fmt.Sprintf("SELECT COUNT(id) FROM %v", tableName(dataType))
+
+	err := db.QueryRowContext(ctx, query).Scan(&res)
 	if err != nil {
 		return 0,
-			fmt.Errorf("failed to execute db statement for datatype %v: %w",
+			fmt.Errorf("failed to count items of datatype %v: %w",
 				model.GetItemTitle(dataType),
 				err)
 	}
@@ -224,15 +297,14 @@ func (db *Database) Count(ctx context.Context, dataType int, userID string) (int
 }
 
 func (db *Database) GetData(ctx context.Context, dataType int) (any, error) {
-	stmt, err := db.prepLoadStmnt(dataType)
-	if err != nil {
-		return nil,
-			fmt.Errorf("failed to prepare db statement for datatype %v: %w",
-				model.GetItemTitle(dataType),
-				err)
+	if dataType < 0 || dataType >= len(db.loadStmts) {
+		return nil, fmt.Errorf("unsupported data type %v", dataType)
 	}
 
-	return execLoad(ctx, stmt, dataType)
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return execLoad(ctx, db.loadStmts[dataType], dataType)
 }
 
 func execLoad(ctx context.Context, stmt *sql.Stmt, dataType int) (any, error) {
@@ -251,6 +323,11 @@ func execLoad(ctx context.Context, stmt *sql.Stmt, dataType int) (any, error) {
 
 	defer rows.Close()
 
+	return itemsFromRows(rows, dataType)
+}
+
+// itemsFromRows returns slice of dataType items read from rows.
+func itemsFromRows(rows *sql.Rows, dataType int) (any, error) {
 	switch dataType {
 	case model.KeyCredentials:
 		res := make([]model.ItemCredentials, 0)
@@ -264,7 +341,7 @@ func execLoad(ctx context.Context, stmt *sql.Stmt, dataType int) (any, error) {
 			res = append(res, item)
 		}
 
-		if rows.Err() != nil {
+		if err := rows.Err(); err != nil {
 			return nil,
 				fmt.Errorf("failed to scan values from database result: %w", err)
 		}
@@ -283,7 +360,7 @@ func execLoad(ctx context.Context, stmt *sql.Stmt, dataType int) (any, error) {
 			res = append(res, item)
 		}
 
-		if rows.Err() != nil {
+		if err := rows.Err(); err != nil {
 			return nil,
 				fmt.Errorf("failed to scan values from database result: %w", err)
 		}
@@ -301,7 +378,7 @@ func execLoad(ctx context.Context, stmt *sql.Stmt, dataType int) (any, error) {
 			res = append(res, item)
 		}
 
-		if rows.Err() != nil {
+		if err := rows.Err(); err != nil {
 			return nil,
 				fmt.Errorf("failed to scan values from database result: %w", err)
 		}
@@ -319,7 +396,7 @@ func execLoad(ctx context.Context, stmt *sql.Stmt, dataType int) (any, error) {
 			res = append(res, item)
 		}
 
-		if rows.Err() != nil {
+		if err := rows.Err(); err != nil {
 			return nil,
 				fmt.Errorf("failed to scan values from database result: %w", err)
 		}
@@ -360,7 +437,7 @@ func decryptCred(encrypted []byte) (model.Credentials, error) {
 
 	b, err := encryption.Decrypt(encrypted)
 	if err != nil {
-		return res, nil
+		return res, err
 	}
 
 	buf := bytes.NewBuffer(b)
@@ -382,19 +459,26 @@ func encryptCred(item model.Credentials) ([]byte, error) {
 }
 
 func itemTextFromRow(rows *sql.Rows) (model.ItemText, error) {
+	var attachments string
+
 	item := model.ItemText{}
 
-	// fields: id, text, name, comment
+	// fields: id, text, name, comment, attachments
 	err := rows.Scan(&item.ID,
 		&item.Text,
 		&item.Name,
-		&item.Comment)
+		&item.Comment,
+		&attachments)
 
 	if err != nil {
 		return model.ItemText{},
 			fmt.Errorf("failed to scan values from database result: %w", err)
 	}
 
+	if item.Attachments, err = decodeAttachments(attachments); err != nil {
+		return model.ItemText{}, err
+	}
+
 	return item, nil
 }
 
@@ -439,6 +523,96 @@ func itemBinaryFromRow(rows *sql.Rows) (model.ItemBinary, error) {
 }
 
 func (db *Database) AddData(ctx context.Context, dataType int, userID string, data any) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = db.putItem(ctx, tx, dataType, userID, data); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteData deletes item of dataType owned by user and
+// leaves a tombstone in the change log.
+func (db *Database) DeleteData(ctx context.Context, dataType int, userID, id string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = deleteItem(ctx, tx, dataType, userID, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Batch applies ops of user in one transaction. Every operation
+// is applied within a savepoint, so an operation that fails is
+// rolled back alone, along with its notification, and its error
+// is returned at its index. The rest are committed together.
+func (db *Database) Batch(ctx context.Context, userID string, ops []model.BatchOp) ([]error, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	errs := make([]error, len(ops))
+
+	for i, op := range ops {
+		if _, err := tx.ExecContext(ctx, savepoint()); err != nil {
+			return nil, fmt.Errorf("failed to set savepoint: %w", err)
+		}
+
+		switch op.Op {
+		case model.BatchCreate, model.BatchUpdate:
+			err = db.putItem(ctx, tx, op.DataType, userID, op.Item)
+		case model.BatchDelete:
+			err = deleteItem(ctx, tx, op.DataType, userID, op.ID)
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+
+		if err != nil {
+			errs[i] = err
+
+			if _, err := tx.ExecContext(ctx, rollbackToSavepoint()); err != nil {
+				return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+			}
+
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, releaseSavepoint()); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+// putItem adds or updates an item within tx, logs the change
+// and announces it.
+func (db *Database) putItem(ctx context.Context, tx *sql.Tx, dataType int, userID string, data any) error {
 	// Create new item ID using UUID
 	id := uuid.NewString()
 	query := itemInsQuery(dataType)
@@ -448,16 +622,302 @@ func (db *Database) AddData(ctx context.Context, dataType int, userID string, da
 		return fmt.Errorf("failed to compose args for db query: %w", err)
 	}
 
-	rowsAffected, err := db.execInsUpdStatement(ctx, query, args...)
+	// Taking the change number locks the user row so that
+	// concurrent additions can not exceed quotas together.
+	seq, err := nextSeq(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	// usage prior to the change, see checkQuotas
+	var before []model.UsageEntry
+
+	if db.quotaItems != 0 || db.quotaBytes != 0 {
+		if before, err = usageEntries(ctx, tx, userID); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("data addition query failed: %w", err)
 	}
 
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error when finding rows affected %w", err)
+	}
+
+	// Upsert does not update items owned by other users
 	if rowsAffected == 0 {
-		return fmt.Errorf("data addition query failed: no rows were added")
+		return fmt.Errorf("%w: item is owned by another user", strgerrors.ErrNotFound)
 	}
 
-	return nil
+	if err = db.checkQuotas(ctx, tx, dataType, userID, before); err != nil {
+		return err
+	}
+
+	// args start with the ID of the item, see itemInsArgs
+	itemID, _ := args[0].(string)
+
+	change, err := recordChange(ctx, tx, userID, dataType, itemID, seq, false)
+	if err != nil {
+		return err
+	}
+
+	return notify(ctx, tx, userID, change)
+}
+
+// deleteItem deletes an item within tx, leaves a tombstone
+// and announces the change.
+func deleteItem(ctx context.Context, tx *sql.Tx, dataType int, userID, id string) error {
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	seq, err := nextSeq(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, delItem(dataType), id, userID)
+	if err != nil {
+		return fmt.Errorf("data deletion query failed: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error when finding rows affected %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return strgerrors.ErrNotFound
+	}
+
+	change, err := recordChange(ctx, tx, userID, dataType, id, seq, true)
+	if err != nil {
+		return err
+	}
+
+	return notify(ctx, tx, userID, change)
+}
+
+// Changes returns up to limit changes of user's items
+// committed after the change with sequence number since,
+// in the order they were committed.
+func (db *Database) Changes(ctx context.Context, userID string, since int64, limit int) ([]model.Change, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	// items are loaded from the same snapshot as the change log
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changes, err := loadChanges(ctx, tx, userID, since, limit)
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+
+	until := changes[len(changes)-1].Seq
+
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		rows, err := tx.QueryContext(ctx, selChanged(dataType), userID, since, until)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute db statement: %w", err)
+		}
+
+		data, err := itemsFromRows(rows, dataType)
+		rows.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		attachItems(changes, dataType, data)
+	}
+
+	return changes, nil
+}
+
+// nextSeq takes the next change sequence number of the user.
+// See nextChangeSeq.
+func nextSeq(ctx context.Context, tx *sql.Tx, userID string) (int64, error) {
+	var seq int64
+
+	err := tx.QueryRowContext(ctx, nextChangeSeq(), userID).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: user does not exist", strgerrors.ErrNotFound)
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to take change number: %w", err)
+	}
+
+	return seq, nil
+}
+
+// recordChange logs the change of an item and returns it.
+func recordChange(ctx context.Context, tx *sql.Tx, userID string, dataType int, itemID string, seq int64, deleted bool) (model.Change, error) {
+	var createdSeq int64
+
+	c := model.Change{Seq: seq, DataType: dataType, ID: itemID}
+
+	err := tx.QueryRowContext(ctx, logChange(), userID, dataType, itemID, seq, deleted).
+		Scan(&createdSeq, &c.ChangedAt)
+	if err != nil {
+		return c, fmt.Errorf("failed to log change: %w", err)
+	}
+
+	switch {
+	case deleted:
+		c.Kind = model.ChangeDeleted
+	case createdSeq == seq:
+		c.Kind = model.ChangeCreated
+	default:
+		c.Kind = model.ChangeUpdated
+	}
+
+	return c, nil
+}
+
+// loadChanges returns a page of change log. Items are to be
+// attached by caller.
+func loadChanges(ctx context.Context, q queryer, userID string, since int64, limit int) ([]model.Change, error) {
+	rows, err := q.QueryContext(ctx, selChanges(), userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute changes query: %w", err)
+	}
+
+	defer rows.Close()
+
+	res := make([]model.Change, 0)
+
+	for rows.Next() {
+		var (
+			c          model.Change
+			createdSeq int64
+			deleted    bool
+		)
+
+		err := rows.Scan(&c.DataType, &c.ID, &c.Seq, &createdSeq, &deleted, &c.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+		}
+
+		switch {
+		case deleted:
+			c.Kind = model.ChangeDeleted
+		case createdSeq > since:
+			c.Kind = model.ChangeCreated
+		default:
+			c.Kind = model.ChangeUpdated
+		}
+
+		res = append(res, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	return res, nil
+}
+
+// attachItems sets items of dataType to the matching changes.
+func attachItems(changes []model.Change, dataType int, data any) {
+	items := make(map[string]any)
+
+	switch v := data.(type) {
+	case []model.ItemCredentials:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	case []model.ItemText:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	case []model.ItemBinary:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	case []model.ItemCard:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	}
+
+	for i, c := range changes {
+		if c.DataType == dataType && c.Kind != model.ChangeDeleted {
+			changes[i].Item = items[c.ID]
+		}
+	}
+}
+
+// checkQuotas checks the change against configured limits, see
+// quota.Check. Expected to be called within a transaction after the data
+// is modified so the changes are taken into account.
+func (db *Database) checkQuotas(ctx context.Context, q queryer, dataType int, userID string, before []model.UsageEntry) error {
+	if db.quotaItems == 0 && db.quotaBytes == 0 {
+		return nil
+	}
+
+	after, err := usageEntries(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	return quota.Check(before, after, dataType, db.quotaItems, db.quotaBytes)
+}
+
+// Usage returns user's storage consumption by data type
+// along with configured quotas.
+func (db *Database) Usage(ctx context.Context, userID string) (model.Usage, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	entries, err := usageEntries(ctx, db.DB, userID)
+	if err != nil {
+		return model.Usage{}, err
+	}
+
+	res := model.Usage{
+		Entries:    entries,
+		ItemsLimit: db.quotaItems,
+		BytesLimit: db.quotaBytes,
+	}
+
+	for _, e := range entries {
+		res.TotalBytes += e.Bytes
+	}
+
+	return res, nil
+}
+
+func usageEntries(ctx context.Context, q queryer, userID string) ([]model.UsageEntry, error) {
+	rows, err := q.QueryContext(ctx, selUsage(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute usage query: %w", err)
+	}
+
+	defer rows.Close()
+
+	res := make([]model.UsageEntry, 0, model.KeyLimit)
+
+	for rows.Next() {
+		e := model.UsageEntry{}
+		if err := rows.Scan(&e.DataType, &e.Count, &e.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+		}
+
+		res = append(res, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	return res, nil
 }
 
 func itemInsQuery(datatype int) string {
@@ -522,6 +982,9 @@ func assertItem[T model.Item](data any) (T, error) {
 }
 
 func (db *Database) GetCardInfo(ctx context.Context, id, userID string) (model.ItemCard, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT id, full_number, expires, 
 		cardholdername, cardholdersurename, 
 		cvvhash, name, comment FROM cards 
@@ -534,8 +997,7 @@ func (db *Database) GetCardInfo(ctx context.Context, id, userID string) (model.I
 			&res.CVVHash, &res.Name, &res.Comment)
 
 	if errors.Is(err, sql.ErrNoRows) {
-		// ToDo: replace with a public error
-		return res, fmt.Errorf("item not found")
+		return res, strgerrors.ErrNotFound
 	} else if err != nil {
 		return res, fmt.Errorf("failed to get card data from Database: %w", err)
 	}
@@ -1,49 +1,176 @@
 package psqldb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/usa4ev/ghostorange/internal/app/model"
 )
 
-func (db *Database) prepCountStmnt(dataType int) (*sql.Stmt, error) {
-	var table string
+// prepareStatements prepares count and load statements for every
+// data type. Statements are closed along with the Database.
+func (db *Database) prepareStatements(ctx context.Context) error {
+	db.countStmts = make([]*sql.Stmt, model.KeyLimit)
+	db.loadStmts = make([]*sql.Stmt, model.KeyLimit)
 
+	for i := 0; i < model.KeyLimit; i++ {
+		var err error
+
+		db.countStmts[i], err = db.PrepareContext(ctx, selCount(i))
+		if err != nil {
+			return fmt.Errorf("failed to prepare count statement for datatype %v: %w",
+				model.GetItemTitle(i), err)
+		}
+
+		db.loadStmts[i], err = db.PrepareContext(ctx, selLoad(i))
+		if err != nil {
+			return fmt.Errorf("failed to prepare load statement for datatype %v: %w",
+				model.GetItemTitle(i), err)
+		}
+	}
+
+	return nil
+}
+
+func selCount(dataType int) string {
+	return fmt.Sprintf("SELECT COUNT(id) FROM %v WHERE user_id = $1",
+		tableName(dataType))
+}
+
+func tableName(dataType int) string {
 	switch dataType {
 	case model.KeyCredentials:
-		table = "credentials"
+		return "credentials"
 	case model.KeyText:
-		table = "text"
+		return "text"
 	case model.KeyBinary:
-		table = "binarydata"
+		return "binarydata"
 	case model.KeyCards:
-		table = "cards"
+		return "cards"
 	}
 
-	query := fmt.Sprintf("SELECT COUNT(id) FROM %v WHERE user_id = $1",
-		table)
+	return ""
+}
 
-	return db.Prepare(query)
+// payloadColumn returns the column that holds
+// the bulk of data type specific item data.
+func payloadColumn(dataType int) string {
+	switch dataType {
+	case model.KeyCredentials:
+		return "encrypted"
+	case model.KeyText:
+		return "text"
+	case model.KeyBinary:
+		return "data"
+	case model.KeyCards:
+		return "full_number"
+	}
+
+	return ""
+}
+
+// nextChangeSeq returns a query that takes the next change
+// sequence number of the user. The user row stays locked until
+// the transaction ends, so changes of the user are serialized
+// and committed in the order of their numbers.
+func nextChangeSeq() string {
+	return `UPDATE users SET change_seq = change_seq + 1
+		WHERE id = $1
+		RETURNING change_seq`
+}
+
+// logChange returns a query that records the latest change of an item
+// and returns the sequence number it was created with and change time.
+// Args: user_id, data_type, item_id, seq, deleted.
+func logChange() string {
+	return `INSERT INTO changes(
+		user_id, data_type, item_id, seq, created_seq, deleted, ts
+		)
+		VALUES (
+			$1, $2, $3, $4, $4, $5, now()
+			)
+			ON CONFLICT (user_id, data_type, item_id) DO UPDATE SET
+			seq = $4,
+			created_seq = CASE WHEN changes.deleted THEN $4 ELSE changes.created_seq END,
+			deleted = $5,
+			ts = now()
+			RETURNING created_seq, ts`
+}
+
+// savepoint returns a query that marks the state a failed
+// operation of a batch is rolled back to.
+func savepoint() string {
+	return "SAVEPOINT batch_op"
+}
+
+func rollbackToSavepoint() string {
+	return "ROLLBACK TO SAVEPOINT batch_op"
 }
 
-func (db *Database) prepLoadStmnt(dataType int) (*sql.Stmt, error) {
-	var query string
+func releaseSavepoint() string {
+	return "RELEASE SAVEPOINT batch_op"
+}
 
+func delItem(dataType int) string {
+	return fmt.Sprintf("DELETE FROM %v WHERE id = $1 AND user_id = $2",
+		tableName(dataType))
+}
+
+// selChanges returns a query that loads a page of user's changes.
+// Args: user_id, since, limit.
+func selChanges() string {
+	return `SELECT data_type, item_id, seq, created_seq, deleted, ts
+		FROM changes
+		WHERE user_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3`
+}
+
+// selChanged returns a query that loads dataType items changed
+// within (since, until] range of sequence numbers.
+// Args: user_id, since, until.
+func selChanged(dataType int) string {
+	return fmt.Sprintf(`%v
+		AND id IN (SELECT item_id FROM changes
+			WHERE user_id = $1 AND data_type = %v AND seq > $2 AND seq <= $3)`,
+		selLoad(dataType), dataType)
+}
+
+// selUsage returns a query that counts items and their size
+// in bytes for every data type owned by user.
+// Fields: data_type, count, bytes.
+func selUsage() string {
+	queries := make([]string, 0, model.KeyLimit)
+
+	for i := 0; i < model.KeyLimit; i++ {
+		queries = append(queries,
+			fmt.Sprintf(`SELECT %v, COUNT(id),
+				COALESCE(SUM(octet_length(%v) + octet_length(name) + octet_length(comment)), 0)
+				FROM %v
+				WHERE user_id = $1`,
+				i, payloadColumn(i), tableName(i)))
+	}
+
+	return strings.Join(queries, " UNION ALL ")
+}
+
+func selLoad(dataType int) string {
 	switch dataType {
 	case model.KeyCredentials:
-		query = selCredentials()
+		return selCredentials()
 	case model.KeyText:
-		query = selText()
+		return selText()
 	case model.KeyBinary:
-		query = selBinary()
+		return selBinary()
 	case model.KeyCards:
-		query = selCards()
+		return selCards()
 	}
 
-	return db.Prepare(query)
+	return ""
 }
 
 func selCredentials() string {
@@ -53,7 +180,7 @@ func selCredentials() string {
 }
 
 func selText() string {
-	return `SELECT id, text, name, comment
+	return `SELECT id, text, name, comment, attachments
 		FROM text
 		WHERE user_id = $1`
 }
@@ -80,7 +207,8 @@ func insCredentials() string {
 			ON CONFLICT (id) DO UPDATE SET
 			encrypted=$3, 
 			name=$4, 
-			comment=$5`
+			comment=$5
+			WHERE credentials.user_id = $2`
 }
 
 // argsCredentials returns slice of args required
@@ -106,15 +234,17 @@ func argsCredentials(id, userID string, item model.ItemCredentials) ([]any, erro
 
 func insText() string {
 	return `INSERT INTO text(
-		id, user_id, ts, text, name, comment
+		id, user_id, ts, text, name, comment, attachments
 		) 
 		VALUES (
-			$1, $2, now()::timestamptz, $3, $4, $5
+			$1, $2, now()::timestamptz, $3, $4, $5, $6
 			) 
 			ON CONFLICT (id) DO UPDATE SET
 			text=$3, 
 			name=$4, 
-			comment=$5`
+			comment=$5,
+			attachments=$6
+			WHERE text.user_id = $2`
 }
 
 // argsText returns slice of args required
@@ -124,15 +254,51 @@ func argsText(id, userID string, item model.ItemText) ([]any, error) {
 		id = item.ID
 	}
 
+	attachments, err := encodeAttachments(item.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
 	return []any{
 		id,
 		userID,
 		[]byte(item.Text),
 		item.Name,
 		item.Comment,
+		attachments,
 	}, nil
 }
 
+// encodeAttachments returns IDs of attachments as they are kept
+// in text table, see decodeAttachments.
+func encodeAttachments(ids []string) (string, error) {
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode attachments: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// decodeAttachments returns IDs of attachments kept in text table
+// as a JSON array, empty value means there are none.
+func decodeAttachments(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal([]byte(s), &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+
+	return ids, nil
+}
+
 func insCard() string {
 	return `INSERT INTO cards(
 		id, user_id, ts, number, full_number, cvvhash, expires, 
@@ -150,13 +316,18 @@ func insCard() string {
 			name=$7, 
 			comment=$8,
 			cardholdername=$9,
-			cardholdersurename=$10`
+			cardholdersurename=$10
+			WHERE cards.user_id = $2`
 
 }
 
 // argsCard returns slice of args required
 // by query. See insCard.
 func argsCard(id, userID string, item model.ItemCard) ([]any, error) {
+	if len(item.Number) != 16 {
+		return nil, fmt.Errorf("card number must be 16 digits long")
+	}
+
 	// Replace 8 middle charachters with *
 	number := item.Number[:4] + strings.Repeat("*", 8) + item.Number[12:]
 
@@ -190,7 +361,8 @@ func insBinary() string {
 			extention=$4, 
 			size=$5, 
 			name=$6, 
-			comment=$7`
+			comment=$7
+			WHERE binarydata.user_id = $2`
 }
 
 // argsBinary returns slice of args required
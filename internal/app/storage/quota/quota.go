@@ -0,0 +1,49 @@
+// Package quota checks storage consumption against configured limits
+// the same way for every storage implementation.
+package quota
+
+import (
+	"fmt"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+)
+
+// Check returns strgerrors.ErrQuotaExceeded if a change of an item
+// of dataType took user's consumption from before to after past the
+// limits, zero limit means no limit. Changes that do not increase
+// consumption pass even over the limits, so users can still shrink
+// their data once the limits are lowered.
+func Check(before, after []model.UsageEntry, dataType, itemsLimit int, bytesLimit int64) error {
+	countBefore, bytesBefore := usageOf(before, dataType)
+	countAfter, bytesAfter := usageOf(after, dataType)
+
+	if itemsLimit != 0 && countAfter > itemsLimit && countAfter > countBefore {
+		return fmt.Errorf("%w: more than %v items of type %v",
+			strgerrors.ErrQuotaExceeded,
+			itemsLimit,
+			model.GetItemTitle(dataType))
+	}
+
+	if bytesLimit != 0 && bytesAfter > bytesLimit && bytesAfter > bytesBefore {
+		return fmt.Errorf("%w: more than %v bytes stored",
+			strgerrors.ErrQuotaExceeded,
+			bytesLimit)
+	}
+
+	return nil
+}
+
+// usageOf returns the number of items of dataType
+// and the total size of all items of entries.
+func usageOf(entries []model.UsageEntry, dataType int) (count int, total int64) {
+	for _, e := range entries {
+		total += e.Bytes
+
+		if e.DataType == dataType {
+			count = e.Count
+		}
+	}
+
+	return count, total
+}
@@ -0,0 +1,46 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+)
+
+func TestCheck(t *testing.T) {
+	usage := func(texts int, bytes int64) []model.UsageEntry {
+		return []model.UsageEntry{
+			{DataType: model.KeyText, Count: texts, Bytes: bytes},
+			{DataType: model.KeyBinary, Count: 1, Bytes: 10},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		before, after []model.UsageEntry
+		items         int
+		bytes         int64
+		wantErr       bool
+	}{
+		{"no limits", usage(1, 10), usage(9, 900), 0, 0, false},
+		{"within limits", usage(1, 10), usage(2, 20), 2, 30, false},
+		{"items exceeded", usage(2, 20), usage(3, 30), 2, 0, true},
+		{"bytes exceeded", usage(1, 10), usage(1, 30), 0, 30, true},
+		{"shrinks over limits", usage(3, 90), usage(3, 60), 2, 50, false},
+		{"grows over limits", usage(3, 60), usage(3, 70), 0, 50, true},
+		{"deletes over limits", usage(3, 60), usage(2, 50), 1, 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.before, tt.after, model.KeyText, tt.items, tt.bytes)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, strgerrors.ErrQuotaExceeded)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package sqlitedb
+
+import (
+	"context"
+	"embed"
+
+	"github.com/usa4ev/ghostorange/internal/app/storage/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// SQLite serializes writers itself, so no lock is taken.
+var dialect = migrate.Dialect{
+	CreateTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT not null,
+		applied_at TIMESTAMP not null);`,
+}
+
+// MigrateUp applies all pending migrations and returns their versions.
+// It fails with migrate.ErrUnknownSchema if database has been migrated
+// by a newer version of the service.
+func (db *Database) MigrateUp(ctx context.Context) ([]int, error) {
+	return db.migrator.Up(ctx)
+}
+
+// MigrateDown rolls back the latest applied migration
+// and returns its version.
+func (db *Database) MigrateDown(ctx context.Context) (int, error) {
+	return db.migrator.Down(ctx)
+}
+
+// MigrationStatus returns known and applied migrations ordered by version.
+func (db *Database) MigrationStatus(ctx context.Context) ([]migrate.Status, error) {
+	return db.migrator.Status(ctx)
+}
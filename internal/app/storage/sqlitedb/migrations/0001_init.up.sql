@@ -0,0 +1,43 @@
+CREATE TABLE users (
+	id TEXT PRIMARY KEY,
+	username TEXT not null,
+	pwdhash TEXT not null);
+
+CREATE TABLE credentials (
+	id TEXT PRIMARY KEY,
+	user_id TEXT not null REFERENCES users (id),
+	ts TIMESTAMP not null,
+	encrypted BLOB not null,
+	name TEXT not null,
+	comment TEXT not null);
+
+CREATE TABLE text (
+	id TEXT PRIMARY KEY,
+	user_id TEXT not null REFERENCES users (id),
+	ts TIMESTAMP not null,
+	text BLOB not null,
+	name TEXT not null,
+	comment TEXT not null);
+
+CREATE TABLE binarydata (
+	id TEXT PRIMARY KEY,
+	user_id TEXT not null REFERENCES users (id),
+	ts TIMESTAMP not null,
+	data BLOB not null,
+	extention BLOB not null,
+	size INTEGER not null,
+	name TEXT not null,
+	comment TEXT not null);
+
+CREATE TABLE cards (
+	id TEXT PRIMARY KEY,
+	user_id TEXT not null REFERENCES users (id),
+	ts TIMESTAMP not null,
+	number TEXT not null,
+	full_number TEXT not null,
+	expires DATE not null,
+	cardholderName TEXT not null,
+	cardholderSurename TEXT not null,
+	cvvhash TEXT not null,
+	name TEXT not null,
+	comment TEXT not null);
@@ -0,0 +1 @@
+ALTER TABLE text DROP COLUMN attachments;
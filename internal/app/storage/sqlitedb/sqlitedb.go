@@ -0,0 +1,821 @@
+// Package sqlitedb implements storage on an embedded SQLite database.
+// It is meant for single-user and test deployments that can not
+// afford a PostgreSQL instance.
+package sqlitedb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth"
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	"github.com/usa4ev/ghostorange/internal/app/storage/broker"
+	"github.com/usa4ev/ghostorange/internal/app/storage/migrate"
+	"github.com/usa4ev/ghostorange/internal/app/storage/quota"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+	"github.com/usa4ev/ghostorange/internal/pkg/encryption"
+)
+
+type (
+	Database struct {
+		*sql.DB
+		quotaItems   int
+		quotaBytes   int64
+		queryTimeout time.Duration
+		migrator     *migrate.Migrator
+		events       *broker.Broker
+	}
+	config interface {
+		DBDSN() string
+		QuotaItems() int
+		QuotaBytes() int64
+		DBQueryTimeout() time.Duration
+	}
+	queryer interface {
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	}
+)
+
+// New opens database file and brings its schema up to date.
+// It fails if the schema has been migrated by a newer version of the service.
+func New(cfg config) (*Database, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.MigrateUp(context.Background())
+	if err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("cannot migrate Database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open opens database file leaving its schema as is.
+// DSN is expected to look like sqlite://path/to/file.db,
+// sqlite://:memory: opens a private in-memory database.
+func Open(cfg config) (*Database, error) {
+	var (
+		db  Database
+		err error
+	)
+
+	db.quotaItems = cfg.QuotaItems()
+	db.quotaBytes = cfg.QuotaBytes()
+	db.queryTimeout = cfg.DBQueryTimeout()
+	db.events = broker.New()
+
+	path := strings.TrimPrefix(cfg.DBDSN(), srvconfig.SchemeSQLite)
+	if path == "" {
+		return nil, fmt.Errorf("database path is missing in DSN")
+	}
+
+	db.DB, err = sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open Database: %w", err)
+	}
+
+	// SQLite allows a single writer at a time, and every connection
+	// to :memory: opens a separate database, so the pool is limited
+	// to a single connection.
+	db.SetMaxOpenConns(1)
+
+	db.migrator, err = migrate.New(db.DB, migrationsFS, dialect)
+	if err != nil {
+		db.DB.Close()
+
+		return nil, err
+	}
+
+	return &db, nil
+}
+
+// withTimeout returns ctx limited by configured query timeout.
+func (db *Database) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
+
+// Subscribe returns a channel that receives changes of user's items
+// until ctx is done. Changes are only seen by the process that made
+// them, database file is not expected to be shared by several servers.
+func (db *Database) Subscribe(ctx context.Context, userID string) (<-chan model.Change, error) {
+	return db.events.Subscribe(ctx, userID), nil
+}
+
+// Ready returns nil if database is reachable and all migrations are applied.
+func (db *Database) Ready(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database is not reachable: %w", err)
+	}
+
+	version, err := db.migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if latest := db.migrator.Latest(); version != latest {
+		return fmt.Errorf("database schema version is %v, expected %v", version, latest)
+	}
+
+	return nil
+}
+
+// AddUser adds new row to Database and return new user ID or error if addition failed
+func (db *Database) AddUser(ctx context.Context, username, hash string) (string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	id := uuid.New().String()
+
+	res, err := db.ExecContext(ctx,
+		`INSERT INTO users(id, username, pwdhash) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`,
+		id, username, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to add user: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("error when finding rows affected %w", err)
+	} else if affected == 0 {
+		return "", auth.ErrUserAlreadyExists
+	}
+
+	return id, nil
+}
+
+// UserExists returns true if user found by given userName or false otherwise
+func (db *Database) UserExists(ctx context.Context, userName string) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+
+	err := db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, userName).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetPasswordHash returns user ID and pwd hash found by given userName or empty string as user ID if user not found
+func (db *Database) GetPasswordHash(ctx context.Context, userName string) (string, string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	var userID, hash string
+
+	err := db.QueryRowContext(ctx,
+		`SELECT id, pwdhash FROM users WHERE username = $1`, userName).Scan(&userID, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to get a password hash from Database: %w", err)
+	}
+
+	return userID, hash, nil
+}
+
+func (db *Database) Count(ctx context.Context, dataType int, userID string) (int, error) {
+	if tableName(dataType) == "" {
+		return 0, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	var res int
+
+	err := db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT COUNT(id) FROM %v WHERE user_id = $1`, tableName(dataType)),
+		userID).Scan(&res)
+	if err != nil {
+		return 0,
+			fmt.Errorf("failed to count items of datatype %v: %w",
+				model.GetItemTitle(dataType),
+				err)
+	}
+
+	return res, nil
+}
+
+// TotalCount returns number of items of dataType owned by all users.
+func (db *Database) TotalCount(ctx context.Context, dataType int) (int, error) {
+	if tableName(dataType) == "" {
+		return 0, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	var res int
+
+	err := db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT COUNT(id) FROM %v`, tableName(dataType))).Scan(&res)
+	if err != nil {
+		return 0,
+			fmt.Errorf("failed to count items of datatype %v: %w",
+				model.GetItemTitle(dataType),
+				err)
+	}
+
+	return res, nil
+}
+
+// GetData returns slice of items of dataType owned by
+// the user whose ID is stored in ctx.
+func (db *Database) GetData(ctx context.Context, dataType int) (any, error) {
+	userID, ok := ctx.Value(session.CtxKeyUserID).(string)
+	if !ok {
+		return nil, fmt.Errorf("context is missing user ID")
+	}
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return loadItems(ctx, db, dataType, selLoad(dataType), userID)
+}
+
+// loadItems returns slice of dataType items selected by query.
+func loadItems(ctx context.Context, q queryer, dataType int, query string, args ...any) (any, error) {
+	switch dataType {
+	case model.KeyCredentials:
+		return load(ctx, q, itemCredsFromRow, query, args...)
+	case model.KeyText:
+		return load(ctx, q, itemTextFromRow, query, args...)
+	case model.KeyBinary:
+		return load(ctx, q, itemBinaryFromRow, query, args...)
+	case model.KeyCards:
+		return load(ctx, q, itemCardFromRow, query, args...)
+	}
+
+	return nil, fmt.Errorf("attempted to load an unknown data type")
+}
+
+// load executes query and scans every resulting row with scan.
+func load[T model.Item](ctx context.Context, q queryer,
+	scan func(rows *sql.Rows) (T, error), query string, args ...any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute db statement: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]T, 0)
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	return res, nil
+}
+
+func itemCredsFromRow(rows *sql.Rows) (model.ItemCredentials, error) {
+	var encrypted []byte
+
+	item := model.ItemCredentials{}
+
+	// fields: id, encrypted, name, comment
+	err := rows.Scan(&item.ID, &encrypted, &item.Name, &item.Comment)
+	if err != nil {
+		return model.ItemCredentials{},
+			fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	item.Credentials, err = decryptCred(encrypted)
+	if err != nil {
+		return model.ItemCredentials{},
+			fmt.Errorf("failed to decrypt credentials result: %w", err)
+	}
+
+	return item, nil
+}
+
+func itemTextFromRow(rows *sql.Rows) (model.ItemText, error) {
+	var text []byte
+	var attachments string
+
+	item := model.ItemText{}
+
+	// fields: id, text, name, comment, attachments
+	err := rows.Scan(&item.ID, &text, &item.Name, &item.Comment, &attachments)
+	if err != nil {
+		return model.ItemText{},
+			fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	item.Text = string(text)
+
+	if item.Attachments, err = decodeAttachments(attachments); err != nil {
+		return model.ItemText{}, err
+	}
+
+	return item, nil
+}
+
+func itemCardFromRow(rows *sql.Rows) (model.ItemCard, error) {
+	item := model.ItemCard{}
+
+	// fields: id, number, name, comment
+	err := rows.Scan(&item.ID, &item.Number, &item.Name, &item.Comment)
+	if err != nil {
+		return model.ItemCard{},
+			fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	return item, nil
+}
+
+func itemBinaryFromRow(rows *sql.Rows) (model.ItemBinary, error) {
+	var data, ext []byte
+
+	item := model.ItemBinary{}
+
+	// fields: id, data, extention, size, name, comment
+	err := rows.Scan(&item.ID, &data, &ext, &item.Size, &item.Name, &item.Comment)
+	if err != nil {
+		return model.ItemBinary{},
+			fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	item.Data = base64.StdEncoding.EncodeToString(data)
+	item.Extention = string(ext)
+
+	return item, nil
+}
+
+func decryptCred(encrypted []byte) (model.Credentials, error) {
+	var res model.Credentials
+
+	b, err := encryption.Decrypt(encrypted)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.NewDecoder(bytes.NewBuffer(b)).Decode(&res)
+
+	return res, err
+}
+
+func encryptCred(item model.Credentials) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	if err := json.NewEncoder(buf).Encode(item); err != nil {
+		return nil, err
+	}
+
+	return encryption.Encrypt(buf.Bytes())
+}
+
+func (db *Database) AddData(ctx context.Context, dataType int, userID string, data any) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	// The pool holds a single connection, so transactions
+	// are serialized and quotas can not be exceeded concurrently.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	change, err := db.putItem(ctx, tx, dataType, userID, data)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	db.events.Publish(userID, change)
+
+	return nil
+}
+
+// DeleteData deletes item of dataType owned by user and
+// leaves a tombstone in the change log.
+func (db *Database) DeleteData(ctx context.Context, dataType int, userID, id string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	change, err := deleteItem(ctx, tx, dataType, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	db.events.Publish(userID, change)
+
+	return nil
+}
+
+// Batch applies ops of user in one transaction. Every operation
+// is applied within a savepoint, so an operation that fails is
+// rolled back alone and its error is returned at its index.
+// The rest are committed together.
+func (db *Database) Batch(ctx context.Context, userID string, ops []model.BatchOp) ([]error, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	errs := make([]error, len(ops))
+	changes := make([]model.Change, 0, len(ops))
+
+	for i, op := range ops {
+		if _, err := tx.ExecContext(ctx, savepoint()); err != nil {
+			return nil, fmt.Errorf("failed to set savepoint: %w", err)
+		}
+
+		var change model.Change
+
+		switch op.Op {
+		case model.BatchCreate, model.BatchUpdate:
+			change, err = db.putItem(ctx, tx, op.DataType, userID, op.Item)
+		case model.BatchDelete:
+			change, err = deleteItem(ctx, tx, op.DataType, userID, op.ID)
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+
+		if err != nil {
+			errs[i] = err
+
+			if _, err := tx.ExecContext(ctx, rollbackToSavepoint()); err != nil {
+				return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+			}
+
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, releaseSavepoint()); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range changes {
+		db.events.Publish(userID, c)
+	}
+
+	return errs, nil
+}
+
+// putItem adds or updates an item within tx and logs the change.
+func (db *Database) putItem(ctx context.Context, tx *sql.Tx, dataType int, userID string, data any) (model.Change, error) {
+	// Create new item ID using UUID
+	id := uuid.NewString()
+	query := itemInsQuery(dataType)
+	args, err := itemInsArgs(dataType, id, userID, data)
+
+	if err != nil {
+		return model.Change{}, fmt.Errorf("failed to compose args for db query: %w", err)
+	}
+
+	seq, err := nextSeq(ctx, tx, userID)
+	if err != nil {
+		return model.Change{}, err
+	}
+
+	// usage prior to the change, see checkQuotas
+	var before []model.UsageEntry
+
+	if db.quotaItems != 0 || db.quotaBytes != 0 {
+		if before, err = usageEntries(ctx, tx, userID); err != nil {
+			return model.Change{}, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return model.Change{}, fmt.Errorf("data addition query failed: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return model.Change{}, fmt.Errorf("error when finding rows affected %w", err)
+	}
+
+	// Upsert does not update items owned by other users
+	if rowsAffected == 0 {
+		return model.Change{}, fmt.Errorf("%w: item is owned by another user", strgerrors.ErrNotFound)
+	}
+
+	if err = db.checkQuotas(ctx, tx, dataType, userID, before); err != nil {
+		return model.Change{}, err
+	}
+
+	// args start with the ID of the item, see itemInsArgs
+	itemID, _ := args[0].(string)
+
+	return recordChange(ctx, tx, userID, dataType, itemID, seq, false)
+}
+
+// deleteItem deletes an item within tx and leaves a tombstone.
+func deleteItem(ctx context.Context, tx *sql.Tx, dataType int, userID, id string) (model.Change, error) {
+	if dataType < 0 || dataType >= model.KeyLimit {
+		return model.Change{}, fmt.Errorf("unsupported data type %v", dataType)
+	}
+
+	seq, err := nextSeq(ctx, tx, userID)
+	if err != nil {
+		return model.Change{}, err
+	}
+
+	res, err := tx.ExecContext(ctx, delItem(dataType), id, userID)
+	if err != nil {
+		return model.Change{}, fmt.Errorf("data deletion query failed: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return model.Change{}, fmt.Errorf("error when finding rows affected %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.Change{}, strgerrors.ErrNotFound
+	}
+
+	return recordChange(ctx, tx, userID, dataType, id, seq, true)
+}
+
+// Changes returns up to limit changes of user's items
+// committed after the change with sequence number since,
+// in the order they were committed.
+func (db *Database) Changes(ctx context.Context, userID string, since int64, limit int) ([]model.Change, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	// items are loaded from the same snapshot as the change log
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changes, err := loadChanges(ctx, tx, userID, since, limit)
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+
+	until := changes[len(changes)-1].Seq
+
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		data, err := loadItems(ctx, tx, dataType, selChanged(dataType), userID, since, until)
+		if err != nil {
+			return nil, err
+		}
+
+		attachItems(changes, dataType, data)
+	}
+
+	return changes, nil
+}
+
+// nextSeq takes the next change sequence number of the user.
+func nextSeq(ctx context.Context, tx *sql.Tx, userID string) (int64, error) {
+	var seq int64
+
+	err := tx.QueryRowContext(ctx, nextChangeSeq(), userID).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: user does not exist", strgerrors.ErrNotFound)
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to take change number: %w", err)
+	}
+
+	return seq, nil
+}
+
+// recordChange logs the change of an item and returns it.
+func recordChange(ctx context.Context, tx *sql.Tx, userID string, dataType int, itemID string, seq int64, deleted bool) (model.Change, error) {
+	var createdSeq int64
+
+	c := model.Change{Seq: seq, DataType: dataType, ID: itemID}
+
+	err := tx.QueryRowContext(ctx, logChange(), userID, dataType, itemID, seq, deleted).
+		Scan(&createdSeq, &c.ChangedAt)
+	if err != nil {
+		return c, fmt.Errorf("failed to log change: %w", err)
+	}
+
+	switch {
+	case deleted:
+		c.Kind = model.ChangeDeleted
+	case createdSeq == seq:
+		c.Kind = model.ChangeCreated
+	default:
+		c.Kind = model.ChangeUpdated
+	}
+
+	return c, nil
+}
+
+// loadChanges returns a page of change log. Items are to be
+// attached by caller.
+func loadChanges(ctx context.Context, q queryer, userID string, since int64, limit int) ([]model.Change, error) {
+	rows, err := q.QueryContext(ctx, selChanges(), userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute changes query: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]model.Change, 0)
+
+	for rows.Next() {
+		var (
+			c          model.Change
+			createdSeq int64
+			deleted    bool
+		)
+
+		err := rows.Scan(&c.DataType, &c.ID, &c.Seq, &createdSeq, &deleted, &c.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+		}
+
+		switch {
+		case deleted:
+			c.Kind = model.ChangeDeleted
+		case createdSeq > since:
+			c.Kind = model.ChangeCreated
+		default:
+			c.Kind = model.ChangeUpdated
+		}
+
+		res = append(res, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	return res, nil
+}
+
+// attachItems sets items of dataType to the matching changes.
+func attachItems(changes []model.Change, dataType int, data any) {
+	items := make(map[string]any)
+
+	switch v := data.(type) {
+	case []model.ItemCredentials:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	case []model.ItemText:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	case []model.ItemBinary:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	case []model.ItemCard:
+		for _, item := range v {
+			items[item.ID] = item
+		}
+	}
+
+	for i, c := range changes {
+		if c.DataType == dataType && c.Kind != model.ChangeDeleted {
+			changes[i].Item = items[c.ID]
+		}
+	}
+}
+
+// checkQuotas checks the change against configured limits, see
+// quota.Check. Expected to be called within a transaction after the data
+// is modified so the changes are taken into account.
+func (db *Database) checkQuotas(ctx context.Context, q queryer, dataType int, userID string, before []model.UsageEntry) error {
+	if db.quotaItems == 0 && db.quotaBytes == 0 {
+		return nil
+	}
+
+	after, err := usageEntries(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	return quota.Check(before, after, dataType, db.quotaItems, db.quotaBytes)
+}
+
+// Usage returns user's storage consumption by data type
+// along with configured quotas.
+func (db *Database) Usage(ctx context.Context, userID string) (model.Usage, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	entries, err := usageEntries(ctx, db.DB, userID)
+	if err != nil {
+		return model.Usage{}, err
+	}
+
+	res := model.Usage{
+		Entries:    entries,
+		ItemsLimit: db.quotaItems,
+		BytesLimit: db.quotaBytes,
+	}
+
+	for _, e := range entries {
+		res.TotalBytes += e.Bytes
+	}
+
+	return res, nil
+}
+
+func usageEntries(ctx context.Context, q queryer, userID string) ([]model.UsageEntry, error) {
+	rows, err := q.QueryContext(ctx, selUsage(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute usage query: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]model.UsageEntry, 0, model.KeyLimit)
+
+	for rows.Next() {
+		e := model.UsageEntry{}
+		if err := rows.Scan(&e.DataType, &e.Count, &e.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+		}
+
+		res = append(res, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan values from database result: %w", err)
+	}
+
+	return res, nil
+}
+
+func (db *Database) GetCardInfo(ctx context.Context, id, userID string) (model.ItemCard, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, full_number, expires,
+		cardholdername, cardholdersurename,
+		cvvhash, name, comment FROM cards
+		WHERE id = $1 AND user_id = $2`
+
+	res := model.ItemCard{}
+	err := db.QueryRowContext(ctx, query, id, userID).
+		Scan(&res.ID, &res.Number, &res.Exp,
+			&res.CardholderName, &res.CardholderSurename,
+			&res.CVVHash, &res.Name, &res.Comment)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, strgerrors.ErrNotFound
+	} else if err != nil {
+		return res, fmt.Errorf("failed to get card data from Database: %w", err)
+	}
+
+	return res, nil
+}
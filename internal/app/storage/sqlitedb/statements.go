@@ -0,0 +1,396 @@
+package sqlitedb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+func tableName(dataType int) string {
+	switch dataType {
+	case model.KeyCredentials:
+		return "credentials"
+	case model.KeyText:
+		return "text"
+	case model.KeyBinary:
+		return "binarydata"
+	case model.KeyCards:
+		return "cards"
+	}
+
+	return ""
+}
+
+// payloadColumn returns the column that holds
+// the bulk of data type specific item data.
+func payloadColumn(dataType int) string {
+	switch dataType {
+	case model.KeyCredentials:
+		return "encrypted"
+	case model.KeyText:
+		return "text"
+	case model.KeyBinary:
+		return "data"
+	case model.KeyCards:
+		return "full_number"
+	}
+
+	return ""
+}
+
+// selUsage returns a query that counts items and their size
+// in bytes for every data type owned by user.
+// Fields: data_type, count, bytes.
+func selUsage() string {
+	queries := make([]string, 0, model.KeyLimit)
+
+	// length of a text value is measured in characters,
+	// so values are cast to BLOB to get their size in bytes
+	for i := 0; i < model.KeyLimit; i++ {
+		queries = append(queries,
+			fmt.Sprintf(`SELECT %v, COUNT(id),
+				COALESCE(SUM(length(CAST(%v AS BLOB)) + length(CAST(name AS BLOB)) + length(CAST(comment AS BLOB))), 0)
+				FROM %v
+				WHERE user_id = $1`,
+				i, payloadColumn(i), tableName(i)))
+	}
+
+	return strings.Join(queries, " UNION ALL ")
+}
+
+// nextChangeSeq returns a query that takes the next change
+// sequence number of the user.
+func nextChangeSeq() string {
+	return `UPDATE users SET change_seq = change_seq + 1
+		WHERE id = $1
+		RETURNING change_seq`
+}
+
+// logChange returns a query that records the latest change of an item
+// and returns the sequence number it was created with and change time.
+// Args: user_id, data_type, item_id, seq, deleted.
+func logChange() string {
+	return `INSERT INTO changes(
+		user_id, data_type, item_id, seq, created_seq, deleted, ts
+		)
+		VALUES (
+			$1, $2, $3, $4, $4, $5, CURRENT_TIMESTAMP
+			)
+			ON CONFLICT (user_id, data_type, item_id) DO UPDATE SET
+			seq = $4,
+			created_seq = CASE WHEN changes.deleted THEN $4 ELSE changes.created_seq END,
+			deleted = $5,
+			ts = CURRENT_TIMESTAMP
+			RETURNING created_seq, ts`
+}
+
+// savepoint returns a query that marks the state a failed
+// operation of a batch is rolled back to.
+func savepoint() string {
+	return "SAVEPOINT batch_op"
+}
+
+func rollbackToSavepoint() string {
+	return "ROLLBACK TO SAVEPOINT batch_op"
+}
+
+func releaseSavepoint() string {
+	return "RELEASE SAVEPOINT batch_op"
+}
+
+func delItem(dataType int) string {
+	return fmt.Sprintf("DELETE FROM %v WHERE id = $1 AND user_id = $2",
+		tableName(dataType))
+}
+
+// selChanges returns a query that loads a page of user's changes.
+// Args: user_id, since, limit.
+func selChanges() string {
+	return `SELECT data_type, item_id, seq, created_seq, deleted, ts
+		FROM changes
+		WHERE user_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3`
+}
+
+// selChanged returns a query that loads dataType items changed
+// within (since, until] range of sequence numbers.
+// Args: user_id, since, until.
+func selChanged(dataType int) string {
+	return fmt.Sprintf(`%v
+		AND id IN (SELECT item_id FROM changes
+			WHERE user_id = $1 AND data_type = %v AND seq > $2 AND seq <= $3)`,
+		selLoad(dataType), dataType)
+}
+
+func selLoad(dataType int) string {
+	switch dataType {
+	case model.KeyCredentials:
+		return selCredentials()
+	case model.KeyText:
+		return selText()
+	case model.KeyBinary:
+		return selBinary()
+	case model.KeyCards:
+		return selCards()
+	}
+
+	return ""
+}
+
+func selCredentials() string {
+	return `SELECT id, encrypted, name, comment
+		FROM credentials
+		WHERE user_id = $1`
+}
+
+func selText() string {
+	return `SELECT id, text, name, comment, attachments
+		FROM text
+		WHERE user_id = $1`
+}
+
+func selBinary() string {
+	return `SELECT id, data, extention, size, name, comment
+		FROM binarydata
+		WHERE user_id = $1`
+}
+
+func selCards() string {
+	return `SELECT id, number, name, comment
+		FROM cards
+		WHERE user_id = $1`
+}
+
+func itemInsQuery(datatype int) string {
+	switch datatype {
+	case model.KeyCredentials:
+		return insCredentials()
+	case model.KeyText:
+		return insText()
+	case model.KeyBinary:
+		return insBinary()
+	case model.KeyCards:
+		return insCard()
+	}
+
+	return ""
+}
+
+// itemInsArgs returns slice of arguments that matches
+// datatype-specific query
+func itemInsArgs(datatype int, id, userID string, data any) ([]any, error) {
+	switch datatype {
+	case model.KeyCredentials:
+		item, err := assertItem[model.ItemCredentials](data)
+		if err != nil {
+			return nil, err
+		}
+
+		return argsCredentials(id, userID, item)
+	case model.KeyText:
+		item, err := assertItem[model.ItemText](data)
+		if err != nil {
+			return nil, err
+		}
+
+		return argsText(id, userID, item)
+	case model.KeyBinary:
+		item, err := assertItem[model.ItemBinary](data)
+		if err != nil {
+			return nil, err
+		}
+
+		return argsBinary(id, userID, item)
+	case model.KeyCards:
+		item, err := assertItem[model.ItemCard](data)
+		if err != nil {
+			return nil, err
+		}
+
+		return argsCard(id, userID, item)
+	}
+
+	return nil, fmt.Errorf("unsupported data type %v", datatype)
+}
+
+func assertItem[T model.Item](data any) (T, error) {
+	val, ok := data.(T)
+	if !ok {
+		return val, fmt.Errorf("data type mismatch actual data type")
+	}
+
+	return val, nil
+}
+
+func insCredentials() string {
+	return `INSERT INTO credentials(
+		id, user_id, ts, encrypted, name, comment
+		)
+		VALUES (
+			$1, $2, CURRENT_TIMESTAMP, $3, $4, $5
+			)
+			ON CONFLICT (id) DO UPDATE SET
+			encrypted=$3,
+			name=$4,
+			comment=$5
+			WHERE credentials.user_id = $2`
+}
+
+// argsCredentials returns slice of args required
+// by query. See insCredentials.
+func argsCredentials(id, userID string, item model.ItemCredentials) ([]any, error) {
+	encrypted, err := encryptCred(item.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.ID != "" {
+		id = item.ID
+	}
+
+	return []any{id, userID, encrypted, item.Name, item.Comment}, nil
+}
+
+func insText() string {
+	return `INSERT INTO text(
+		id, user_id, ts, text, name, comment, attachments
+		)
+		VALUES (
+			$1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6
+			)
+			ON CONFLICT (id) DO UPDATE SET
+			text=$3,
+			name=$4,
+			comment=$5,
+			attachments=$6
+			WHERE text.user_id = $2`
+}
+
+// argsText returns slice of args required
+// by query. See insText.
+func argsText(id, userID string, item model.ItemText) ([]any, error) {
+	if item.ID != "" {
+		id = item.ID
+	}
+
+	attachments, err := encodeAttachments(item.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{id, userID, []byte(item.Text), item.Name, item.Comment, attachments}, nil
+}
+
+// encodeAttachments returns IDs of attachments as they are kept
+// in text table, see decodeAttachments.
+func encodeAttachments(ids []string) (string, error) {
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode attachments: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// decodeAttachments returns IDs of attachments kept in text table
+// as a JSON array, empty value means there are none.
+func decodeAttachments(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal([]byte(s), &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+
+	return ids, nil
+}
+
+func insCard() string {
+	return `INSERT INTO cards(
+		id, user_id, ts, number, full_number, cvvhash, expires,
+		name, comment,
+		cardholdername, cardholdersurename
+		)
+		VALUES (
+			$1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6, $7, $8, $9, $10
+			)
+			ON CONFLICT (id) DO UPDATE SET
+			number = $3,
+			full_number = $4,
+			cvvhash=$5,
+			expires=$6,
+			name=$7,
+			comment=$8,
+			cardholdername=$9,
+			cardholdersurename=$10
+			WHERE cards.user_id = $2`
+}
+
+// argsCard returns slice of args required
+// by query. See insCard.
+func argsCard(id, userID string, item model.ItemCard) ([]any, error) {
+	if len(item.Number) != 16 {
+		return nil, fmt.Errorf("card number must be 16 digits long")
+	}
+
+	// Replace 8 middle charachters with *
+	number := item.Number[:4] + strings.Repeat("*", 8) + item.Number[12:]
+
+	if item.ID != "" {
+		id = item.ID
+	}
+
+	return []any{
+		id,
+		userID,
+		number,
+		item.Number,
+		item.CVVHash,
+		item.Exp,
+		item.Name,
+		item.Comment,
+		item.CardholderName,
+		item.CardholderSurename,
+	}, nil
+}
+
+func insBinary() string {
+	return `INSERT INTO binarydata(
+		id, user_id, ts, data, extention, size, name, comment
+		)
+		VALUES (
+			$1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6, $7
+			)
+			ON CONFLICT (id) DO UPDATE SET
+			data=$3,
+			extention=$4,
+			size=$5,
+			name=$6,
+			comment=$7
+			WHERE binarydata.user_id = $2`
+}
+
+// argsBinary returns slice of args required
+// by query. See insBinary.
+func argsBinary(id, userID string, item model.ItemBinary) ([]any, error) {
+	data, err := base64.StdEncoding.DecodeString(item.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.ID != "" {
+		id = item.ID
+	}
+
+	return []any{id, userID, data, []byte(item.Extention), item.Size, item.Name, item.Comment}, nil
+}
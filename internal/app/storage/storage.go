@@ -2,9 +2,17 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
 
 	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	"github.com/usa4ev/ghostorange/internal/app/storage/memdb"
+	"github.com/usa4ev/ghostorange/internal/app/storage/migrate"
 	"github.com/usa4ev/ghostorange/internal/app/storage/psqldb"
+	"github.com/usa4ev/ghostorange/internal/app/storage/sqlitedb"
 )
 
 type (
@@ -14,15 +22,77 @@ type (
 		UserExists(ctx context.Context, username string) (bool, error)
 
 		Count(ctx context.Context, dataType int, user string) (int, error)
+		TotalCount(ctx context.Context, dataType int) (int, error)
 		GetData(ctx context.Context, dataType int) (any, error)
 		AddData(ctx context.Context, dataType int, userID string, data any) error
+		DeleteData(ctx context.Context, dataType int, userID, id string) error
+		// Batch applies ops of user in one transaction. An operation
+		// that fails is rolled back alone and its error is returned at
+		// its index, the rest are committed together. Returned error
+		// means none of ops have been applied.
+		Batch(ctx context.Context, userID string, ops []model.BatchOp) ([]error, error)
 		GetCardInfo(ctx context.Context, id, userID string) (model.ItemCard, error)
+		Usage(ctx context.Context, userID string) (model.Usage, error)
+
+		// Changes returns up to limit changes of user's items made
+		// after the change with sequence number since, oldest first.
+		Changes(ctx context.Context, userID string, since int64, limit int) ([]model.Change, error)
+
+		// Subscribe returns a channel that receives changes of user's
+		// items once they are committed. The channel is closed when ctx
+		// is done or the subscriber falls behind.
+		Subscribe(ctx context.Context, userID string) (<-chan model.Change, error)
+
+		// Ready returns nil if storage is reachable
+		// and its schema is up to date.
+		Ready(ctx context.Context) error
+
+		// Close releases storage resources, e.g. connection pool.
+		Close() error
+	}
+
+	// Migrator manages storage schema versions.
+	Migrator interface {
+		MigrateUp(ctx context.Context) ([]int, error)
+		MigrateDown(ctx context.Context) (int, error)
+		MigrationStatus(ctx context.Context) ([]migrate.Status, error)
+		Close() error
 	}
+
 	config interface {
 		DBDSN() string
+		DBDriver() string
+		QuotaItems() int
+		QuotaBytes() int64
+		DBMaxOpenConns() int
+		DBMaxIdleConns() int
+		DBConnMaxLifetime() time.Duration
+		DBQueryTimeout() time.Duration
 	}
 )
 
-func New(cfg config) (Storage, error) {
-	return psqldb.New(cfg.DBDSN())
+// New returns Storage implementation selected by DSN scheme,
+// see srvconfig.Config.DBDriver.
+func New(cfg config, logger *zap.Logger) (Storage, error) {
+	switch cfg.DBDriver() {
+	case srvconfig.DriverSQLite:
+		return sqlitedb.New(cfg)
+	case srvconfig.DriverMemory:
+		return memdb.New(cfg), nil
+	}
+
+	return psqldb.New(cfg, logger)
+}
+
+// NewMigrator returns Migrator for configured storage.
+// Unlike New it does not touch storage schema.
+func NewMigrator(cfg config) (Migrator, error) {
+	switch cfg.DBDriver() {
+	case srvconfig.DriverSQLite:
+		return sqlitedb.Open(cfg)
+	case srvconfig.DriverMemory:
+		return nil, fmt.Errorf("in-memory storage has no schema to migrate")
+	}
+
+	return psqldb.Open(cfg)
 }
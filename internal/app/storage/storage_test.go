@@ -0,0 +1,68 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/usa4ev/ghostorange/internal/app/srvconfig"
+	"github.com/usa4ev/ghostorange/internal/app/storage"
+	"github.com/usa4ev/ghostorange/internal/app/storage/storagetest"
+)
+
+// TestConformance runs the conformance suite against every backend.
+// PostgreSQL is tested if TEST_DATABASE_DSN is set.
+func TestConformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		storagetest.Run(t, func(t *testing.T, opts storagetest.Options) storage.Storage {
+			return newStorage(t, srvconfig.SchemeMemory, opts)
+		})
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		// database files by test, so that storages of a test share data
+		paths := make(map[string]string)
+
+		storagetest.Run(t, func(t *testing.T, opts storagetest.Options) storage.Storage {
+			path, ok := paths[t.Name()]
+			if !ok {
+				path = filepath.Join(t.TempDir(), "test.db")
+				paths[t.Name()] = path
+			}
+
+			return newStorage(t, srvconfig.SchemeSQLite+path, opts)
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_DSN")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_DSN is not set")
+		}
+
+		storagetest.Run(t, func(t *testing.T, opts storagetest.Options) storage.Storage {
+			return newStorage(t, dsn, opts)
+		})
+	})
+}
+
+func newStorage(t *testing.T, dsn string, opts storagetest.Options) storage.Storage {
+	t.Helper()
+
+	cfg := srvconfig.New(srvconfig.IgnoreOsArgs(), srvconfig.WithEnvVars(map[string]string{
+		"DATABASE_DSN": dsn,
+		"QUOTA_ITEMS":  strconv.Itoa(opts.QuotaItems),
+		"QUOTA_BYTES":  strconv.FormatInt(opts.QuotaBytes, 10),
+	}))
+
+	s, err := storage.New(cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
@@ -0,0 +1,769 @@
+// Package storagetest provides a conformance test suite
+// every storage.Storage implementation is expected to pass.
+package storagetest
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/auth/session"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/storage"
+	"github.com/usa4ev/ghostorange/internal/app/storage/strgerrors"
+)
+
+type (
+	// Options holds storage settings a test requires.
+	Options struct {
+		QuotaItems int
+		QuotaBytes int64
+	}
+
+	// Factory returns a ready to use storage configured with opts.
+	// Storages may share data with each other: every test registers
+	// its own users and does not rely on storage being empty.
+	// Storages returned within one test are expected to share data,
+	// as if the server was restarted, if the backend keeps it.
+	Factory func(t *testing.T, opts Options) storage.Storage
+)
+
+// Run runs the conformance suite against storages returned by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, newStorage Factory)
+	}{
+		{"Users", testUsers},
+		{"AddGetData", testAddGetData},
+		{"Upsert", testUpsert},
+		{"Attachments", testAttachments},
+		{"DeleteData", testDeleteData},
+		{"Batch", testBatch},
+		{"Changes", testChanges},
+		{"ChangesPaging", testChangesPaging},
+		{"Subscribe", testSubscribe},
+		{"OwnerIsolation", testOwnerIsolation},
+		{"CardMasking", testCardMasking},
+		{"CredentialsEncryption", testCredentialsEncryption},
+		{"Count", testCount},
+		{"TotalCount", testTotalCount},
+		{"GetCardInfo", testGetCardInfo},
+		{"Usage", testUsage},
+		{"QuotaItems", testQuotaItems},
+		{"QuotaBytes", testQuotaBytes},
+		{"QuotaLowered", testQuotaLowered},
+		{"Ready", testReady},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage)
+		})
+	}
+}
+
+func testUsers(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	ctx := context.Background()
+	username := uuid.NewString()
+
+	exists, err := s.UserExists(ctx, username)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	userID, hash, err := s.GetPasswordHash(ctx, username)
+	require.NoError(t, err)
+	assert.Empty(t, userID, "unknown user must have empty ID")
+	assert.Empty(t, hash)
+
+	userID, err = s.AddUser(ctx, username, "hash")
+	require.NoError(t, err)
+	assert.NotEmpty(t, userID)
+
+	exists, err = s.UserExists(ctx, username)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	gotID, hash, err := s.GetPasswordHash(ctx, username)
+	require.NoError(t, err)
+	assert.Equal(t, userID, gotID)
+	assert.Equal(t, "hash", hash)
+}
+
+func testAddGetData(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		t.Run(model.GetItemTitle(dataType), func(t *testing.T) {
+			require.NoError(t, s.AddData(ctx, dataType, userID, SampleItem(dataType)))
+
+			data, err := s.GetData(ctx, dataType)
+			require.NoError(t, err)
+
+			switch items := data.(type) {
+			case []model.ItemCredentials:
+				require.Len(t, items, 1)
+				assert.NotEmpty(t, items[0].ID)
+				items[0].ID = ""
+				assert.Equal(t, SampleItem(dataType), items[0])
+			case []model.ItemText:
+				require.Len(t, items, 1)
+				assert.NotEmpty(t, items[0].ID)
+				items[0].ID = ""
+				assert.Equal(t, SampleItem(dataType), items[0])
+			case []model.ItemBinary:
+				require.Len(t, items, 1)
+				assert.NotEmpty(t, items[0].ID)
+				items[0].ID = ""
+				assert.Equal(t, SampleItem(dataType), items[0])
+			case []model.ItemCard:
+				// full card info is checked by GetCardInfo test
+				require.Len(t, items, 1)
+				assert.NotEmpty(t, items[0].ID)
+				assert.Equal(t, "card", items[0].Name)
+			default:
+				t.Fatalf("unexpected type %T of data type %v", data, dataType)
+			}
+		})
+	}
+}
+
+func testUpsert(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, SampleItem(model.KeyText)))
+
+	items := getItems[model.ItemText](t, s, userID, model.KeyText)
+	require.Len(t, items, 1)
+
+	updated := model.ItemText{ID: items[0].ID, Text: "updated", Name: "new name", Comment: "new comment"}
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, updated))
+
+	items = getItems[model.ItemText](t, s, userID, model.KeyText)
+	require.Len(t, items, 1, "item with known ID must be updated, not added")
+	assert.Equal(t, updated, items[0])
+
+	n, err := s.Count(ctx, model.KeyText, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// unknown ID set by client is used for a new item
+	id := uuid.NewString()
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, model.ItemText{ID: id, Text: "new"}))
+
+	items = getItems[model.ItemText](t, s, userID, model.KeyText)
+	require.Len(t, items, 2)
+	assert.Contains(t, items, model.ItemText{ID: id, Text: "new"})
+}
+
+func testAttachments(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	note := model.ItemText{ID: uuid.NewString(), Text: "see attached", Attachments: []string{"a", "b"}}
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, note))
+
+	note.Attachments[0] = "changed"
+
+	items := getItems[model.ItemText](t, s, userID, model.KeyText)
+	require.Len(t, items, 1)
+	assert.Equal(t, []string{"a", "b"}, items[0].Attachments)
+
+	note.Attachments = nil
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, note))
+
+	items = getItems[model.ItemText](t, s, userID, model.KeyText)
+	require.Len(t, items, 1)
+	assert.Equal(t, note, items[0], "attachments are unlinked")
+}
+
+func testDeleteData(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	owner, other := newUser(t, s), newUser(t, s)
+
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		require.NoError(t, s.AddData(UserContext(owner), dataType, owner, SampleItem(dataType)))
+	}
+
+	texts := getItems[model.ItemText](t, s, owner, model.KeyText)
+	require.Len(t, texts, 1)
+	id := texts[0].ID
+
+	err := s.DeleteData(UserContext(other), model.KeyText, other, id)
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound, "item of another user is deleted")
+
+	err = s.DeleteData(UserContext(owner), model.KeyBinary, owner, id)
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound, "item of another data type is deleted")
+
+	require.NoError(t, s.DeleteData(UserContext(owner), model.KeyText, owner, id))
+	assert.Empty(t, getItems[model.ItemText](t, s, owner, model.KeyText))
+
+	err = s.DeleteData(UserContext(owner), model.KeyText, owner, id)
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound)
+
+	// a card is deleted along with its full info
+	id = cardID(t, s, owner)
+	require.NoError(t, s.DeleteData(UserContext(owner), model.KeyCards, owner, id))
+
+	_, err = s.GetCardInfo(UserContext(owner), id, owner)
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound)
+}
+
+func testBatch(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{QuotaItems: 2})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	require.NoError(t, s.AddData(ctx, model.KeyCredentials, userID, SampleItem(model.KeyCredentials)))
+	creds := getItems[model.ItemCredentials](t, s, userID, model.KeyCredentials)
+	require.Len(t, creds, 1)
+
+	changes, err := s.Changes(ctx, userID, 0, 100)
+	require.NoError(t, err)
+
+	since := changes[len(changes)-1].Seq
+
+	updated := creds[0]
+	updated.Name = "updated"
+
+	first, second := uuid.NewString(), uuid.NewString()
+
+	errs, err := s.Batch(ctx, userID, []model.BatchOp{
+		{Op: model.BatchCreate, DataType: model.KeyText, Item: model.ItemText{ID: first, Text: "first"}},
+		{Op: model.BatchDelete, DataType: model.KeyText, ID: "missing"},
+		{Op: model.BatchCreate, DataType: model.KeyText, Item: model.ItemText{ID: second, Text: "second"}},
+		{Op: model.BatchCreate, DataType: model.KeyText, Item: model.ItemText{ID: uuid.NewString(), Text: "third"}},
+		{Op: model.BatchUpdate, DataType: model.KeyCredentials, Item: updated},
+		{Op: model.BatchDelete, DataType: model.KeyText, ID: first},
+	})
+	require.NoError(t, err)
+	require.Len(t, errs, 6)
+
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], strgerrors.ErrNotFound)
+	assert.NoError(t, errs[2])
+	assert.ErrorIs(t, errs[3], strgerrors.ErrQuotaExceeded, "quota counts items of the batch")
+	assert.NoError(t, errs[4])
+	assert.NoError(t, errs[5])
+
+	assert.Equal(t, []model.ItemText{{ID: second, Text: "second"}},
+		getItems[model.ItemText](t, s, userID, model.KeyText), "failed operations are rolled back alone")
+	assert.Equal(t, []model.ItemCredentials{updated},
+		getItems[model.ItemCredentials](t, s, userID, model.KeyCredentials))
+
+	// failed operations leave no trace in the change log,
+	// the log keeps the latest change of every item
+	changes, err = s.Changes(ctx, userID, since, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, since+4, changes[2].Seq, "sequence numbers of failed operations are not taken")
+	assert.Equal(t, model.ChangeDeleted, changes[2].Kind)
+
+	// an item of another user is neither updated nor deleted
+	other := newUser(t, s)
+
+	errs, err = s.Batch(UserContext(other), other, []model.BatchOp{
+		{Op: model.BatchUpdate, DataType: model.KeyCredentials, Item: model.ItemCredentials{ID: updated.ID}},
+		{Op: model.BatchDelete, DataType: model.KeyText, ID: second},
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, errs[0], strgerrors.ErrNotFound)
+	assert.ErrorIs(t, errs[1], strgerrors.ErrNotFound)
+	assert.Len(t, getItems[model.ItemText](t, s, userID, model.KeyText), 1)
+}
+
+func testChanges(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID, other := newUser(t, s), newUser(t, s)
+	ctx := UserContext(userID)
+
+	changes, err := s.Changes(ctx, userID, 0, 100)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	text := model.ItemText{ID: uuid.NewString(), Text: "v1", Name: "text"}
+	card := SampleItem(model.KeyCards).(model.ItemCard)
+	card.ID = uuid.NewString()
+
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, text))
+	require.NoError(t, s.AddData(ctx, model.KeyCards, userID, card))
+	require.NoError(t, s.AddData(UserContext(other), model.KeyText, other, SampleItem(model.KeyText)))
+
+	changes, err = s.Changes(ctx, userID, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 2, "changes of another user are returned")
+
+	assert.Equal(t, model.KeyText, changes[0].DataType)
+	assert.Equal(t, text.ID, changes[0].ID)
+	assert.Equal(t, model.ChangeCreated, changes[0].Kind)
+	assert.Equal(t, text, changes[0].Item)
+	assert.False(t, changes[0].ChangedAt.IsZero())
+
+	assert.Equal(t, card.ID, changes[1].ID)
+	assert.Greater(t, changes[1].Seq, changes[0].Seq)
+	assert.Equal(t, "4111********1111", changes[1].Item.(model.ItemCard).Number,
+		"changes must not reveal full card number")
+
+	since := changes[1].Seq
+
+	text.Text = "v2"
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, text))
+	require.NoError(t, s.DeleteData(ctx, model.KeyCards, userID, card.ID))
+
+	changes, err = s.Changes(ctx, userID, since, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+
+	assert.Equal(t, model.ChangeUpdated, changes[0].Kind)
+	assert.Equal(t, text, changes[0].Item)
+
+	assert.Equal(t, card.ID, changes[1].ID)
+	assert.Equal(t, model.ChangeDeleted, changes[1].Kind)
+	assert.Nil(t, changes[1].Item, "tombstone must not carry item data")
+
+	// only the latest change of an item is kept
+	changes, err = s.Changes(ctx, userID, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, model.ChangeCreated, changes[0].Kind,
+		"item created after the cursor is reported as created")
+	assert.Equal(t, model.ChangeDeleted, changes[1].Kind)
+}
+
+func testChangesPaging(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	ids := make([]string, 5)
+	for i := range ids {
+		ids[i] = uuid.NewString()
+		require.NoError(t, s.AddData(ctx, model.KeyText, userID, model.ItemText{ID: ids[i]}))
+	}
+
+	var (
+		got   []string
+		since int64
+	)
+
+	for {
+		changes, err := s.Changes(ctx, userID, since, 2)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(changes), 2)
+
+		if len(changes) == 0 {
+			break
+		}
+
+		for _, c := range changes {
+			got = append(got, c.ID)
+		}
+
+		since = changes[len(changes)-1].Seq
+	}
+
+	assert.Equal(t, ids, got)
+}
+
+func testSubscribe(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID, other := newUser(t, s), newUser(t, s)
+	ctx := UserContext(userID)
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := s.Subscribe(subCtx, userID)
+	require.NoError(t, err)
+
+	// changes might be delivered asynchronously, so the first one is
+	// repeated until it arrives in case the storage is not listening yet
+	var got model.Change
+
+	text := model.ItemText{ID: uuid.NewString(), Text: "text"}
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, s.AddData(ctx, model.KeyText, userID, text))
+
+		select {
+		case got = <-events:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, time.Millisecond)
+
+	assert.Equal(t, model.KeyText, got.DataType)
+	assert.Equal(t, text.ID, got.ID)
+	assert.Contains(t, []string{model.ChangeCreated, model.ChangeUpdated}, got.Kind)
+	assert.Nil(t, got.Item, "events must not carry item data")
+
+	require.NoError(t, s.AddData(UserContext(other), model.KeyText, other, SampleItem(model.KeyText)))
+	require.NoError(t, s.DeleteData(ctx, model.KeyText, userID, text.ID))
+
+	// the rest of attempts are delivered before the deletion
+	for got.Kind != model.ChangeDeleted {
+		select {
+		case got = <-events:
+			require.Equal(t, text.ID, got.ID, "changes of another user are received")
+		case <-time.After(5 * time.Second):
+			t.Fatal("deletion is not received")
+		}
+	}
+
+	assert.False(t, got.ChangedAt.IsZero())
+
+	cancel()
+
+	require.Eventually(t, func() bool {
+		_, ok := <-events
+		return !ok
+	}, 5*time.Second, time.Millisecond, "channel must be closed once ctx is done")
+}
+
+func testOwnerIsolation(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	owner, other := newUser(t, s), newUser(t, s)
+
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		require.NoError(t, s.AddData(UserContext(owner), dataType, owner, SampleItem(dataType)))
+
+		n, err := s.Count(UserContext(other), dataType, other)
+		require.NoError(t, err)
+		assert.Zero(t, n, "%v of another user are counted", model.GetItemTitle(dataType))
+
+		data, err := s.GetData(UserContext(other), dataType)
+		require.NoError(t, err)
+		assert.Zero(t, reflect.ValueOf(data).Len(),
+			"%v of another user are returned", model.GetItemTitle(dataType))
+	}
+
+	id := cardID(t, s, owner)
+
+	_, err := s.GetCardInfo(UserContext(other), id, other)
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound, "card of another user is revealed")
+
+	// an item can not be overwritten by another user even if its ID is known
+	texts := getItems[model.ItemText](t, s, owner, model.KeyText)
+	require.Len(t, texts, 1)
+
+	err = s.AddData(UserContext(other), model.KeyText, other,
+		model.ItemText{ID: texts[0].ID, Text: "hijacked"})
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound)
+
+	texts = getItems[model.ItemText](t, s, owner, model.KeyText)
+	require.Len(t, texts, 1)
+	assert.Equal(t, SampleItem(model.KeyText).(model.ItemText).Text, texts[0].Text)
+
+	n, err := s.Count(UserContext(other), model.KeyText, other)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func testCardMasking(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	card := SampleItem(model.KeyCards).(model.ItemCard)
+	require.NoError(t, s.AddData(ctx, model.KeyCards, userID, card))
+
+	cards := getItems[model.ItemCard](t, s, userID, model.KeyCards)
+	require.Len(t, cards, 1)
+
+	assert.Equal(t, "4111********1111", cards[0].Number)
+	assert.Empty(t, cards[0].CVVHash, "card list must not reveal CVV hash")
+	assert.Empty(t, cards[0].CardholderName, "card list must not reveal cardholder")
+	assert.True(t, cards[0].Exp.IsZero(), "card list must not reveal expiration date")
+
+	got, err := s.GetCardInfo(ctx, cards[0].ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, card.Number, got.Number)
+}
+
+func testCredentialsEncryption(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	creds := []model.Credentials{
+		{Login: "login", Password: "p@ssw0rd"},
+		{Login: "юзер", Password: "пароль 🔑 \"quoted\" \n"},
+		{Login: "", Password: ""},
+		{Login: "long", Password: strings.Repeat("x", 4096)},
+	}
+
+	for i, c := range creds {
+		require.NoError(t, s.AddData(ctx, model.KeyCredentials, userID,
+			model.ItemCredentials{ID: uuid.NewString(), Credentials: c, Name: strconv.Itoa(i)}))
+	}
+
+	items := getItems[model.ItemCredentials](t, s, userID, model.KeyCredentials)
+	require.Len(t, items, len(creds))
+
+	for _, item := range items {
+		i, err := strconv.Atoi(item.Name)
+		require.NoError(t, err)
+		assert.Equal(t, creds[i], item.Credentials)
+	}
+}
+
+func testCount(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	for dataType := 0; dataType < model.KeyLimit; dataType++ {
+		n, err := s.Count(ctx, dataType, userID)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.AddData(ctx, model.KeyText, userID, SampleItem(model.KeyText)))
+	}
+
+	n, err := s.Count(ctx, model.KeyText, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = s.Count(ctx, model.KeyBinary, userID)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func testTotalCount(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	ctx := context.Background()
+
+	before, err := s.TotalCount(ctx, model.KeyText)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		userID := newUser(t, s)
+		require.NoError(t, s.AddData(ctx, model.KeyText, userID, SampleItem(model.KeyText)))
+	}
+
+	after, err := s.TotalCount(ctx, model.KeyText)
+	require.NoError(t, err)
+	assert.Equal(t, before+2, after)
+}
+
+func testGetCardInfo(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	_, err := s.GetCardInfo(ctx, uuid.NewString(), userID)
+	assert.ErrorIs(t, err, strgerrors.ErrNotFound)
+
+	want := SampleItem(model.KeyCards).(model.ItemCard)
+	require.NoError(t, s.AddData(ctx, model.KeyCards, userID, want))
+
+	id := cardID(t, s, userID)
+
+	got, err := s.GetCardInfo(ctx, id, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, id, got.ID)
+	assert.Equal(t, want.Number, got.Number)
+	assert.True(t, want.Exp.Equal(got.Exp), "expiration date %v, want %v", got.Exp, want.Exp)
+	assert.Equal(t, want.CardholderName, got.CardholderName)
+	assert.Equal(t, want.CardholderSurename, got.CardholderSurename)
+	assert.Equal(t, want.CVVHash, got.CVVHash)
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.Comment, got.Comment)
+}
+
+func testUsage(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{QuotaItems: 10, QuotaBytes: 1000})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	item := model.ItemText{Text: "hello", Name: "n", Comment: "c"}
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, item))
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, item))
+
+	usage, err := s.Usage(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, usage.ItemsLimit)
+	assert.Equal(t, int64(1000), usage.BytesLimit)
+	assert.Equal(t, int64(14), usage.TotalBytes)
+
+	for _, e := range usage.Entries {
+		if e.DataType == model.KeyText {
+			assert.Equal(t, 2, e.Count)
+			assert.Equal(t, int64(14), e.Bytes)
+		} else {
+			assert.Zero(t, e.Count, "data type %v", e.DataType)
+		}
+	}
+}
+
+func testQuotaItems(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{QuotaItems: 1})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID, SampleItem(model.KeyText)))
+
+	err := s.AddData(ctx, model.KeyText, userID, SampleItem(model.KeyText))
+	assert.ErrorIs(t, err, strgerrors.ErrQuotaExceeded)
+
+	n, err := s.Count(ctx, model.KeyText, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "item exceeding quota must not be stored")
+
+	// the quota applies to every data type separately
+	assert.NoError(t, s.AddData(ctx, model.KeyBinary, userID, SampleItem(model.KeyBinary)))
+}
+
+func testQuotaBytes(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{QuotaBytes: 100})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	require.NoError(t, s.AddData(ctx, model.KeyText, userID,
+		model.ItemText{Text: strings.Repeat("a", 50)}))
+
+	err := s.AddData(ctx, model.KeyText, userID,
+		model.ItemText{Text: strings.Repeat("a", 51)})
+	assert.ErrorIs(t, err, strgerrors.ErrQuotaExceeded)
+
+	usage, err := s.Usage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), usage.TotalBytes)
+}
+
+func testQuotaLowered(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+	userID := newUser(t, s)
+	ctx := UserContext(userID)
+
+	for _, text := range []string{strings.Repeat("a", 100), "b"} {
+		require.NoError(t, s.AddData(ctx, model.KeyText, userID, model.ItemText{Text: text}))
+	}
+
+	// restarted with limits below the data already stored
+	s = newStorage(t, Options{QuotaItems: 1, QuotaBytes: 50})
+
+	items := getItems[model.ItemText](t, s, userID, model.KeyText)
+	if len(items) == 0 {
+		t.Skip("storage does not keep data across restarts")
+	}
+
+	require.Len(t, items, 2)
+
+	big := items[0]
+	if big.Text == "b" {
+		big = items[1]
+	}
+
+	big.Text = strings.Repeat("a", 60)
+	assert.NoError(t, s.AddData(ctx, model.KeyText, userID, big), "shrinking an item over quota")
+
+	big.Text = strings.Repeat("a", 70)
+	err := s.AddData(ctx, model.KeyText, userID, big)
+	assert.ErrorIs(t, err, strgerrors.ErrQuotaExceeded, "growing an item over quota")
+
+	err = s.AddData(ctx, model.KeyText, userID, model.ItemText{Text: "c"})
+	assert.ErrorIs(t, err, strgerrors.ErrQuotaExceeded, "adding an item over quota")
+
+	usage, err := s.Usage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(61), usage.TotalBytes)
+}
+
+func testReady(t *testing.T, newStorage Factory) {
+	s := newStorage(t, Options{})
+
+	assert.NoError(t, s.Ready(context.Background()))
+}
+
+// UserContext returns context that carries userID
+// the same way authorisation middleware does.
+func UserContext(userID string) context.Context {
+	return context.WithValue(context.Background(), session.CtxKeyUserID, userID)
+}
+
+// SampleItem returns an item of dataType without ID.
+func SampleItem(dataType int) any {
+	switch dataType {
+	case model.KeyCredentials:
+		return model.ItemCredentials{
+			Credentials: model.Credentials{Login: "login", Password: "p@ssw0rd"},
+			Name:        "credentials",
+			Comment:     "comment",
+		}
+	case model.KeyText:
+		return model.ItemText{Text: "some text", Name: "text", Comment: "comment"}
+	case model.KeyBinary:
+		return model.ItemBinary{
+			Size:      4,
+			Extention: "bin",
+			Data:      base64.StdEncoding.EncodeToString([]byte{0, 1, 2, 255}),
+			Name:      "binary",
+			Comment:   "comment",
+		}
+	case model.KeyCards:
+		return model.ItemCard{
+			Number:             "4111111111111111",
+			Exp:                time.Date(2030, time.May, 1, 0, 0, 0, 0, time.UTC),
+			CardholderName:     "John",
+			CardholderSurename: "Doe",
+			CVVHash:            "cvvhash",
+			Name:               "card",
+			Comment:            "comment",
+		}
+	}
+
+	return nil
+}
+
+// getItems returns items of dataType owned by user.
+func getItems[T model.Item](t *testing.T, s storage.Storage, userID string, dataType int) []T {
+	t.Helper()
+
+	data, err := s.GetData(UserContext(userID), dataType)
+	require.NoError(t, err)
+
+	items, ok := data.([]T)
+	require.True(t, ok, "unexpected type %T", data)
+
+	return items
+}
+
+// newUser registers a user with a unique name and returns its ID.
+func newUser(t *testing.T, s storage.Storage) string {
+	t.Helper()
+
+	userID, err := s.AddUser(context.Background(), uuid.NewString(), "hash")
+	require.NoError(t, err)
+
+	return userID
+}
+
+// cardID returns ID of the only card owned by user.
+func cardID(t *testing.T, s storage.Storage, userID string) string {
+	t.Helper()
+
+	cards := getItems[model.ItemCard](t, s, userID, model.KeyCards)
+	require.Len(t, cards, 1)
+
+	return cards[0].ID
+}
@@ -0,0 +1,9 @@
+// Package strgerrors contains errors shared by storage implementations.
+package strgerrors
+
+import "fmt"
+
+var (
+	ErrQuotaExceeded = fmt.Errorf("storage quota exceeded")
+	ErrNotFound      = fmt.Errorf("item not found")
+)
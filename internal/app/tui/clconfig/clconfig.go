@@ -1,49 +1,208 @@
 package clconfig
 
 import (
+	"crypto/tls"
 	"flag"
 	"os"
+	"path/filepath"
+	"time"
 )
 
-type Config struct{
-	srvAddr string
-	logPath string
+// Transports the client talks to the server with.
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
+// Environment variables the client is configured with.
+const (
+	EnvConfig    = "GHOSTORANGE_CONFIG"
+	EnvProfile   = "GHOSTORANGE_PROFILE"
+	EnvAddress   = "GHOSTORANGE_ADDRESS"
+	EnvTransport = "GHOSTORANGE_TRANSPORT"
+	EnvLogLevel  = "GHOSTORANGE_LOG_LEVEL"
+)
+
+type Config struct {
+	srvAddr   string
+	logPath   string
+	logLevel  string
+	cacheDir  string
+	transport string
+	// clipTimeout is the time copied secrets
+	// stay in the clipboard
+	clipTimeout time.Duration
+	// lockTimeout is the time of inactivity
+	// the client is locked after
+	lockTimeout time.Duration
+	tls         *tls.Config
+	profile     string
+	args        []string
+
+	// src is kept to switch profiles
+	src *sources
 }
 
 type (
-	option func(*Config)
+	option func(*sources)
 )
 
-func WithAddress(addr string)option{
-	return func(c *Config){
-		c.srvAddr = addr
+func WithAddress(addr string) option {
+	return func(s *sources) {
+		s.opts.srvAddr = addr
 	}
 }
 
-func New(opts... option)*Config{
+// WithCacheDir sets the directory to keep local cache in
+func WithCacheDir(dir string) option {
+	return func(s *sources) {
+		s.opts.cacheDir = dir
+	}
+}
+
+// WithTransport sets the transport to talk to the server with
+func WithTransport(transport string) option {
+	return func(s *sources) {
+		s.opts.transport = transport
+	}
+}
+
+// WithFile sets the path of config file
+func WithFile(path string) option {
+	return func(s *sources) {
+		s.filePath = path
+		s.fileSet = true
+	}
+}
 
-	c:= Config{}
+// WithProfile selects the profile of config file
+func WithProfile(name string) option {
+	return func(s *sources) {
+		s.opts.profile = name
+	}
+}
+
+// WithOsArgs replaces command line arguments
+func WithOsArgs(args []string) option {
+	return func(s *sources) {
+		s.osArgs = args
+	}
+}
+
+// WithEnvVars replaces environment variables
+func WithEnvVars(vars map[string]string) option {
+	return func(s *sources) {
+		s.env = vars
+	}
+}
+
+// New returns Config of the selected profile of config file with
+// environment variables, opts and command line flags applied on top,
+// in that order.
+func New(opts ...option) (*Config, error) {
+	src := &sources{
+		filePath: filepath.Join(defaultCacheDir(), FileName),
+		osArgs:   os.Args[1:],
+		env: map[string]string{
+			EnvConfig:    os.Getenv(EnvConfig),
+			EnvProfile:   os.Getenv(EnvProfile),
+			EnvAddress:   os.Getenv(EnvAddress),
+			EnvTransport: os.Getenv(EnvTransport),
+			EnvLogLevel:  os.Getenv(EnvLogLevel),
+		},
+	}
+
+	for _, opt := range opts {
+		opt(src)
+	}
 
-	for _,opt := range opts{
-		opt(&c)
+	if v := src.env[EnvConfig]; v != "" {
+		src.filePath, src.fileSet = v, true
 	}
 
 	fs := flag.NewFlagSet("myFS", flag.ContinueOnError)
-	if !fs.Parsed() {
-		fs.StringVar(&c.srvAddr, "a", c.srvAddr, "the service address")
-		fs.StringVar(&c.logPath, "l", c.logPath, "path to write log")
+	fs.StringVar(&src.flags.srvAddr, "a", "", "the service address")
+	fs.StringVar(&src.flags.logPath, "l", "", "path to write log")
+	fs.StringVar(&src.flags.logLevel, "ll", "", "log level: debug, info, warn or error")
+	fs.StringVar(&src.flags.cacheDir, "c", "", "directory to keep local cache in")
+	fs.StringVar(&src.flags.transport, "t", "", "transport to talk to the server with: http or grpc")
+	fs.StringVar(&src.flags.profile, "profile", "", "profile of config file to use")
+	fs.DurationVar(&src.flags.clipTimeout, "clip", 0, "time copied secrets stay in the clipboard")
+	fs.DurationVar(&src.flags.lockTimeout, "lock", 0, "time of inactivity the client is locked after, negative disables locking")
+	configPath := fs.String("config", "", "path to JSON config file")
 
-		fs.Parse(os.Args[1:])
+	fs.Parse(src.osArgs)
+
+	if *configPath != "" {
+		src.filePath, src.fileSet = *configPath, true
+	}
+
+	if err := src.readFile(); err != nil {
+		return nil, err
 	}
 
-	return &c
+	c, err := src.resolve(src.profileName())
+	if err != nil {
+		return nil, err
+	}
+
+	c.args = fs.Args()
 
+	return c, nil
 }
 
-func (c Config) SrvAddr()string{
+func (c Config) SrvAddr() string {
 	return c.srvAddr
 }
 
-func (c Config) LogPath()string{
+func (c Config) LogPath() string {
 	return c.logPath
-}
\ No newline at end of file
+}
+
+// LogLevel returns client log level: debug, info, warn or error
+func (c Config) LogLevel() string {
+	return c.logLevel
+}
+
+func (c Config) CacheDir() string {
+	return c.cacheDir
+}
+
+// Transport returns TransportHTTP or TransportGRPC
+func (c Config) Transport() string {
+	return c.transport
+}
+
+// ClipboardTimeout returns the time copied secrets stay in the
+// clipboard, it's zero if not set.
+func (c Config) ClipboardTimeout() time.Duration {
+	return c.clipTimeout
+}
+
+// LockTimeout returns the time of inactivity the client is locked
+// after, it's zero if not set and negative if locking is disabled.
+func (c Config) LockTimeout() time.Duration {
+	return c.lockTimeout
+}
+
+// TLS returns TLS config to talk to the server with,
+// it's nil if the server is talked to in plain text.
+func (c Config) TLS() *tls.Config {
+	return c.tls
+}
+
+// Args returns command line arguments left after flags
+func (c Config) Args() []string {
+	return c.args
+}
+
+// defaultCacheDir returns ghostorange directory in user's
+// config dir or in the working directory if there's no such.
+func defaultCacheDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".ghostorange"
+	}
+
+	return filepath.Join(dir, "ghostorange")
+}
@@ -0,0 +1,83 @@
+package clconfig
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	file := WithFile("./testdata/client.json")
+	noEnv := WithEnvVars(map[string]string{})
+
+	cfg, err := New(file, noEnv, WithOsArgs(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"broken", "production", "staging"}, cfg.Profiles())
+	assert.Equal(t, "production", cfg.Profile(), "default profile is selected")
+	assert.Equal(t, "vault.example.com:443", cfg.SrvAddr())
+	assert.Equal(t, TransportGRPC, cfg.Transport())
+	assert.Equal(t, "warn", cfg.LogLevel())
+	assert.Equal(t, filepath.Join(defaultCacheDir(), "profiles", "production"), cfg.CacheDir())
+	assert.NotNil(t, cfg.TLS())
+
+	cfg, err = New(file, noEnv, WithOsArgs([]string{"--profile", "staging", "-t", "grpc", "ls", "text"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "staging", cfg.Profile())
+	assert.Equal(t, "staging.example.com:8080", cfg.SrvAddr())
+	assert.Equal(t, TransportGRPC, cfg.Transport(), "flags are applied on top of profile")
+	assert.Equal(t, "staging.log", cfg.LogPath())
+	assert.Equal(t, "/tmp/staging", cfg.CacheDir())
+	assert.Equal(t, 10*time.Second, cfg.ClipboardTimeout())
+	assert.Equal(t, 2*time.Minute, cfg.LockTimeout())
+	assert.Nil(t, cfg.TLS())
+	assert.Equal(t, []string{"ls", "text"}, cfg.Args())
+
+	cfg, err = cfg.SwitchProfile("production")
+	require.NoError(t, err)
+
+	assert.Equal(t, "vault.example.com:443", cfg.SrvAddr())
+	assert.Equal(t, []string{"ls", "text"}, cfg.Args())
+
+	cfg, err = New(file, WithOsArgs(nil), WithEnvVars(map[string]string{
+		EnvProfile: "staging",
+		EnvAddress: "localhost:8080",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "staging", cfg.Profile())
+	assert.Equal(t, "localhost:8080", cfg.SrvAddr(), "environment is applied on top of profile")
+
+	cfg, err = New(file, noEnv, WithOsArgs([]string{"--profile", "staging", "-lock", "-1s"}))
+	require.NoError(t, err)
+
+	assert.Negative(t, cfg.LockTimeout(), "flag disables locking")
+
+	_, err = cfg.SwitchProfile("broken")
+	assert.ErrorContains(t, err, "no certificates found")
+
+	_, err = New(file, noEnv, WithOsArgs([]string{"--profile", "missing"}))
+	assert.ErrorContains(t, err, "unknown profile")
+
+	_, err = New(WithFile("./testdata/missing.json"), noEnv, WithOsArgs(nil))
+	assert.Error(t, err, "config file set explicitly must exist")
+}
+
+func TestNoFile(t *testing.T) {
+	// keep config file of the user running tests out of the way
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, err := New(WithEnvVars(map[string]string{EnvConfig: ""}),
+		WithOsArgs([]string{"-a", "localhost:8080", "-c", "cache"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "localhost:8080", cfg.SrvAddr())
+	assert.Equal(t, "cache", cfg.CacheDir())
+	assert.Equal(t, TransportHTTP, cfg.Transport())
+	assert.Nil(t, cfg.TLS())
+}
@@ -0,0 +1,234 @@
+package clconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileName is the name of config file looked for
+// in ghostorange directory of user's config dir.
+const FileName = "client.json"
+
+type (
+	// sources keeps everything Config is made of,
+	// so that another profile can be selected later.
+	sources struct {
+		filePath string
+		fileSet  bool
+		file     fileStruct
+		env      map[string]string
+		osArgs   []string
+		opts     Config
+		flags    Config
+	}
+
+	fileStruct struct {
+		DefaultProfile string             `json:"default_profile"`
+		Profiles       map[string]profile `json:"profiles"`
+	}
+
+	profile struct {
+		Address   string      `json:"address"`
+		Transport string      `json:"transport"`
+		LogPath   string      `json:"log_path"`
+		LogLevel  string      `json:"log_level"`
+		CacheDir  string      `json:"cache_dir"`
+		TLS       tlsSettings `json:"tls"`
+		// ClipboardTimeout is in seconds
+		ClipboardTimeout int `json:"clipboard_timeout"`
+		// LockTimeout is in seconds, negative disables locking
+		LockTimeout int `json:"lock_timeout"`
+	}
+
+	tlsSettings struct {
+		Enabled bool `json:"enabled"`
+		// CAFile is PEM file of certificates to trust
+		// instead of the system ones
+		CAFile     string `json:"ca_file"`
+		ServerName string `json:"server_name"`
+		// InsecureSkipVerify disables certificate checks,
+		// never set it for production vaults
+		InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	}
+)
+
+// Profile returns the name of selected profile,
+// it's empty if config file has no profiles.
+func (c Config) Profile() string {
+	return c.profile
+}
+
+// Profiles returns names of all profiles of config file.
+func (c Config) Profiles() []string {
+	names := make([]string, 0, len(c.src.file.Profiles))
+	for name := range c.src.file.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// SwitchProfile returns Config of another profile. Environment
+// variables and command line flags are applied on top of it
+// the same way as they are applied to the selected one.
+func (c Config) SwitchProfile(name string) (*Config, error) {
+	nc, err := c.src.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	nc.args = c.args
+
+	return nc, nil
+}
+
+func (s *sources) readFile() error {
+	data, err := os.ReadFile(s.filePath)
+	if errors.Is(err, fs.ErrNotExist) && !s.fileSet {
+		// config file is optional unless it's set explicitly
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.file); err != nil {
+		return fmt.Errorf("failed to parse config file %v: %w", s.filePath, err)
+	}
+
+	return nil
+}
+
+// profileName returns the profile selected by flags, opts
+// or environment, the default one of config file otherwise.
+// With no default the first profile by name is used.
+func (s *sources) profileName() string {
+	for _, name := range []string{s.flags.profile, s.opts.profile, s.env[EnvProfile], s.file.DefaultProfile} {
+		if name != "" {
+			return name
+		}
+	}
+
+	names := Config{src: s}.Profiles()
+	if len(names) == 0 {
+		return ""
+	}
+
+	return names[0]
+}
+
+// resolve returns Config of profile name.
+func (s *sources) resolve(name string) (*Config, error) {
+	c := Config{
+		cacheDir:  defaultCacheDir(),
+		transport: TransportHTTP,
+		logLevel:  "debug",
+		profile:   name,
+		src:       s,
+	}
+
+	if name != "" {
+		p, ok := s.file.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", name)
+		}
+
+		// every profile keeps its own cache
+		c.cacheDir = filepath.Join(c.cacheDir, "profiles", name)
+
+		tlsCfg, err := p.TLS.config()
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		c.tls = tlsCfg
+		c.merge(Config{
+			srvAddr:   p.Address,
+			transport: p.Transport,
+			logPath:   p.LogPath,
+			logLevel:  p.LogLevel,
+			cacheDir:  p.CacheDir,
+			// clipboard timeout is set in seconds
+			clipTimeout: time.Duration(p.ClipboardTimeout) * time.Second,
+			lockTimeout: time.Duration(p.LockTimeout) * time.Second,
+		})
+	}
+
+	c.merge(Config{
+		srvAddr:   s.env[EnvAddress],
+		transport: s.env[EnvTransport],
+		logLevel:  s.env[EnvLogLevel],
+	})
+	c.merge(s.opts)
+	c.merge(s.flags)
+
+	if c.transport != TransportHTTP && c.transport != TransportGRPC {
+		return nil, fmt.Errorf("unsupported transport %q", c.transport)
+	}
+
+	return &c, nil
+}
+
+// merge overrides settings of c that are set in pc.
+func (c *Config) merge(pc Config) {
+	if pc.srvAddr != "" {
+		c.srvAddr = pc.srvAddr
+	}
+	if pc.transport != "" {
+		c.transport = pc.transport
+	}
+	if pc.logPath != "" {
+		c.logPath = pc.logPath
+	}
+	if pc.logLevel != "" {
+		c.logLevel = pc.logLevel
+	}
+	if pc.cacheDir != "" {
+		c.cacheDir = pc.cacheDir
+	}
+	if pc.clipTimeout != 0 {
+		c.clipTimeout = pc.clipTimeout
+	}
+	if pc.lockTimeout != 0 {
+		c.lockTimeout = pc.lockTimeout
+	}
+}
+
+func (t tlsSettings) config() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %v", t.CAFile)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,127 @@
+// Package clipboard copies text to the system clipboard with OSC 52
+// terminal escape sequence. The terminal sets the clipboard of the
+// machine it runs on, so copying works over SSH and needs no OS
+// specific tools. Terminals that do not support OSC 52 ignore it.
+//
+// Copied secrets are cleared after a timeout.
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the time copied text stays in the clipboard.
+const DefaultTimeout = 30 * time.Second
+
+// Clipboard writes to the clipboard of the terminal out is.
+type Clipboard struct {
+	out     io.Writer
+	timeout time.Duration
+	// sync runs f where writing to the terminal is safe,
+	// e.g. in event loop of TUI application
+	sync func(f func())
+	env  func(string) string
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// New returns Clipboard that clears copied text after timeout.
+// Writes are done by the function passed to sync, so that escape
+// sequences are not mixed with screen updates.
+func New(out io.Writer, timeout time.Duration, sync func(f func())) *Clipboard {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Clipboard{out: out, timeout: timeout, sync: sync, env: os.Getenv}
+}
+
+// Copy copies text to the clipboard. The clipboard is cleared
+// after timeout unless something else is copied meanwhile.
+func (c *Clipboard) Copy(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.write(text); err != nil {
+		return err
+	}
+
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+
+	var timer *time.Timer
+
+	timer = time.AfterFunc(c.timeout, func() {
+		c.sync(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			// a later copy has replaced the text
+			if c.timer != timer {
+				return
+			}
+
+			c.timer = nil
+			c.write("")
+		})
+	})
+	c.timer = timer
+
+	return nil
+}
+
+// Clear clears the clipboard now if copied text is still there,
+// e.g. when the application exits or locks before timeout.
+// It writes to the terminal directly, so it is called where that
+// is safe, in event loop or once the loop has ended.
+func (c *Clipboard) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timer == nil {
+		return nil
+	}
+
+	c.timer.Stop()
+	c.timer = nil
+
+	return c.write("")
+}
+
+// Timeout returns the time copied text stays in the clipboard.
+func (c *Clipboard) Timeout() time.Duration {
+	return c.timeout
+}
+
+func (c *Clipboard) write(text string) error {
+	seq := Sequence(text)
+
+	// terminal multiplexers pass sequences to the outer
+	// terminal only if they are wrapped
+	switch {
+	case c.env("TMUX") != "":
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case c.env("STY") != "":
+		seq = "\x1bP" + seq + "\x1b\\"
+	}
+
+	if _, err := io.WriteString(c.out, seq); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+
+	return nil
+}
+
+// Sequence returns OSC 52 sequence that sets the clipboard to text.
+// Empty text clears it.
+func Sequence(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
@@ -0,0 +1,93 @@
+package clipboard
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is written by timers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func newClipboard(out *syncBuffer, timeout time.Duration, env map[string]string) *Clipboard {
+	c := New(out, timeout, func(f func()) { f() })
+	c.env = func(name string) string { return env[name] }
+
+	return c
+}
+
+func TestCopy(t *testing.T) {
+	out := &syncBuffer{}
+	c := newClipboard(out, 50*time.Millisecond, nil)
+
+	require.NoError(t, c.Copy("secret"))
+	assert.Equal(t, "\x1b]52;c;c2VjcmV0\a", out.String())
+
+	assert.Eventually(t, func() bool {
+		return out.String() == "\x1b]52;c;c2VjcmV0\a\x1b]52;c;\a"
+	}, time.Second, 10*time.Millisecond, "clipboard is cleared after timeout")
+}
+
+func TestCopyReplaces(t *testing.T) {
+	out := &syncBuffer{}
+	c := newClipboard(out, 100*time.Millisecond, nil)
+
+	require.NoError(t, c.Copy("first"))
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, c.Copy("second"))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, Sequence("first")+Sequence("second"), out.String(),
+		"the timer of the first copy is stopped")
+
+	assert.Eventually(t, func() bool {
+		return out.String() == Sequence("first")+Sequence("second")+Sequence("")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClear(t *testing.T) {
+	out := &syncBuffer{}
+	c := newClipboard(out, 50*time.Millisecond, nil)
+
+	require.NoError(t, c.Clear())
+	assert.Empty(t, out.String(), "nothing is cleared if nothing is copied")
+
+	require.NoError(t, c.Copy("secret"))
+	require.NoError(t, c.Clear())
+	assert.Equal(t, Sequence("secret")+Sequence(""), out.String())
+
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, c.Clear())
+	assert.Equal(t, Sequence("secret")+Sequence(""), out.String(), "the timer is stopped")
+}
+
+func TestMultiplexers(t *testing.T) {
+	out := &syncBuffer{}
+	require.NoError(t, newClipboard(out, time.Hour, map[string]string{"TMUX": "/tmp/tmux"}).Copy("a"))
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;YQ==\a\x1b\\", out.String())
+
+	out = &syncBuffer{}
+	require.NoError(t, newClipboard(out, time.Hour, map[string]string{"STY": "1.pts"}).Copy("a"))
+	assert.Equal(t, "\x1bP\x1b]52;c;YQ==\a\x1b\\", out.String())
+}
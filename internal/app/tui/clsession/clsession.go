@@ -0,0 +1,147 @@
+// Package clsession keeps client sessions on disk, so that user
+// is not asked for password on every start.
+//
+// A saved session holds the session token and the key of user's
+// local cache, so the file is as sensitive as the password. It is
+// kept readable by its owner only, the way ssh keeps private keys,
+// and is refused if anyone else can read it.
+package clsession
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const fileName = "session.json"
+
+// ErrNoSession is returned when there is no valid saved session.
+var ErrNoSession = errors.New("no saved session")
+
+// Store keeps sessions of a single server. Sessions of different
+// servers share the file.
+type Store struct {
+	path    string
+	srvAddr string
+}
+
+// New returns Store that keeps sessions in dir.
+func New(dir, srvAddr string) *Store {
+	return &Store{path: filepath.Join(dir, fileName), srvAddr: srvAddr}
+}
+
+// Load returns saved session unless it has expired.
+func (s *Store) Load() (model.Session, error) {
+	sessions, err := s.read()
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	sess, ok := sessions[s.srvAddr]
+	if !ok || !sess.Valid(time.Now()) {
+		return model.Session{}, ErrNoSession
+	}
+
+	return sess, nil
+}
+
+// Save saves sess replacing the one saved before.
+func (s *Store) Save(sess model.Session) error {
+	sessions, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	sessions[s.srvAddr] = sess
+
+	return s.write(sessions)
+}
+
+// Delete deletes saved session if there is one.
+func (s *Store) Delete() error {
+	sessions, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := sessions[s.srvAddr]; !ok {
+		return nil
+	}
+
+	delete(sessions, s.srvAddr)
+
+	return s.write(sessions)
+}
+
+func (s *Store) read() (map[string]model.Session, error) {
+	sessions := make(map[string]model.Session)
+
+	st, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sessions, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to access session file: %w", err)
+	}
+
+	// file permissions are not enforced on windows
+	if runtime.GOOS != "windows" && st.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("session file %v is accessible by other users, "+
+			"restrict its permissions to 0600 or delete it", s.path)
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode session file: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// write replaces the file at once, so it is never left half written.
+func (s *Store) write(sessions map[string]model.Session) error {
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	// temp file is created with 0600 permissions
+	f, err := os.CreateTemp(dir, fileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package clsession
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ghostorange")
+
+	s := New(dir, "localhost:8080")
+	other := New(dir, "example.com:8080")
+
+	_, err := s.Load()
+	assert.ErrorIs(t, err, ErrNoSession)
+
+	sess := model.Session{Login: "user", Token: "token", CacheKey: []byte("key"),
+		ExpiresAt: time.Now().Add(time.Hour).UTC().Round(time.Second)}
+
+	require.NoError(t, s.Save(sess))
+	require.NoError(t, other.Save(model.Session{Login: "other", Token: "other",
+		ExpiresAt: time.Now().Add(time.Hour)}))
+
+	got, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, sess, got)
+
+	if runtime.GOOS != "windows" {
+		st, err := os.Stat(filepath.Join(dir, fileName))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+	}
+
+	require.NoError(t, s.Delete())
+
+	_, err = s.Load()
+	assert.ErrorIs(t, err, ErrNoSession)
+
+	got, err = other.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "other", got.Login, "sessions of other servers are kept")
+
+	sess.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, s.Save(sess))
+
+	_, err = s.Load()
+	assert.ErrorIs(t, err, ErrNoSession, "expired session is not loaded")
+}
+
+func TestStoreRefusesReadableFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not enforced on windows")
+	}
+
+	dir := t.TempDir()
+	s := New(dir, "localhost:8080")
+
+	require.NoError(t, s.Save(model.Session{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, os.Chmod(filepath.Join(dir, fileName), 0o644))
+
+	_, err := s.Load()
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoSession)
+}
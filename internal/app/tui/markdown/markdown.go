@@ -0,0 +1,281 @@
+// Package markdown renders Markdown notes as text with tview
+// color tags, to be shown by a TextView with dynamic colors.
+//
+// A common subset is supported: headings, emphasis, strikethrough,
+// inline and fenced code, links, block quotes, lists, task lists
+// and horizontal rules. Anything else is shown as it is written,
+// and text is escaped, so it never turns into tags itself.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	colorHeading = "yellow"
+	colorCode    = "green"
+	colorLink    = "blue"
+	colorMuted   = "gray"
+
+	rule = "────────────────────"
+)
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern    = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	listPattern    = regexp.MustCompile(`^(\s*)([-*+])\s+(\[[ xX]\]\s+)?(.*)$`)
+	orderedPattern = regexp.MustCompile(`^(\s*)(\d{1,9}[.)])\s+(.*)$`)
+	quotePattern   = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	fencePattern   = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+)
+
+// Render returns text with Markdown formatting turned into
+// color tags.
+func Render(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	// fence opening the code block the line is in, if any
+	fence := ""
+
+	for _, line := range lines {
+		if m := fencePattern.FindStringSubmatch(line); m != nil && (fence == "" || m[1] == fence) {
+			if fence == "" {
+				fence = m[1]
+			} else {
+				fence = ""
+			}
+
+			continue
+		}
+
+		if fence != "" {
+			out = append(out, "["+colorCode+"]"+tview.Escape(line)+"[-]")
+
+			continue
+		}
+
+		out = append(out, renderLine(line))
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// renderLine renders a line outside of code blocks.
+func renderLine(line string) string {
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		attrs := "b"
+		if len(m[1]) == 1 {
+			attrs = "bu"
+		}
+
+		return "[" + colorHeading + "]" + renderInline(m[2], attrs) + "[-]"
+	}
+
+	if rulePattern.MatchString(line) {
+		return "[" + colorMuted + "]" + rule + "[-]"
+	}
+
+	if m := quotePattern.FindStringSubmatch(line); m != nil {
+		return "[" + colorMuted + "]│[-] " + renderInline(m[1], "i")
+	}
+
+	if m := listPattern.FindStringSubmatch(line); m != nil {
+		bullet := "•"
+
+		switch strings.ToLower(strings.TrimSpace(m[3])) {
+		case "[ ]":
+			bullet = "☐"
+		case "[x]":
+			bullet = "☑"
+		}
+
+		return m[1] + "[" + colorHeading + "]" + bullet + "[-] " + renderInline(m[4], "")
+	}
+
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + "[" + colorHeading + "]" + m[2] + "[-] " + renderInline(m[3], "")
+	}
+
+	return renderInline(line, "")
+}
+
+// renderInline renders emphasis, code spans and links of s.
+// attrs are text attributes in effect, e.g. "b" within a heading,
+// they are restored after every span that changes them.
+func renderInline(s string, attrs string) string {
+	var (
+		b     strings.Builder
+		plain strings.Builder
+	)
+
+	flush := func() {
+		b.WriteString(tview.Escape(plain.String()))
+		plain.Reset()
+	}
+
+	if attrs != "" {
+		b.WriteString(attrTag(attrs))
+	}
+
+	rs := []rune(s)
+
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+
+		switch {
+		case r == '\\' && i+1 < len(rs) && unicode.IsPunct(rs[i+1]):
+			i++
+			plain.WriteRune(rs[i])
+
+			continue
+		case r == '`':
+			if end := indexRune(rs, '`', i+1); end > i+1 {
+				flush()
+				b.WriteString("[" + colorCode + "]" + tview.Escape(string(rs[i+1:end])) + "[-]")
+				i = end
+
+				continue
+			}
+		case r == '[':
+			if text, url, end, ok := link(rs, i); ok {
+				flush()
+				b.WriteString("[" + colorLink + "]" + renderInline(text, attrs+"u") + attrTag(attrs) + "[-]")
+
+				if url != text {
+					b.WriteString(" [" + colorMuted + "](" + tview.Escape(url) + ")[-]")
+				}
+
+				i = end
+
+				continue
+			}
+		case r == '*' || r == '_' || r == '~':
+			if inner, attr, end, ok := emphasis(rs, i); ok {
+				flush()
+				b.WriteString(renderInline(inner, attrs+attr))
+				b.WriteString(attrTag(attrs))
+				i = end
+
+				continue
+			}
+		}
+
+		plain.WriteRune(r)
+	}
+
+	flush()
+
+	if attrs != "" {
+		b.WriteString("[::-]")
+	}
+
+	return b.String()
+}
+
+// emphasis parses emphasis span opening at rs[i]. It returns
+// the text within, the attribute the span sets and the index
+// of the last rune of the closing delimiter.
+func emphasis(rs []rune, i int) (inner, attr string, end int, ok bool) {
+	d := rs[i]
+
+	n := 1
+	if i+1 < len(rs) && rs[i+1] == d {
+		n = 2
+	}
+
+	switch {
+	case d == '~' && n == 2:
+		attr = "s"
+	case d == '~':
+		return "", "", 0, false
+	case n == 2:
+		attr = "b"
+	default:
+		attr = "i"
+	}
+
+	start := i + n
+	if start >= len(rs) || unicode.IsSpace(rs[start]) {
+		return "", "", 0, false
+	}
+
+	// underscores within words, as in snake_case, are not emphasis
+	if d == '_' && i > 0 && isWordRune(rs[i-1]) {
+		return "", "", 0, false
+	}
+
+	for j := start + 1; j+n <= len(rs); j++ {
+		if !closes(rs, j, d, n) || unicode.IsSpace(rs[j-1]) {
+			continue
+		}
+
+		after := j + n
+		if d == '_' && after < len(rs) && isWordRune(rs[after]) {
+			continue
+		}
+
+		return string(rs[start:j]), attr, after - 1, true
+	}
+
+	return "", "", 0, false
+}
+
+// closes tells if exactly n delimiters d start at rs[j].
+func closes(rs []rune, j int, d rune, n int) bool {
+	for k := 0; k < n; k++ {
+		if rs[j+k] != d {
+			return false
+		}
+	}
+
+	return j+n >= len(rs) || rs[j+n] != d
+}
+
+// link parses [text](url) starting at rs[i]. It returns the index
+// of the closing parenthesis.
+func link(rs []rune, i int) (text, url string, end int, ok bool) {
+	closing := indexRune(rs, ']', i+1)
+	if closing < 0 || closing+1 >= len(rs) || rs[closing+1] != '(' {
+		return "", "", 0, false
+	}
+
+	end = indexRune(rs, ')', closing+2)
+	if end < 0 {
+		return "", "", 0, false
+	}
+
+	url = strings.TrimSpace(string(rs[closing+2 : end]))
+	if url == "" || strings.ContainsAny(url, " \t") {
+		return "", "", 0, false
+	}
+
+	return string(rs[i+1 : closing]), url, end, true
+}
+
+func indexRune(rs []rune, r rune, from int) int {
+	for i := from; i < len(rs); i++ {
+		if rs[i] == r {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// attrTag returns a tag that sets text attributes to attrs.
+func attrTag(attrs string) string {
+	if attrs == "" {
+		return "[::-]"
+	}
+
+	return "[::" + attrs + "]"
+}
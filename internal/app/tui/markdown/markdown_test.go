@@ -0,0 +1,54 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tt := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{name: "plain", md: "just text", want: "just text"},
+		{name: "heading", md: "# Wi-Fi #", want: "[yellow][::bu]Wi-Fi[::-][-]"},
+		{name: "subheading", md: "### Guest", want: "[yellow][::b]Guest[::-][-]"},
+		{name: "bold", md: "key is **secret**", want: "key is [::b]secret[::-][::-]"},
+		{name: "italic in bold", md: "**a *b* c**", want: "[::b]a [::bi]b[::-][::b] c[::-][::-]"},
+		{name: "strikethrough", md: "~~old~~", want: "[::s]old[::-][::-]"},
+		{name: "snake case", md: "my_long_name", want: "my_long_name"},
+		{name: "code", md: "run `ls *.go`", want: "run [green]ls *.go[-]"},
+		{name: "link", md: "[bank](https://bank.example.com)",
+			want: "[blue][::u]bank[::-][::-][-] [gray](https://bank.example.com)[-]"},
+		{name: "escaped", md: `\*not\* [red] text`, want: "*not* [red[] text"},
+		{name: "list", md: "  - item", want: "  [yellow]•[-] item"},
+		{name: "task", md: "- [x] done", want: "[yellow]☑[-] done"},
+		{name: "ordered", md: "2. second", want: "[yellow]2.[-] second"},
+		{name: "quote", md: "> note", want: "[gray]│[-] [::i]note[::-]"},
+		{name: "rule", md: "---", want: "[gray]" + rule + "[-]"},
+		{name: "code block", md: "```go\n**x** [y]\n```\n*z*",
+			want: "[green]**x** [y[][-]\n[::i]z[::-][::-]"},
+		{name: "unclosed", md: "2 * 3 = **6", want: "2 * 3 = **6"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Render(tc.md))
+		})
+	}
+}
+
+func TestRenderKeepsText(t *testing.T) {
+	// text written by user must never be taken for tags
+	md := "[red]alert[-] [\"region\"] **[::b]** `[green]`"
+
+	tv := tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetText(Render(md))
+
+	assert.Equal(t, "[red]alert[-] [\"region\"] [::b] [green]", tv.GetText(true))
+}
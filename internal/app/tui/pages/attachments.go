@@ -0,0 +1,150 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// attached returns binary items of ids in the same order.
+// Items deleted since they were attached are left out.
+func (c *Constructor) attached(ids []string) ([]model.ItemBinary, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	val, err := c.Adapter.GetData(model.KeyBinary)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := val.([]model.ItemBinary)
+	if !ok {
+		return nil, fmt.Errorf("got unexpected data type; expected: %v",
+			model.GetItemTitle(model.KeyBinary))
+	}
+
+	byID := make(map[string]model.ItemBinary, len(data))
+	for _, item := range data {
+		byID[item.ID] = item
+	}
+
+	items := make([]model.ItemBinary, 0, len(ids))
+
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// attachmentNames returns names of binary items of ids
+// to be shown in a form.
+func (c *Constructor) attachmentNames(ids []string) string {
+	items, err := c.attached(ids)
+	if err != nil {
+		c.Logger.Errorf("failed to get attachments: %v", err)
+
+		return "failed to get attachments"
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = fileName(item)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// showAttachments shows binary items to pick attachments of a note
+// from, the ones of ids are picked already. IDs of picked items are
+// passed to use and the focus is switched back to pageKey once user
+// is done.
+func (c *Constructor) showAttachments(pageKey string, ids []string, use func(ids []string)) {
+	val, err := c.Adapter.GetData(model.KeyBinary)
+	if err != nil {
+		c.ShowError(err, pageKey)
+		return
+	}
+
+	data, ok := val.([]model.ItemBinary)
+	if !ok {
+		c.ShowError(fmt.Errorf("got unexpected data type; expected: %v",
+			model.GetItemTitle(model.KeyBinary)), pageKey)
+		return
+	}
+
+	picked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		picked[id] = true
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+
+	for _, item := range data {
+		name := fileName(item)
+		if picked[item.ID] {
+			name = markPrefix + name
+		}
+
+		list.AddItem(name, "", 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, main, _ string, _ rune) {
+		id := data[index].ID
+		picked[id] = !picked[id]
+
+		main = strings.TrimPrefix(main, markPrefix)
+		if picked[id] {
+			main = markPrefix + main
+		}
+
+		list.SetItemText(index, main, "")
+	})
+
+	done := func() {
+		var res []string
+
+		for _, item := range data {
+			if picked[item.ID] {
+				res = append(res, item.ID)
+			}
+		}
+
+		use(res)
+		c.Pages.SwitchToPage(pageKey)
+	}
+
+	list.SetDoneFunc(done)
+
+	list.SetBorder(true).
+		SetTitle("Attachments: Enter picks a file, Esc is done")
+
+	menu := tview.NewFlex().
+		AddItem(tview.NewButton("Done").SetSelectedFunc(done), 0, 1, false).
+		AddItem(tview.NewButton("Cancel").
+			SetSelectedFunc(func() {
+				c.Pages.SwitchToPage(pageKey)
+			}), 0, 1, false)
+
+	page := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(menu, 1, 0, false)
+
+	c.Pages.AddPage(KeyFormAttachments, page, true, false)
+	c.Pages.SwitchToPage(KeyFormAttachments)
+}
+
+func fileName(item model.ItemBinary) string {
+	if item.Extention == "" {
+		return item.Name
+	}
+
+	return item.Name + "." + strings.TrimPrefix(item.Extention, ".")
+}
@@ -1,6 +1,8 @@
 package pages
 
 import (
+	"errors"
+
 	"github.com/rivo/tview"
 
 	"github.com/usa4ev/ghostorange/internal/app/model"
@@ -9,6 +11,7 @@ import (
 
 func (c *Constructor) loginForm() *tview.Form {
 	creds := model.Credentials{}
+	remember := false
 
 	tAppInfo := tview.NewTextView().
 		SetText(appinfo.AppInfo()).SetSize(2, 50)
@@ -23,22 +26,45 @@ func (c *Constructor) loginForm() *tview.Form {
 		AddPasswordField("password", "", 25, '*', func(text string) {
 			creds.Password = text
 		}).
+		AddCheckbox("remember me", remember, func(checked bool) {
+			remember = checked
+		}).
 		AddButton("Login", func() {
 			c.Logger.Debugf("login attempt, user %v",
 				creds.Login)
 			if err := c.Adapter.Login(creds); err == nil {
 				c.Logger.Debugf("successfull login, user %v",
 					creds.Login)
+				c.rememberSession(remember)
+				c.unlocked = true
+				c.watchEvents()
 				c.Build(KeyMenu)
 				c.Pages.SwitchToPage(KeyMenu)
 			} else {
-				c.ShowMessage(err.Error(), KeyLoginForm)
+				c.ShowError(err, KeyLoginForm)
 			}
 		}).
 		AddButton("Register", func() {
 			c.Pages.SwitchToPage(KeyRegistrationForm)
 		})
 
+	if c.Profiles != nil && len(c.Profiles.Profiles()) > 1 {
+		names := c.Profiles.Profiles()
+		cur := -1
+
+		for i, name := range names {
+			if name == c.Profiles.Profile() {
+				cur = i
+			}
+		}
+
+		loginForm.AddDropDown("profile", names, cur, func(name string, _ int) {
+			if name != "" && name != c.Profiles.Profile() {
+				c.switchProfile(name)
+			}
+		})
+	}
+
 	return loginForm
 }
 
@@ -57,13 +83,111 @@ func (c *Constructor) regForm() *tview.Form {
 		}).
 		AddButton("Register", func() {
 			if err := c.Adapter.Register(creds); err == nil {
+				c.unlocked = true
+				c.watchEvents()
 				c.Build(KeyMenu)
 				c.Pages.SwitchToPage(KeyMenu)
 				creds = model.Credentials{}
 			} else {
-				c.ShowMessage(err.Error(), KeyRegistrationForm)
+				c.ShowError(err, KeyRegistrationForm)
 			}
 		})
 
 	return regForm
 }
+
+// ResumeSession resumes the session saved by the last login with
+// "remember me" checked. It reports whether the user is logged in,
+// so the login page can be skipped.
+func (c *Constructor) ResumeSession() bool {
+	s, err := c.Sessions.Load()
+	if err != nil {
+		c.Logger.Debugf("no session to resume: %v", err)
+
+		return false
+	}
+
+	if err := c.Adapter.Resume(s); err != nil {
+		c.Logger.Infof("failed to resume session of %v: %v", s.Login, err)
+
+		if errors.Is(err, model.ErrInvalidSession) {
+			c.forgetSession()
+		}
+
+		return false
+	}
+
+	c.Logger.Debugf("resumed session, user %v", s.Login)
+	c.unlocked = true
+	c.watchEvents()
+
+	return true
+}
+
+// rememberSession saves the session if remember is set,
+// otherwise the session saved before is forgotten.
+func (c *Constructor) rememberSession(remember bool) {
+	if !remember {
+		c.forgetSession()
+
+		return
+	}
+
+	s, err := c.Adapter.Session()
+	if err == nil {
+		err = c.Sessions.Save(s)
+	}
+
+	if err != nil {
+		c.Logger.Errorf("failed to save session: %v", err)
+	}
+}
+
+func (c *Constructor) forgetSession() {
+	if err := c.Sessions.Delete(); err != nil {
+		c.Logger.Errorf("failed to delete saved session: %v", err)
+	}
+}
+
+// switchProfile makes the client talk to the server of profile
+// name and resumes the session saved for it, if any.
+func (c *Constructor) switchProfile(name string) {
+	a, sessions, err := c.Profiles.Switch(name)
+	if err != nil {
+		c.ShowError(err, KeyLoginForm)
+
+		return
+	}
+
+	c.Logger.Debugf("switched to profile %v", name)
+
+	c.forgetCurItem()
+	c.unlocked = false
+
+	if err := c.Adapter.Close(); err != nil {
+		c.Logger.Errorf("failed to close adapter: %v", err)
+	}
+
+	c.Adapter, c.Sessions = a, sessions
+
+	if c.ResumeSession() {
+		c.Build(KeyMenu)
+		c.Pages.SwitchToPage(KeyMenu)
+
+		return
+	}
+
+	c.Build(KeyLoginForm)
+	c.Pages.SwitchToPage(KeyLoginForm)
+}
+
+// logout forgets the session and returns to the login page.
+func (c *Constructor) logout() {
+	c.forgetSession()
+	c.forgetCurItem()
+	c.unlocked = false
+	c.Adapter.Logout()
+
+	c.Build(KeyLoginForm)
+	c.Pages.SwitchToPage(KeyLoginForm)
+}
@@ -0,0 +1,130 @@
+package pages
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/backup"
+	"github.com/usa4ev/ghostorange/internal/app/importer"
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// backupForm exports all items to an encrypted archive and restores
+// archives made by export. Results of restore are shown below the form.
+func (c *Constructor) backupForm() tview.Primitive {
+	var path, passphrase, repeat, password string
+
+	report := tview.NewTextView().
+		SetScrollable(true)
+	report.SetBorder(true).
+		SetTitle("Report")
+
+	export := func() {
+		if passphrase != repeat {
+			c.ShowError(errors.New("passphrases do not match"), KeyFormBackup)
+
+			return
+		}
+
+		if err := backup.CheckPassphrase(passphrase); err != nil {
+			c.ShowError(err, KeyFormBackup)
+
+			return
+		}
+
+		// the archive holds full card numbers,
+		// so the password is asked again
+		s, err := c.Adapter.Session()
+		if err != nil {
+			c.ShowError(err, KeyFormBackup)
+
+			return
+		}
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			c.ShowError(err, KeyFormBackup)
+
+			return
+		}
+
+		err = c.Adapter.Export(f, model.Credentials{Login: s.Login, Password: password}, passphrase)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			os.Remove(path)
+			c.ShowError(err, KeyFormBackup)
+			c.Logger.Errorf("failed to export: %v", err)
+
+			return
+		}
+
+		report.SetText(fmt.Sprintf("Exported to %v", path))
+	}
+
+	restore := func(dryRun bool) {
+		items, err := importer.ParseArchiveFile(path, passphrase)
+		if err != nil {
+			c.ShowError(err, KeyFormBackup)
+
+			return
+		}
+
+		res, err := importer.Import(c.Adapter, items, dryRun)
+		if err != nil {
+			c.ShowError(err, KeyFormBackup)
+			c.Logger.Errorf("failed to restore: %v", err)
+
+			return
+		}
+
+		lines := make([]string, 0, len(res.Results)+2)
+		for _, r := range res.Results {
+			lines = append(lines, r.String())
+		}
+
+		lines = append(lines, "", res.Summary())
+
+		report.SetText(strings.Join(lines, "\n")).
+			ScrollToBeginning()
+	}
+
+	form := tview.NewForm().
+		AddInputField("Path", path, 40, nil, func(text string) {
+			path = text
+		}).
+		AddPasswordField("Passphrase", passphrase, 40, '*', func(text string) {
+			passphrase = text
+		}).
+		AddPasswordField("Repeat (export)", repeat, 40, '*', func(text string) {
+			repeat = text
+		}).
+		AddPasswordField("Password (export)", password, 40, '*', func(text string) {
+			password = text
+		}).
+		AddButton("Export", export).
+		AddButton("Preview restore", func() {
+			restore(true)
+		}).
+		AddButton("Restore", func() {
+			restore(false)
+		}).
+		AddButton("Back", func() {
+			c.Build(KeyMenu)
+			c.Pages.SwitchToPage(KeyMenu)
+		})
+
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf("Encrypted archive (*%v)", backup.FileExt))
+
+	return tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 13, 0, true).
+		AddItem(report, 0, 1, false)
+}
@@ -23,19 +23,19 @@ func (c *Constructor) binaryList() listGenerator {
 	tComment := tview.NewTextView()
 	tComment.SetTitle("Comment: ")
 
-	buttons := make(map[string]func())
+	var buttons []button
 
-	buttons["Add"] = func() {
+	buttons = append(buttons, button{"Add", func() {
 		c.CurItem = model.ItemBinary{}
 		c.Build(KeyFormLoadBinary)
 		c.Pages.SwitchToPage(KeyFormLoadBinary)
-	}
+	}})
 
-	buttons["Edit"] = func() {
+	buttons = append(buttons, button{"Edit", func() {
 		if val, ok := c.CurItem.(model.ItemBinary); ok && val.ID != "" {
 			c.Pages.SwitchToPage(KeyFormLoadBinary)
 		}
-	}
+	}})
 
 	var data []model.ItemBinary
 
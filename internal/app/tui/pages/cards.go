@@ -27,18 +27,32 @@ func (c *Constructor) cardsList() listGenerator {
 	tExpires.SetTitle("Expires: ")
 	tComment.SetTitle("Comment: ")
 
-	buttons := make(map[string]func())
+	var buttons []button
 
-	buttons["Add"] = func() {
+	buttons = append(buttons, button{"Add", func() {
 		c.forgetCurItem()
 		c.Pages.SwitchToPage(KeyFormCards)
-	}
+	}})
 
-	buttons["Edit"] = func() {
+	buttons = append(buttons, button{"Edit", func() {
 		if val, ok := c.CurItem.(model.ItemCard); ok && val.ID != "" {
 			c.Pages.SwitchToPage(KeyFormCards)
 		}
-	}
+	}})
+
+	// full number is revealed by CVV code first
+	buttons = append(buttons, button{"Copy number", func() {
+		if val, ok := c.CurItem.(model.ItemCard); !ok || val.ID == "" {
+			return
+		}
+
+		c.Pages.AddPage(KeyFormCVV, c.cardsCVVForm(func() {
+			if card, ok := c.CurItem.(model.ItemCard); ok {
+				c.copySecret("Card number", card.Number, KeyCards)
+			}
+		}), true, false)
+		c.Pages.SwitchToPage(KeyFormCVV)
+	}})
 
 	var data []model.ItemCard
 
@@ -139,7 +153,7 @@ func (c *Constructor) cardsForm() *tview.Form {
 				hash, err := argon2hash.GenerateFromPassword(cvv,
 					argon2hash.DefaultParams())
 				if err != nil {
-					c.ShowMessage(err.Error(), KeyFormCards)
+					c.ShowError(err, KeyFormCards)
 					return
 				}
 
@@ -151,12 +165,12 @@ func (c *Constructor) cardsForm() *tview.Form {
 
 					// Add data
 					if err := c.Adapter.AddData(model.KeyCards, item); err != nil {
-						c.ShowMessage(err.Error(), KeyFormCards)
+						c.ShowError(err, KeyFormCards)
 						return
 					}
 				} else {
 					if err := c.Adapter.UpdateData(model.KeyCards, item); err != nil {
-						c.ShowMessage(err.Error(), KeyFormCards)
+						c.ShowError(err, KeyFormCards)
 						return
 					}
 					c.CurItem = item
@@ -174,7 +188,9 @@ func (c *Constructor) cardsForm() *tview.Form {
 	return form
 }
 
-func (c *Constructor) cardsCVVForm() *tview.Form {
+// cardsCVVForm asks for CVV code to reveal the current card,
+// done is called once the revealed card is the current item.
+func (c *Constructor) cardsCVVForm(done func()) *tview.Form {
 	var cvv string
 	return tview.NewForm().AddInputField("CVV", cvv, 3, nil, func(text string) {
 		cvv = text
@@ -195,13 +211,18 @@ func (c *Constructor) cardsCVVForm() *tview.Form {
 			var err error
 			item, err = c.Adapter.GetCard(item.ID, cvv)
 			if err != nil {
-				c.ShowMessage(err.Error(), KeyFormCVV)
+				c.ShowError(err, KeyFormCVV)
 				return
 			}
 
 			c.CurItem = item
 
-			c.Build(KeyFormCards)
-			c.Pages.SwitchToPage(KeyFormCards)
+			done()
 		})
 }
+
+// openCardForm shows revealed card in the card form.
+func (c *Constructor) openCardForm() {
+	c.Build(KeyFormCards)
+	c.Pages.SwitchToPage(KeyFormCards)
+}
@@ -0,0 +1,110 @@
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// conflictsList returns a page that lists local changes
+// the server did not accept and lets the user decide
+// which version to keep.
+func (c *Constructor) conflictsList() tview.Primitive {
+	flex := tview.NewFlex()
+	list := tview.NewList()
+	lflex := tview.NewFlex().
+		SetDirection(tview.FlexRow)
+	detail := tview.NewTextView()
+
+	data, err := c.Adapter.Conflicts()
+	if err != nil {
+		c.ShowError(err, KeyMenu)
+		c.Logger.Errorf("failed to get conflicts: %v", err)
+		return nil
+	}
+
+	var cur *model.Conflict
+
+	resolve := func(keepLocal bool) func() {
+		return func() {
+			if cur == nil {
+				return
+			}
+
+			if err := c.Adapter.ResolveConflict(cur.ID, keepLocal); err != nil {
+				c.ShowError(err, KeyConflicts)
+				c.Logger.Errorf("failed to resolve conflict: %v", err)
+			}
+
+			c.Build(KeyConflicts)
+			c.Pages.SwitchToPage(KeyConflicts)
+		}
+	}
+
+	menu := tview.NewFlex().
+		AddItem(tview.NewButton("Back").
+			SetSelectedFunc(func() {
+				c.Build(KeyMenu)
+				c.Pages.SwitchToPage(KeyMenu)
+			}), 0, 1, false).
+		AddItem(tview.NewButton("Keep mine").
+			SetSelectedFunc(resolve(true)), 0, 1, false).
+		AddItem(tview.NewButton("Keep server's").
+			SetSelectedFunc(resolve(false)), 0, 1, false)
+
+	for _, cf := range data {
+		list.AddItem(fmt.Sprintf("%v: %v", model.GetItemTitle(cf.DataType), cf.Name),
+			cf.Reason, 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, name string, second_name string, shortcut rune) {
+		cur = &data[index]
+		detail.Clear().SetText(conflictText(*cur))
+	})
+
+	lflex.AddItem(list, 0, 1, true).
+		AddItem(menu, 1, 0, false)
+
+	flex.AddItem(lflex, 0, 1, false).
+		AddItem(detail, 0, 1, false)
+
+	return flex
+}
+
+// conflictText describes both versions of the conflicting item.
+func conflictText(cf model.Conflict) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%v\n", cf.Reason)
+	fmt.Fprintf(&b, "changed locally: %v\n", cf.QueuedAt.Local().Format(timeLayout))
+	fmt.Fprintf(&b, "detected: %v\n\n", cf.DetectedAt.Local().Format(timeLayout))
+
+	if cf.Deleted {
+		b.WriteString("Mine: deleted\n\n")
+	} else {
+		fmt.Fprintf(&b, "Mine:\n%v\n\n", itemText(cf.Local))
+	}
+
+	if cf.Remote == nil {
+		b.WriteString("Server's: none")
+	} else {
+		fmt.Fprintf(&b, "Server's:\n%v", itemText(cf.Remote))
+	}
+
+	return b.String()
+}
+
+func itemText(item any) string {
+	b, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(b)
+}
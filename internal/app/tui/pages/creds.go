@@ -21,18 +21,30 @@ func (c *Constructor) credList() listGenerator {
 	tPassword.SetTitle("Password: ")
 	tComment.SetTitle("Comment: ")
 
-	buttons := make(map[string]func())
+	var buttons []button
 
-	buttons["Add"] = func() {
+	buttons = append(buttons, button{"Add", func() {
 		c.forgetCurItem()
 		c.Pages.SwitchToPage(KeyFormCredentials)
-	}
+	}})
 
-	buttons["Edit"] = func() {
+	buttons = append(buttons, button{"Edit", func() {
 		if val, ok := c.CurItem.(model.ItemCredentials); ok && val.ID != "" {
 			c.Pages.SwitchToPage(KeyFormCredentials)
 		}
-	}
+	}})
+
+	buttons = append(buttons, button{"Copy login", func() {
+		if val, ok := c.CurItem.(model.ItemCredentials); ok {
+			c.copySecret("Login", val.Credentials.Login, KeyCredentials)
+		}
+	}})
+
+	buttons = append(buttons, button{"Copy password", func() {
+		if val, ok := c.CurItem.(model.ItemCredentials); ok {
+			c.copySecret("Password", val.Credentials.Password, KeyCredentials)
+		}
+	}})
 
 	var data []model.ItemCredentials
 
@@ -95,22 +107,51 @@ func (c *Constructor) credForm() *tview.Form {
 			}).
 			AddInputField("Login", item.Credentials.Login, 25, nil, func(text string) {
 				item.Credentials.Login = text
-			}).
-			AddPasswordField("Password", item.Credentials.Password, 25, '*', func(text string) {
+			})
+
+		meter := tview.NewTextView().
+			SetLabel("Strength").
+			SetSize(1, 40).
+			SetDynamicColors(true).
+			SetText(strengthMeter(item.Credentials.Password))
+
+		password := tview.NewInputField().
+			SetLabel("Password").
+			SetText(item.Credentials.Password).
+			SetFieldWidth(25).
+			SetMaskCharacter('*').
+			SetChangedFunc(func(text string) {
 				item.Credentials.Password = text
-			}).
+				meter.SetText(strengthMeter(text))
+			})
+
+		form.AddFormItem(password).
+			AddFormItem(meter).
 			AddTextArea("Comment", item.Comment, 25, 3, 0, func(text string) {
 				item.Comment = text
 			}).
+			AddButton("Generate", func() {
+				pwd, err := c.generatePassword()
+				if err != nil {
+					c.ShowError(err, KeyFormCredentials)
+					return
+				}
+				password.SetText(pwd)
+			}).
+			AddButton("Generator", func() {
+				c.showGenerator(KeyFormCredentials, func(pwd string) {
+					password.SetText(pwd)
+				})
+			}).
 			AddButton("Save", func() {
 				if item.ID == "" {
 					if err := c.Adapter.AddData(model.KeyCredentials, item); err != nil {
-						c.ShowMessage(err.Error(), KeyFormCredentials)
+						c.ShowError(err, KeyFormCredentials)
 						return
 					}
 				} else {
 					if err := c.Adapter.UpdateData(model.KeyCredentials, item); err != nil {
-						c.ShowMessage(err.Error(), KeyFormCredentials)
+						c.ShowError(err, KeyFormCredentials)
 						return
 					}
 					c.CurItem = item
@@ -0,0 +1,103 @@
+package pages
+
+import (
+	"context"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+const (
+	// delays between attempts to open the event stream
+	minEventsRetry = time.Second
+	maxEventsRetry = time.Minute
+)
+
+// watchEvents keeps the front page up to date with changes
+// made on other devices. It is expected to be called once
+// the user is logged in, subsequent calls do nothing.
+func (c *Constructor) watchEvents() {
+	if c.watching {
+		return
+	}
+
+	c.watching = true
+
+	go func() {
+		retry := minEventsRetry
+		reconnected := false
+
+		for {
+			events, err := c.Adapter.Events(context.Background())
+			if err != nil {
+				c.Logger.Debugf("failed to open event stream: %v", err)
+
+				time.Sleep(retry)
+
+				if retry *= 2; retry > maxEventsRetry {
+					retry = maxEventsRetry
+				}
+
+				continue
+			}
+
+			retry = minEventsRetry
+
+			// changes might have been missed while the stream was closed
+			if reconnected {
+				c.App.QueueUpdateDraw(func() { c.refresh(model.KeyLimit) })
+			}
+
+			for e := range events {
+				dataType := e.DataType
+				c.App.QueueUpdateDraw(func() { c.refresh(dataType) })
+			}
+
+			c.Logger.Debugf("event stream is closed")
+
+			reconnected = true
+		}
+	}()
+}
+
+// refresh rebuilds the front page in place if it shows items
+// of dataType, model.KeyLimit stands for any data type.
+// The menu is refreshed on any change since it shows counters.
+// Forms are never touched, so the user does not lose input.
+func (c *Constructor) refresh(dataType int) {
+	key, _ := c.Pages.GetFrontPage()
+
+	switch shown := listDataType(key); {
+	case key == KeyMenu:
+	case shown == model.KeyLimit:
+		return
+	case dataType != model.KeyLimit && shown != dataType:
+		return
+	}
+
+	c.Logger.Debugf("refreshing %v page", key)
+
+	cur := -1
+	if list, ok := c.lists[key]; ok {
+		cur = list.GetCurrentItem()
+	}
+
+	c.Build(key)
+	c.Pages.SwitchToPage(key)
+
+	if list, ok := c.lists[key]; ok && cur >= 0 {
+		list.SetCurrentItem(cur)
+	}
+}
+
+// keepList remembers list shown on page key, so the page
+// keeps selection when it is refreshed.
+func (c *Constructor) keepList(key string, list *tview.List) {
+	if c.lists == nil {
+		c.lists = make(map[string]*tview.List)
+	}
+
+	c.lists[key] = list
+}
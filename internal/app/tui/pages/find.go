@@ -0,0 +1,50 @@
+package pages
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// findText looks for query in text ignoring case, starting at
+// byte offset from and wrapping around the end of text.
+// It returns byte offsets of the match.
+func findText(text, query string, from int) (start, end int, ok bool) {
+	if query == "" {
+		return 0, 0, false
+	}
+
+	if from < 0 || from > len(text) {
+		from = 0
+	}
+
+	if start, end, ok = findFrom(text, query, from, len(text)); ok {
+		return start, end, true
+	}
+
+	return findFrom(text, query, 0, from)
+}
+
+// findFrom returns the first match starting within text[from:to].
+func findFrom(text, query string, from, to int) (start, end int, ok bool) {
+	n := utf8.RuneCountInString(query)
+
+	for i := from; i < to; {
+		// the match may be longer than query in bytes,
+		// as case folding keeps runes, not bytes
+		j, count := i, 0
+		for j < len(text) && count < n {
+			_, size := utf8.DecodeRuneInString(text[j:])
+			j += size
+			count++
+		}
+
+		if count == n && strings.EqualFold(text[i:j], query) {
+			return i, j, true
+		}
+
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+
+	return 0, 0, false
+}
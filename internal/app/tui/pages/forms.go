@@ -1,6 +1,8 @@
 package pages
 
 import (
+	"time"
+
 	"github.com/rivo/tview"
 	"go.uber.org/zap"
 
@@ -26,22 +28,71 @@ const (
 	KeyFormBinary       = "binary form"
 	KeyFormLoadBinary   = "binary load form"
 	KeyFormSaveBinary   = "binary save form"
+	KeyConflicts        = "conflicts"
+	KeyFormGenerator    = "password generator form"
+	KeyLock             = "lock"
+	KeyFormImport       = "import form"
+	KeyFormBackup       = "backup form"
+	KeyFormAttachments  = "attachments form"
 )
 
 type (
 	// Constructor creates new pages and add them to Pages.
 	// Provider is requred to use in event handlers.
 	Constructor struct {
-		Adapter adapter.Adapter
-		Pages   *tview.Pages
-		CurItem any
-		Logger  *zap.SugaredLogger
+		Adapter   adapter.Adapter
+		Sessions  SessionStore
+		Profiles  ProfileSwitcher
+		Clipboard Clipboard
+		App       *tview.Application
+		Pages     *tview.Pages
+		CurItem   any
+		Logger    *zap.SugaredLogger
+
+		// watching is set once the event stream is watched
+		watching bool
+		// unlocked is set while user's data is open
+		unlocked bool
+		// lists shown on pages by page key
+		lists map[string]*tview.List
+		// generator keeps password generator settings
+		generator generatorSettings
+	}
+
+	// SessionStore keeps the session user asked to remember.
+	SessionStore interface {
+		Load() (model.Session, error)
+		Save(model.Session) error
+		Delete() error
+	}
+
+	// ProfileSwitcher switches between server profiles
+	// of client config file.
+	ProfileSwitcher interface {
+		Profiles() []string
+		Profile() string
+		// Switch returns adapter and session store of profile name
+		Switch(name string) (adapter.Adapter, SessionStore, error)
+	}
+
+	// Clipboard copies secrets and clears them after Timeout.
+	Clipboard interface {
+		Copy(text string) error
+		Timeout() time.Duration
+		// Clear clears copied secret before timeout
+		Clear() error
+	}
+
+	// button is an action of list-page menu.
+	button struct {
+		label    string
+		selected func()
 	}
 
 	// listGenerator is builder for data type specific list-pages.
 	listGenerator struct {
 		*Constructor
-		btns         map[string]func()
+		btns         []button
 		detail       tview.Primitive
 		key          string
 		addItemFunc  func(any, *tview.List) error
@@ -75,13 +126,21 @@ func (c *Constructor) buildPrimitive(key string) tview.Primitive {
 	case KeyFormCards:
 		return c.cardsForm()
 	case KeyFormCVV:
-		return c.cardsCVVForm()
+		return c.cardsCVVForm(c.openCardForm)
 	case KeyFormBinary:
 		return c.binaryForm()
 	case KeyFormLoadBinary:
 		return c.binaryLoadForm()
 	case KeyFormSaveBinary:
 		return c.binarySaveForm()
+	case KeyConflicts:
+		return c.conflictsList()
+	case KeyLock:
+		return c.lockForm()
+	case KeyFormImport:
+		return c.importForm()
+	case KeyFormBackup:
+		return c.backupForm()
 	default:
 		return nil
 	}
@@ -130,6 +189,9 @@ func (lg listGenerator) build() tview.Primitive {
 	lflex := tview.NewFlex().
 		SetDirection(tview.FlexRow)
 
+	// rows marked with Space, set once the list is filled
+	var sel *selection
+
 	// Create buttons
 	menu := tview.NewFlex().
 		AddItem(tview.NewButton("Back").
@@ -139,25 +201,33 @@ func (lg listGenerator) build() tview.Primitive {
 				lg.Pages.SwitchToPage(KeyMenu)
 			}), 0, 1, false)
 
-	for k, v := range lg.btns {
-		menu.AddItem(tview.NewButton(k).
-			SetSelectedFunc(v), 0, 1, false)
+	for _, b := range lg.btns {
+		menu.AddItem(tview.NewButton(b.label).
+			SetSelectedFunc(b.selected), 0, 1, false)
 	}
 
+	menu.AddItem(tview.NewButton("Delete").
+		SetSelectedFunc(func() {
+			lg.deleteSelected(sel)
+		}), 0, 1, false)
+
 	// Fill the list
 	val, err := lg.Adapter.GetData(listDataType(lg.key))
 	if err != nil {
-		lg.ShowMessage(err.Error(), KeyMenu)
+		lg.ShowError(err, KeyMenu)
 		lg.Logger.Errorf("failed to get data: %v", err)
 		return nil
 	}
 
 	// Add list rows
 	if err = lg.addItemFunc(val, list); err != nil {
-		lg.ShowMessage(err.Error(), KeyMenu)
+		lg.ShowError(err, KeyMenu)
 	}
 
+	sel = newSelection(list, val)
+
 	list.SetSelectedFunc(lg.selectedFunc)
+	lg.keepList(lg.key, list)
 
 	// Compose the page
 	lflex.AddItem(list, 0, 1, true).
@@ -0,0 +1,131 @@
+package pages
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/pkg/passgen"
+)
+
+// meterColors are colors of strength scores.
+var meterColors = [passgen.MaxScore + 1]string{"red", "orange", "yellow", "green", "green"}
+
+// generatorSettings are kept between uses of the generator.
+type generatorSettings struct {
+	opts passgen.Options
+	// words is number of passphrase words,
+	// a password is generated if it's zero
+	words int
+}
+
+// generatePassword returns a password or passphrase
+// made with user's generator settings.
+func (c *Constructor) generatePassword() (string, error) {
+	if c.generator.opts == (passgen.Options{}) {
+		c.generator.opts = passgen.DefaultOptions
+	}
+
+	if c.generator.words > 0 {
+		return passgen.Passphrase(c.generator.words, "-")
+	}
+
+	return passgen.Password(c.generator.opts)
+}
+
+// showGenerator shows generator settings page with a preview
+// of generated password. The password is passed to use and
+// the focus is switched back to pageKey once user accepts it.
+func (c *Constructor) showGenerator(pageKey string, use func(password string)) {
+	if c.generator.opts == (passgen.Options{}) {
+		c.generator.opts = passgen.DefaultOptions
+	}
+
+	settings := c.generator
+	password := ""
+
+	preview := tview.NewTextView().SetLabel("Password").SetSize(1, 50)
+	meter := tview.NewTextView().SetLabel("Strength").SetSize(1, 50).SetDynamicColors(true)
+
+	generate := func() {
+		c.generator = settings
+
+		pwd, err := c.generatePassword()
+		if err != nil {
+			preview.SetText(err.Error())
+			meter.SetText("")
+			password = ""
+
+			return
+		}
+
+		password = pwd
+		preview.SetText(pwd)
+		meter.SetText(strengthMeter(pwd))
+	}
+
+	number := func(text string, _ rune) bool {
+		_, err := strconv.Atoi(text)
+
+		return text == "" || err == nil
+	}
+
+	form := tview.NewForm().
+		AddInputField("Length", strconv.Itoa(settings.opts.Length), 5, number, func(text string) {
+			settings.opts.Length, _ = strconv.Atoi(text)
+		}).
+		AddCheckbox("Lowercase", settings.opts.Lower, func(checked bool) {
+			settings.opts.Lower = checked
+		}).
+		AddCheckbox("Uppercase", settings.opts.Upper, func(checked bool) {
+			settings.opts.Upper = checked
+		}).
+		AddCheckbox("Digits", settings.opts.Digits, func(checked bool) {
+			settings.opts.Digits = checked
+		}).
+		AddCheckbox("Symbols", settings.opts.Symbols, func(checked bool) {
+			settings.opts.Symbols = checked
+		}).
+		AddCheckbox("Exclude ambiguous", settings.opts.ExcludeAmbiguous, func(checked bool) {
+			settings.opts.ExcludeAmbiguous = checked
+		}).
+		AddInputField("Passphrase words", strconv.Itoa(settings.words), 5, number, func(text string) {
+			settings.words, _ = strconv.Atoi(text)
+		}).
+		AddFormItem(preview).
+		AddFormItem(meter).
+		AddButton("Generate", generate).
+		AddButton("Use", func() {
+			if password == "" {
+				return
+			}
+
+			use(password)
+			c.Pages.SwitchToPage(pageKey)
+		}).
+		AddButton("Cancel", func() {
+			c.Pages.SwitchToPage(pageKey)
+		})
+
+	generate()
+
+	c.Pages.AddPage(KeyFormGenerator, form, true, false)
+	c.Pages.SwitchToPage(KeyFormGenerator)
+}
+
+// strengthMeter returns colored bar of password strength.
+func strengthMeter(password string) string {
+	if password == "" {
+		return ""
+	}
+
+	s := passgen.Estimate(password)
+
+	return fmt.Sprintf("[%v]%v[white]%v %v, %.0f bits",
+		meterColors[s.Score],
+		strings.Repeat("█", s.Score+1),
+		strings.Repeat("░", passgen.MaxScore-s.Score),
+		s, s.Bits)
+}
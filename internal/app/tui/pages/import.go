@@ -0,0 +1,86 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/importer"
+)
+
+// detectFormat is the format option that detects
+// the format by file extension.
+const detectFormat = "by file extension"
+
+// importForm imports export of another password manager.
+// Preview reports what would be imported, results of import
+// are shown below the form.
+func (c *Constructor) importForm() tview.Primitive {
+	var path, format string
+
+	report := tview.NewTextView().
+		SetScrollable(true)
+	report.SetBorder(true).
+		SetTitle("Report")
+
+	run := func(dryRun bool) {
+		f := format
+		if f == detectFormat {
+			f = ""
+		}
+
+		items, err := importer.ParseFile(path, f)
+		if err != nil {
+			c.ShowError(err, KeyFormImport)
+
+			return
+		}
+
+		res, err := importer.Import(c.Adapter, items, dryRun)
+		if err != nil {
+			c.ShowError(err, KeyFormImport)
+			c.Logger.Errorf("failed to import: %v", err)
+
+			return
+		}
+
+		lines := make([]string, 0, len(res.Results)+2)
+		for _, r := range res.Results {
+			lines = append(lines, r.String())
+		}
+
+		lines = append(lines, "", res.Summary())
+
+		report.SetText(strings.Join(lines, "\n")).
+			ScrollToBeginning()
+	}
+
+	formats := append([]string{detectFormat}, importer.Formats...)
+
+	form := tview.NewForm().
+		AddInputField("Path", path, 40, nil, func(text string) {
+			path = text
+		}).
+		AddDropDown("Format", formats, 0, func(option string, _ int) {
+			format = option
+		}).
+		AddButton("Preview", func() {
+			run(true)
+		}).
+		AddButton("Import", func() {
+			run(false)
+		}).
+		AddButton("Back", func() {
+			c.Build(KeyMenu)
+			c.Pages.SwitchToPage(KeyMenu)
+		})
+
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf("Import from %v", strings.Join(importer.Formats, ", ")))
+
+	return tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 9, 0, true).
+		AddItem(report, 0, 1, false)
+}
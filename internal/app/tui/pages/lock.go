@@ -0,0 +1,95 @@
+package pages
+
+import (
+	"github.com/rivo/tview"
+)
+
+// dataPages are pages that may show user's data.
+var dataPages = []string{
+	KeyMenu, KeyError, KeyInput,
+	KeyCredentials, KeyFormCredentials,
+	KeyText, KeyFormText,
+	KeyCards, KeyFormCards, KeyFormCVV,
+	KeyBinary, KeyFormBinary, KeyFormLoadBinary, KeyFormSaveBinary,
+	KeyConflicts, KeyFormGenerator, KeyFormImport, KeyFormBackup, KeyFormAttachments,
+}
+
+// Lock hides user's data behind the lock page until the password
+// is entered. Pages that show data are dropped along with the lists
+// kept for refresh and the current item, the key of local cache
+// is zeroed. It does nothing unless user is logged in.
+func (c *Constructor) Lock() {
+	if !c.unlocked {
+		return
+	}
+
+	switch key, _ := c.Pages.GetFrontPage(); key {
+	case KeyLoginForm, KeyRegistrationForm:
+		return
+	}
+
+	c.Logger.Debugf("locking after inactivity")
+
+	c.unlocked = false
+	c.forgetCurItem()
+	c.Adapter.Lock()
+
+	if err := c.Clipboard.Clear(); err != nil {
+		c.Logger.Errorf("failed to clear clipboard: %v", err)
+	}
+
+	for key, list := range c.lists {
+		list.Clear()
+		delete(c.lists, key)
+	}
+
+	for _, key := range dataPages {
+		c.Pages.RemovePage(key)
+	}
+
+	// forms are expected to exist, so they are rebuilt empty
+	c.Build(KeyFormCredentials)
+	c.Build(KeyFormText)
+	c.Build(KeyFormCards)
+	c.Build(KeyFormBinary)
+
+	c.Build(KeyLock)
+	c.Pages.SwitchToPage(KeyLock)
+}
+
+func (c *Constructor) lockForm() *tview.Form {
+	var password string
+
+	tInfo := tview.NewTextView().
+		SetText("Locked after inactivity, enter the password to unlock").
+		SetSize(1, 55)
+
+	form := tview.NewForm().
+		AddFormItem(tInfo).
+		AddPasswordField("password", "", 25, '*', func(text string) {
+			password = text
+		}).
+		AddButton("Unlock", func() {
+			if err := c.Adapter.Unlock(password); err != nil {
+				c.Logger.Debugf("failed to unlock: %v", err)
+				c.ShowError(err, KeyLock)
+
+				return
+			}
+
+			password = ""
+			c.unlocked = true
+
+			c.Build(KeyMenu)
+			c.Pages.SwitchToPage(KeyMenu)
+			// the page keeps the password typed in
+			c.Pages.RemovePage(KeyLock)
+		}).
+		AddButton("Log out", func() {
+			c.Pages.RemovePage(KeyLock)
+			c.logout()
+		})
+
+	// the password is typed in right away
+	return form.SetFocus(1)
+}
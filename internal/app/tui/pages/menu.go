@@ -13,13 +13,22 @@ func (c *Constructor) menu() *tview.List {
 
 	c.Logger.Debugf("focus on menu page")
 
+	c.Logger.Debugf("request storage usage")
+
+	usage, err := c.Adapter.Usage()
+	if err != nil {
+		// Usage is informational, so the menu is still usable without it
+		c.Logger.Errorf("failed to get storage usage: %v",
+			err)
+	}
+
 	for i := 0; i < model.KeyLimit; i++ {
 		c.Logger.Debugf("request count for %v",
 			model.GetItemTitle(i))
 
 		n, err := c.Adapter.Count(i)
 		if err != nil {
-			c.ShowMessage(err.Error(), KeyMenu)
+			c.ShowError(err, KeyMenu)
 			c.Logger.Errorf("failed to count items: %v",
 				err)
 		}
@@ -40,8 +49,13 @@ func (c *Constructor) menu() *tview.List {
 
 		c.Logger.Debugf("Adding menu item %v", i)
 
-		menu.AddItem(fmt.Sprintf("%v (%v)", title, n),
-			"",
+		mainText := fmt.Sprintf("%v (%v)", title, n)
+		if usage.ItemsLimit != 0 {
+			mainText = fmt.Sprintf("%v (%v of %v)", title, n, usage.ItemsLimit)
+		}
+
+		menu.AddItem(mainText,
+			usageText(usage, i),
 			rune(49+i),
 			func() {
 				c.Build(pageKey)
@@ -49,5 +63,92 @@ func (c *Constructor) menu() *tview.List {
 			})
 	}
 
+	total := fmt.Sprintf("Storage used: %v", formatBytes(usage.TotalBytes))
+	if usage.BytesLimit != 0 {
+		total = fmt.Sprintf("%v of %v", total, formatBytes(usage.BytesLimit))
+	}
+
+	menu.AddItem(total, "", 0, nil)
+
+	st := c.Adapter.Status()
+
+	menu.AddItem(syncText(st), "", 's', func() {
+		if err := c.Adapter.Sync(); err != nil {
+			c.ShowError(err, KeyMenu)
+			c.Logger.Errorf("failed to sync: %v", err)
+
+			return
+		}
+
+		c.Build(KeyMenu)
+		c.Pages.SwitchToPage(KeyMenu)
+	})
+
+	if st.Conflicts != 0 {
+		menu.AddItem(fmt.Sprintf("Conflicts (%v)", st.Conflicts),
+			"changes the server did not accept", 'c',
+			func() {
+				c.Build(KeyConflicts)
+				c.Pages.SwitchToPage(KeyConflicts)
+			})
+	}
+
+	menu.AddItem("Import", "from KeePass, Bitwarden or 1Password", 'i', func() {
+		c.Build(KeyFormImport)
+		c.Pages.SwitchToPage(KeyFormImport)
+	})
+
+	menu.AddItem("Backup", "export or restore encrypted archive", 'b', func() {
+		c.Build(KeyFormBackup)
+		c.Pages.SwitchToPage(KeyFormBackup)
+	})
+
+	menu.AddItem("Log out", "forget saved session", 'q', c.logout)
+
+	c.keepList(KeyMenu, menu)
+
 	return menu
 }
+
+// syncText returns a short description of local cache state.
+func syncText(st model.SyncStatus) string {
+	text := "Online"
+	if !st.Online {
+		text = "Offline"
+	}
+
+	if st.Pending != 0 {
+		text = fmt.Sprintf("%v, %v change(s) pending", text, st.Pending)
+	}
+
+	return fmt.Sprintf("%v - press to sync", text)
+}
+
+// usageText returns a short description of storage consumed
+// by items of dataType.
+func usageText(usage model.Usage, dataType int) string {
+	for _, e := range usage.Entries {
+		if e.DataType == dataType {
+			return formatBytes(e.Bytes)
+		}
+	}
+
+	return ""
+}
+
+// formatBytes returns human readable representation of size.
+func formatBytes(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -1,8 +1,13 @@
 package pages
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
 )
 
 // ShowMessage generates a new modal window with given message
@@ -22,6 +27,57 @@ func (c *Constructor) ShowMessage(message string, pageKey string) {
 	c.Pages.SwitchToPage(KeyError)
 }
 
+// ShowConfirm asks user to confirm an action described by message.
+// confirmed is called if user agrees, otherwise focus
+// is switched back to pageKey.
+func (c *Constructor) ShowConfirm(message string, pageKey string, confirmed func()) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonIndex == 0 {
+				confirmed()
+
+				return
+			}
+
+			c.Pages.SwitchToPage(pageKey)
+		})
+
+	c.Pages.AddPage(KeyError, modal, false, false)
+	c.Pages.SwitchToPage(KeyError)
+}
+
+// ShowError shows err in a modal window just like ShowMessage.
+// If the session is no longer valid the button leads
+// to the login page instead of pageKey.
+func (c *Constructor) ShowError(err error, pageKey string) {
+	if errors.Is(err, model.ErrInvalidSession) {
+		c.ShowMessage("Session has expired, please log in again", KeyLoginForm)
+
+		return
+	}
+
+	c.ShowMessage(err.Error(), pageKey)
+}
+
+// copySecret copies text to the clipboard and tells user
+// when it is cleared. what names the text in the message.
+func (c *Constructor) copySecret(what, text, pageKey string) {
+	if text == "" {
+		return
+	}
+
+	if err := c.Clipboard.Copy(text); err != nil {
+		c.ShowError(err, pageKey)
+
+		return
+	}
+
+	c.ShowMessage(fmt.Sprintf("%v copied, the clipboard is cleared in %v",
+		what, c.Clipboard.Timeout()), pageKey)
+}
+
 // ShowInput generates a new page with input field
 func (c *Constructor) ShowInput(message string, result *string, pageKey string) {
 	input := tview.NewInputField().
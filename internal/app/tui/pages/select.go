@@ -0,0 +1,166 @@
+package pages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/usa4ev/ghostorange/internal/app/model"
+)
+
+// markPrefix is put before names of marked rows.
+const markPrefix = "[::b]*[::-] "
+
+// selection keeps rows of a list page marked with Space,
+// so actions apply to many items at once.
+type selection struct {
+	list   *tview.List
+	ids    []string
+	marked map[int]bool
+}
+
+// newSelection lets user mark rows of list holding items of val.
+func newSelection(list *tview.List, val any) *selection {
+	s := &selection{
+		list:   list,
+		ids:    itemIDs(val),
+		marked: make(map[int]bool),
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == ' ' {
+			s.toggle(list.GetCurrentItem())
+
+			return nil
+		}
+
+		return event
+	})
+
+	return s
+}
+
+// toggle marks or unmarks row index and moves to the next row.
+func (s *selection) toggle(index int) {
+	if index < 0 || index >= len(s.ids) {
+		return
+	}
+
+	s.marked[index] = !s.marked[index]
+
+	main, secondary := s.list.GetItemText(index)
+	main = strings.TrimPrefix(main, markPrefix)
+
+	if s.marked[index] {
+		main = markPrefix + main
+	}
+
+	s.list.SetItemText(index, main, secondary)
+
+	if index+1 < s.list.GetItemCount() {
+		s.list.SetCurrentItem(index + 1)
+	}
+}
+
+// selected returns IDs of marked items, or the current one
+// if nothing is marked.
+func (s *selection) selected() []string {
+	indexes := make([]int, 0, len(s.marked))
+
+	for i, ok := range s.marked {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) == 0 && s.list.GetItemCount() != 0 {
+		indexes = append(indexes, s.list.GetCurrentItem())
+	}
+
+	sort.Ints(indexes)
+
+	ids := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		if i < len(s.ids) {
+			ids = append(ids, s.ids[i])
+		}
+	}
+
+	return ids
+}
+
+// deleteSelected asks to confirm and deletes the selected items
+// in one batch.
+func (lg listGenerator) deleteSelected(sel *selection) {
+	ids := sel.selected()
+	if len(ids) == 0 {
+		return
+	}
+
+	lg.ShowConfirm(fmt.Sprintf("Delete %v item(s)?", len(ids)), lg.key, func() {
+		ops := make([]model.BatchOp, len(ids))
+		for i, id := range ids {
+			ops[i] = model.BatchOp{Op: model.BatchDelete, DataType: listDataType(lg.key), ID: id}
+		}
+
+		errs, err := lg.Adapter.Batch(ops)
+		if err != nil {
+			lg.ShowError(err, lg.key)
+			lg.Logger.Errorf("failed to delete items: %v", err)
+
+			return
+		}
+
+		lg.forgetCurItem()
+		lg.Build(lg.key)
+		lg.Pages.SwitchToPage(lg.key)
+
+		var (
+			failed int
+			first  error
+		)
+
+		for _, err := range errs {
+			if err != nil {
+				if first == nil {
+					first = err
+				}
+				failed++
+			}
+		}
+
+		if failed != 0 {
+			lg.ShowMessage(fmt.Sprintf("%v of %v item(s) were not deleted: %v",
+				failed, len(ids), first), lg.key)
+		}
+	})
+}
+
+// itemIDs returns IDs of items of a list in the order they are shown.
+func itemIDs(val any) []string {
+	var ids []string
+
+	switch v := val.(type) {
+	case []model.ItemCredentials:
+		for _, item := range v {
+			ids = append(ids, item.ID)
+		}
+	case []model.ItemText:
+		for _, item := range v {
+			ids = append(ids, item.ID)
+		}
+	case []model.ItemCard:
+		for _, item := range v {
+			ids = append(ids, item.ID)
+		}
+	case []model.ItemBinary:
+		for _, item := range v {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	return ids
+}
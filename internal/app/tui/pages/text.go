@@ -3,9 +3,11 @@ package pages
 import (
 	"fmt"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
 	"github.com/usa4ev/ghostorange/internal/app/model"
+	"github.com/usa4ev/ghostorange/internal/app/tui/markdown"
 )
 
 // Returns new list-page generator with all it needs to build
@@ -13,20 +15,36 @@ import (
 func (c *Constructor) textList() listGenerator {
 	rflex := tview.NewFlex().
 		SetDirection(tview.FlexRow)
-	txtView := tview.NewTextView()
+	txtView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true)
+
+	// attachments of the note, Enter saves one to file
+	attList := tview.NewList().ShowSecondaryText(false)
+	attList.SetBorder(true).SetTitle("Attachments")
+
+	var attachments []model.ItemBinary
+
+	attList.SetSelectedFunc(func(index int, name string, second_name string, shortcut rune) {
+		c.CurItem = attachments[index]
+		// save form goes back to binary list
+		c.Build(KeyBinary)
+		c.Build(KeyFormSaveBinary)
+		c.Pages.SwitchToPage(KeyFormSaveBinary)
+	})
 
-	buttons := make(map[string]func())
+	var buttons []button
 
-	buttons["Add"] = func() {
+	buttons = append(buttons, button{"Add", func() {
 		c.forgetCurItem()
 		c.Pages.SwitchToPage(KeyFormText)
-	}
+	}})
 
-	buttons["Edit"] = func() {
+	buttons = append(buttons, button{"Edit", func() {
 		if val, ok := c.CurItem.(model.ItemText); ok && val.ID != "" {
 			c.Pages.SwitchToPage(KeyFormText)
 		}
-	}
+	}})
 
 	var data []model.ItemText
 
@@ -48,7 +66,26 @@ func (c *Constructor) textList() listGenerator {
 	selectedF := func(index int, name string, second_name string, shortcut rune) {
 
 		item := data[index]
-		txtView.Clear().SetText(item.Text).SetTitle(item.Name)
+		txtView.Clear().SetText(markdown.Render(item.Text)).SetTitle(item.Name)
+		txtView.ScrollToBeginning()
+
+		var err error
+		attachments, err = c.attached(item.Attachments)
+		if err != nil {
+			c.Logger.Errorf("failed to get attachments: %v", err)
+		}
+
+		attList.Clear()
+		for _, a := range attachments {
+			attList.AddItem(fileName(a), "", 0, nil)
+		}
+
+		// the list is hidden unless the note has attachments
+		height := 0
+		if len(attachments) > 0 {
+			height = len(attachments) + 2
+		}
+		rflex.ResizeItem(attList, height, 0)
 
 		c.CurItem = item
 
@@ -56,6 +93,7 @@ func (c *Constructor) textList() listGenerator {
 	}
 
 	rflex.AddItem(txtView, 0, 1, false).
+		AddItem(attList, 0, 0, false).
 		SetBlurFunc(c.forgetCurItem)
 
 	return listGenerator{
@@ -79,16 +117,88 @@ func (c *Constructor) textForm() *tview.Form {
 		}
 		c.Logger.Debugf("filling the form using item %v", item)
 
+		// Markdown source of the note, wrapped on words
+		editor := tview.NewTextArea().
+			SetLabel("Text").
+			SetText(item.Text, false).
+			SetSize(15, 0).
+			SetWrap(true).
+			SetWordWrap(true)
+		editor.SetChangedFunc(func() {
+			item.Text = editor.GetText()
+		})
+
+		query := ""
+		find := tview.NewInputField().
+			SetLabel("Find").
+			SetFieldWidth(25).
+			SetChangedFunc(func(text string) {
+				query = text
+			})
+
+		// findNext selects the next match of query after the cursor
+		// of the editor and moves the focus there
+		findNext := func() {
+			if query == "" {
+				return
+			}
+
+			_, _, from := editor.GetSelection()
+
+			start, end, ok := findText(editor.GetText(), query, from)
+			if !ok {
+				c.ShowMessage(fmt.Sprintf("%q is not found", query), KeyFormText)
+				return
+			}
+
+			editor.Select(start, end)
+			form.SetFocus(form.GetFormItemIndex("Text"))
+			c.App.SetFocus(form)
+		}
+
+		// Ctrl+F in the editor goes to search, Enter there finds next match
+		editor.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyCtrlF {
+				form.SetFocus(form.GetFormItemIndex("Find"))
+				c.App.SetFocus(form)
+
+				return nil
+			}
+
+			return event
+		})
+		find.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEnter {
+				findNext()
+
+				return nil
+			}
+
+			return event
+		})
+
+		attached := tview.NewTextView().
+			SetLabel("Attachments").
+			SetSize(2, 50).
+			SetText(c.attachmentNames(item.Attachments))
+
 		form.AddTextView("ID", item.ID, 50, 1, false, false).
 			AddInputField("Name", item.Name, 25, nil, func(text string) {
 				item.Name = text
 			}).
-			AddTextArea("Text", item.Text, 50, 10, 0, func(text string) {
-				item.Text = text
-			}).
+			AddFormItem(editor).
+			AddFormItem(find).
 			AddTextArea("Comment", item.Comment, 25, 3, 0, func(text string) {
 				item.Comment = text
 			}).
+			AddFormItem(attached).
+			AddButton("Find next", findNext).
+			AddButton("Attachments", func() {
+				c.showAttachments(KeyFormText, item.Attachments, func(ids []string) {
+					item.Attachments = ids
+					attached.SetText(c.attachmentNames(ids))
+				})
+			}).
 			AddButton("Save", func() {
 				var err error
 				if item.ID == "" {
@@ -1,52 +1,163 @@
 package tui
 
 import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"go.uber.org/zap"
 
 	"github.com/usa4ev/ghostorange/internal/app/adapter"
+	"github.com/usa4ev/ghostorange/internal/app/tui/clipboard"
 	"github.com/usa4ev/ghostorange/internal/app/tui/pages"
 )
 
+// DefaultLockTimeout is the time of inactivity
+// the application is locked after.
+const DefaultLockTimeout = 5 * time.Minute
+
 type (
 	Application struct {
 		tviewApp *tview.Application
 		adapter  adapter.Adapter
 		pages    *tview.Pages
+		clip     *clipboard.Clipboard
+
+		// lock locks the application after lockTimeout
+		// passes since lastInput
+		lock        func()
+		lockTimeout time.Duration
+		mu          sync.Mutex
+		lastInput   time.Time
+		idleTimer   *time.Timer
 	}
 )
 
-func New(adapter adapter.Adapter, logger *zap.SugaredLogger) *Application {
+// New returns TUI application. If a saved session is resumed
+// the application starts at the menu, otherwise at the login page.
+// Profiles are offered on the login page unless profiles is nil.
+// Copied secrets are cleared from the clipboard after clipTimeout.
+// After lockTimeout of no input the application is locked, zero
+// timeout stands for DefaultLockTimeout, negative disables locking.
+func New(adapter adapter.Adapter, sessions pages.SessionStore, profiles pages.ProfileSwitcher,
+	clipTimeout, lockTimeout time.Duration, logger *zap.SugaredLogger,
+) *Application {
 
 	app := tview.NewApplication()
 
+	// escape sequences are written to the terminal
+	// in the event loop, between screen updates
+	clip := clipboard.New(os.Stdout, clipTimeout, func(f func()) {
+		app.QueueUpdate(f)
+	})
+
 	builder := pages.Constructor{
-		Adapter: adapter,
-		Pages:   tview.NewPages(),
-		Logger:  logger,
+		Adapter:   adapter,
+		Sessions:  sessions,
+		Profiles:  profiles,
+		Clipboard: clip,
+		App:       app,
+		Pages:     tview.NewPages(),
+		Logger:    logger,
 	}
 
 	// Create ui pages
 	builder.Build(pages.KeyLoginForm)
 	builder.Build(pages.KeyRegistrationForm)
-	builder.Build(pages.KeyMenu)
-
 	builder.Build(pages.KeyFormCredentials)
 	builder.Build(pages.KeyFormText)
 	builder.Build(pages.KeyFormCards)
 	builder.Build(pages.KeyFormBinary)
 
+	start := pages.KeyLoginForm
+	if builder.ResumeSession() {
+		start = pages.KeyMenu
+	}
+
+	builder.Build(pages.KeyMenu)
+
 	app.SetRoot(builder.GetPages().
-		SwitchToPage(pages.KeyLoginForm), true).
+		SwitchToPage(start), true).
 		EnableMouse(true)
 
-	return &Application{
-		tviewApp: app,
-		adapter:  adapter,
-		pages:    builder.GetPages(),
+	if lockTimeout == 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+
+	a := &Application{
+		tviewApp:    app,
+		adapter:     adapter,
+		pages:       builder.GetPages(),
+		clip:        clip,
+		lock:        builder.Lock,
+		lockTimeout: lockTimeout,
 	}
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		a.touch()
+
+		return event
+	}).SetMouseCapture(func(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
+		a.touch()
+
+		return event, action
+	})
+
+	return a
 }
 
 func (app *Application) Run() error {
-	return app.tviewApp.Run()
+	if app.lockTimeout > 0 {
+		app.touch()
+
+		app.mu.Lock()
+		app.idleTimer = time.AfterFunc(app.lockTimeout, app.checkIdle)
+		app.mu.Unlock()
+
+		defer func() {
+			app.mu.Lock()
+			app.idleTimer.Stop()
+			app.mu.Unlock()
+		}()
+	}
+
+	err := app.tviewApp.Run()
+
+	// secrets must not outlive the application
+	if cerr := app.clip.Clear(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// touch records user's input.
+func (app *Application) touch() {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.lastInput = time.Now()
+}
+
+// checkIdle locks the application if there has been no input
+// for lockTimeout, otherwise it checks again when it may pass.
+func (app *Application) checkIdle() {
+	app.mu.Lock()
+
+	idle := time.Since(app.lastInput)
+	if idle < app.lockTimeout {
+		app.idleTimer.Reset(app.lockTimeout - idle)
+		app.mu.Unlock()
+
+		return
+	}
+
+	app.idleTimer.Reset(app.lockTimeout)
+	app.mu.Unlock()
+
+	// the event loop takes the mutex on input,
+	// so it is not held while queueing
+	app.tviewApp.QueueUpdateDraw(app.lock)
 }
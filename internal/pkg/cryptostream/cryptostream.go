@@ -0,0 +1,300 @@
+// Package cryptostream encrypts streams with a passphrase. The key is
+// derived from the passphrase with argon2id, the stream is split into
+// chunks sealed with AES-GCM, so it's neither loaded into memory whole
+// nor read unauthenticated.
+//
+// A stream starts with a header that holds argon2 parameters, salt and
+// a random nonce prefix. Every chunk is preceded by a flag, that marks
+// the last chunk, and the ciphertext length. The nonce of a chunk is
+// the nonce prefix followed by the chunk number and the flag, the
+// header is authenticated along with every chunk. Reordered, dropped
+// or appended chunks fail to decrypt, a stream cut off before the
+// last chunk is reported as truncated.
+package cryptostream
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/usa4ev/ghostorange/internal/pkg/argon2hash"
+)
+
+// ChunkSize is the size of plain text chunks.
+const ChunkSize = 64 << 10
+
+const (
+	version     = 1
+	saltSize    = 16
+	prefixSize  = 7
+	keySize     = 32
+	overhead    = 16
+	flagMore    = 0
+	flagLast    = 1
+	chunkHeader = 5
+	// maxMemory limits argon2 memory, in KiB, a stream may
+	// ask for, so a forged header does not exhaust memory
+	maxMemory = 1 << 20
+)
+
+var magic = []byte("GOSTREAM")
+
+var (
+	// ErrFormat is returned when the stream is not made by Writer.
+	ErrFormat = errors.New("not an encrypted stream")
+	// ErrDecrypt is returned when a chunk fails to decrypt.
+	ErrDecrypt = errors.New("wrong passphrase or damaged stream")
+	// ErrTruncated is returned when the stream ends before its last chunk.
+	ErrTruncated = errors.New("stream is truncated")
+)
+
+type (
+	// Writer encrypts what is written to it. Close must be
+	// called to write the last chunk.
+	Writer struct {
+		w      io.Writer
+		aead   cipher.AEAD
+		header []byte
+		prefix []byte
+		buf    []byte
+		n      uint32
+		closed bool
+	}
+
+	// Reader decrypts stream made by Writer.
+	Reader struct {
+		r      io.Reader
+		aead   cipher.AEAD
+		header []byte
+		prefix []byte
+		buf    []byte
+		n      uint32
+		last   bool
+	}
+)
+
+// NewWriter writes the header of a new stream to w
+// and returns Writer that encrypts with passphrase.
+func NewWriter(w io.Writer, passphrase string) (*Writer, error) {
+	p := argon2hash.DefaultParams()
+
+	header := make([]byte, 0, len(magic)+10+saltSize+prefixSize)
+	header = append(header, magic...)
+	header = append(header, version)
+	header = binary.BigEndian.AppendUint32(header, p.Memory)
+	header = binary.BigEndian.AppendUint32(header, p.Iterations)
+	header = append(header, p.Parallelism)
+
+	random := make([]byte, saltSize+prefixSize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	header = append(header, random...)
+
+	aead, err := newAEAD(passphrase, random[:saltSize], p.Memory, p.Iterations, p.Parallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: random[saltSize:],
+		buf:    make([]byte, 0, ChunkSize),
+	}, nil
+}
+
+// Write encrypts p, full chunks are written right away.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed stream")
+	}
+
+	written := 0
+
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+
+		// a full chunk is kept until more data comes,
+		// it may turn out to be the last one
+		if len(w.buf) == cap(w.buf) && len(p) > 0 {
+			if err := w.flush(flagMore); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close writes the last chunk, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	return w.flush(flagLast)
+}
+
+func (w *Writer) flush(flag byte) error {
+	sealed := w.aead.Seal(nil, nonce(w.prefix, w.n, flag), w.buf, w.header)
+
+	var head [chunkHeader]byte
+	head[0] = flag
+	binary.BigEndian.PutUint32(head[1:], uint32(len(sealed)))
+
+	if _, err := w.w.Write(head[:]); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(sealed); err != nil {
+		return err
+	}
+
+	w.buf = w.buf[:0]
+	w.n++
+
+	return nil
+}
+
+// NewReader reads the header of stream r and returns Reader
+// that decrypts it with passphrase. A wrong passphrase is
+// reported by the first Read.
+func NewReader(r io.Reader, passphrase string) (*Reader, error) {
+	header := make([]byte, len(magic)+10+saltSize+prefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrFormat
+		}
+
+		return nil, err
+	}
+
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, ErrFormat
+	}
+
+	rest := header[len(magic):]
+	if rest[0] != version {
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrFormat, rest[0])
+	}
+
+	memory := binary.BigEndian.Uint32(rest[1:])
+	iterations := binary.BigEndian.Uint32(rest[5:])
+	parallelism := rest[9]
+	salt := rest[10 : 10+saltSize]
+
+	if memory == 0 || memory > maxMemory || iterations == 0 || iterations > 16 || parallelism == 0 {
+		return nil, fmt.Errorf("%w: bad key derivation parameters", ErrFormat)
+	}
+
+	aead, err := newAEAD(passphrase, salt, memory, iterations, parallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		prefix: rest[10+saltSize:],
+	}, nil
+}
+
+// Read reads decrypted data.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.last {
+			return 0, io.EOF
+		}
+
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// next reads and decrypts the next chunk.
+func (r *Reader) next() error {
+	var head [chunkHeader]byte
+	if _, err := io.ReadFull(r.r, head[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+
+		return err
+	}
+
+	flag, size := head[0], binary.BigEndian.Uint32(head[1:])
+	if (flag != flagMore && flag != flagLast) || size < overhead || size > ChunkSize+overhead {
+		return ErrDecrypt
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+
+		return err
+	}
+
+	plain, err := r.aead.Open(sealed[:0], nonce(r.prefix, r.n, flag), sealed, r.header)
+	if err != nil {
+		return ErrDecrypt
+	}
+
+	r.buf = plain
+	r.n++
+	r.last = flag == flagLast
+
+	return nil
+}
+
+func newAEAD(passphrase string, salt []byte, memory, iterations uint32, parallelism uint8) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), salt, iterations, memory, parallelism, keySize)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aesgcm: %w", err)
+	}
+
+	return aead, nil
+}
+
+// nonce returns nonce of chunk n.
+func nonce(prefix []byte, n uint32, flag byte) []byte {
+	res := make([]byte, 0, prefixSize+5)
+	res = append(res, prefix...)
+	res = binary.BigEndian.AppendUint32(res, n)
+
+	return append(res, flag)
+}
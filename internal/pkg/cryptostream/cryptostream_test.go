@@ -0,0 +1,90 @@
+package cryptostream
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encrypt(t *testing.T, plain []byte, passphrase string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, passphrase)
+	require.NoError(t, err)
+
+	// odd writes cross chunk boundaries
+	for p := plain; len(p) > 0; {
+		n := 1000
+		if n > len(p) {
+			n = len(p)
+		}
+
+		_, err := w.Write(p[:n])
+		require.NoError(t, err)
+
+		p = p[n:]
+	}
+
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func decrypt(stream []byte, passphrase string) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(stream), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 10, ChunkSize, 2*ChunkSize + 7} {
+		plain := make([]byte, size)
+		_, err := rand.Read(plain)
+		require.NoError(t, err)
+
+		stream := encrypt(t, plain, "correct horse")
+
+		res, err := decrypt(stream, "correct horse")
+		require.NoError(t, err, "size %v", size)
+		assert.Equal(t, plain, res, "size %v", size)
+
+		_, err = decrypt(stream, "wrong horse")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	plain := bytes.Repeat([]byte("secret "), ChunkSize/3)
+	stream := encrypt(t, plain, "pass")
+
+	_, err := decrypt(stream[:len(stream)-chunkHeader-overhead-10], "pass")
+	assert.ErrorIs(t, err, ErrTruncated, "cut off stream")
+
+	// the first chunk alone is not the last one
+	first := len(magic) + 10 + saltSize + prefixSize + chunkHeader + ChunkSize + overhead
+	_, err = decrypt(stream[:first], "pass")
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	flipped := append([]byte(nil), stream...)
+	flipped[first-1] ^= 1
+	_, err = decrypt(flipped, "pass")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// header is authenticated
+	salted := append([]byte(nil), stream...)
+	salted[len(magic)+10] ^= 1
+	_, err = decrypt(salted, "pass")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = decrypt([]byte("plain text, not a stream at all"), "pass")
+	assert.ErrorIs(t, err, ErrFormat)
+}
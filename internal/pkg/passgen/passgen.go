@@ -0,0 +1,180 @@
+// Package passgen generates random passwords and diceware
+// passphrases and estimates password strength.
+//
+// Passphrases are made of words of the embedded list of 4096
+// common English words, every word adds 12 bits of entropy.
+package passgen
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Character classes passwords are made of. Quotes, backslash and
+// backtick are left out of symbols, so that passwords can be pasted
+// into shell and config files as they are.
+const (
+	Lower   = "abcdefghijklmnopqrstuvwxyz"
+	Upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits  = "0123456789"
+	Symbols = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	// Ambiguous characters are easy to confuse with each other.
+	Ambiguous = "Il1|O0o"
+)
+
+// Limits of generated passwords and passphrases.
+const (
+	MaxLength = 128
+	MaxWords  = 32
+)
+
+// DefaultWords is the number of words of passphrase, 72 bits.
+const DefaultWords = 6
+
+var (
+	ErrNoClasses = errors.New("no character classes selected")
+	ErrLength    = fmt.Errorf("length must be from number of classes to %v", MaxLength)
+	ErrWords     = fmt.Errorf("number of words must be from 1 to %v", MaxWords)
+)
+
+//go:embed wordlist.txt
+var wordlistData string
+
+var wordlist = strings.Fields(wordlistData)
+
+// Options configure generated password.
+type Options struct {
+	Length  int
+	Lower   bool
+	Upper   bool
+	Digits  bool
+	Symbols bool
+	// ExcludeAmbiguous leaves Ambiguous characters out.
+	ExcludeAmbiguous bool
+}
+
+// DefaultOptions are options of a 20 characters password
+// of all character classes.
+var DefaultOptions = Options{Length: 20, Lower: true, Upper: true, Digits: true, Symbols: true}
+
+// Password returns a random password. It has at least
+// one character of every selected class.
+func Password(opts Options) (string, error) {
+	classes := opts.classes()
+	if len(classes) == 0 {
+		return "", ErrNoClasses
+	}
+
+	if opts.Length < len(classes) || opts.Length > MaxLength {
+		return "", ErrLength
+	}
+
+	all := strings.Join(classes, "")
+	pwd := make([]byte, 0, opts.Length)
+
+	for _, class := range classes {
+		c, err := pick(class)
+		if err != nil {
+			return "", err
+		}
+
+		pwd = append(pwd, c)
+	}
+
+	for len(pwd) < opts.Length {
+		c, err := pick(all)
+		if err != nil {
+			return "", err
+		}
+
+		pwd = append(pwd, c)
+	}
+
+	// characters of every class are at the start, shuffle them
+	for i := len(pwd) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+
+		pwd[i], pwd[j] = pwd[j], pwd[i]
+	}
+
+	return string(pwd), nil
+}
+
+// Passphrase returns words random words of the wordlist joined by sep.
+func Passphrase(words int, sep string) (string, error) {
+	if words < 1 || words > MaxWords {
+		return "", ErrWords
+	}
+
+	res := make([]string, words)
+
+	for i := range res {
+		n, err := randInt(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+
+		res[i] = wordlist[n]
+	}
+
+	return strings.Join(res, sep), nil
+}
+
+func (opts Options) classes() []string {
+	var classes []string
+
+	for _, class := range []struct {
+		on    bool
+		chars string
+	}{
+		{opts.Lower, Lower},
+		{opts.Upper, Upper},
+		{opts.Digits, Digits},
+		{opts.Symbols, Symbols},
+	} {
+		if !class.on {
+			continue
+		}
+
+		chars := class.chars
+		if opts.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(Ambiguous, r) {
+					return -1
+				}
+
+				return r
+			}, chars)
+		}
+
+		classes = append(classes, chars)
+	}
+
+	return classes
+}
+
+func pick(chars string) (byte, error) {
+	n, err := randInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+
+	return chars[n], nil
+}
+
+func randInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,99 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		classes []string
+		err     error
+	}{
+		{name: "default", opts: DefaultOptions, classes: []string{Lower, Upper, Digits, Symbols}},
+		{name: "digits", opts: Options{Length: 6, Digits: true}, classes: []string{Digits}},
+		{name: "no ambiguous", opts: Options{Length: 64, Lower: true, Upper: true, Digits: true, ExcludeAmbiguous: true},
+			classes: []string{Lower, Upper, Digits}},
+		{name: "no classes", opts: Options{Length: 20}, err: ErrNoClasses},
+		{name: "too short", opts: Options{Length: 3, Lower: true, Upper: true, Digits: true, Symbols: true}, err: ErrLength},
+		{name: "too long", opts: Options{Length: MaxLength + 1, Lower: true}, err: ErrLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pwd, err := Password(tt.opts)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, pwd, tt.opts.Length)
+
+			for _, class := range tt.classes {
+				assert.True(t, strings.ContainsAny(pwd, class), "every class is used")
+			}
+
+			if tt.opts.ExcludeAmbiguous {
+				assert.False(t, strings.ContainsAny(pwd, Ambiguous))
+			}
+
+			assert.Equal(t, "", strings.Trim(pwd, strings.Join(tt.classes, "")), "only selected classes are used")
+		})
+	}
+
+	a, _ := Password(DefaultOptions)
+	b, _ := Password(DefaultOptions)
+	assert.NotEqual(t, a, b)
+}
+
+func TestPassphrase(t *testing.T) {
+	assert.Len(t, wordlist, 4096)
+
+	pwd, err := Passphrase(DefaultWords, "-")
+	require.NoError(t, err)
+
+	parts := strings.Split(pwd, "-")
+	assert.Len(t, parts, DefaultWords)
+
+	for _, p := range parts {
+		assert.True(t, words[p])
+	}
+
+	_, err = Passphrase(0, "-")
+	assert.ErrorIs(t, err, ErrWords)
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password string
+		score    int
+	}{
+		{"", 0},
+		{"aaaaaaaaaaaaaaaa", 0},
+		{"1234567890", 0},
+		{"password", 0},
+		{"Tr0ub4dor", 2},
+		{"abandon-zombie-absent", 2},
+		{"Kq8#mZ2!vR7$wL4^", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.Equal(t, tt.score, Estimate(tt.password).Score)
+		})
+	}
+
+	pwd, err := Passphrase(DefaultWords, " ")
+	require.NoError(t, err)
+
+	s := Estimate(pwd)
+	assert.InDelta(t, 72, s.Bits, 0.001, "passphrase is estimated by number of words")
+	assert.Equal(t, "strong", s.String())
+}
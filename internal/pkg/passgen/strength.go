@@ -0,0 +1,125 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Strength is an estimate of password strength.
+type Strength struct {
+	// Bits is estimated entropy of the password.
+	Bits float64
+	// Score ranges from 0 (very weak) to 4 (very strong).
+	Score int
+}
+
+// MaxScore is Score of the strongest passwords.
+const MaxScore = 4
+
+var (
+	labels = [MaxScore + 1]string{"very weak", "weak", "fair", "strong", "very strong"}
+	// thresholds are minimal Bits of scores 1 to 4
+	thresholds = [MaxScore]float64{28, 36, 60, 80}
+)
+
+var (
+	words    = make(map[string]bool, len(wordlist))
+	wordBits = math.Log2(float64(len(wordlist)))
+)
+
+func init() {
+	for _, w := range wordlist {
+		words[w] = true
+	}
+}
+
+// Estimate estimates strength of password. Passwords are assumed
+// to be picked from all characters of the classes they use.
+// Repeated and sequential characters, like aaa or 123, add almost
+// nothing. Passphrases of the wordlist are estimated by number of
+// words, and so is a single word.
+func Estimate(password string) Strength {
+	bits := charBits(password)
+
+	parts := strings.FieldsFunc(strings.ToLower(password), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	known := len(parts) > 0
+	for _, p := range parts {
+		known = known && words[p]
+	}
+
+	if known {
+		bits = math.Min(bits, float64(len(parts))*wordBits)
+	}
+
+	s := Strength{Bits: bits}
+	for s.Score < MaxScore && bits >= thresholds[s.Score] {
+		s.Score++
+	}
+
+	return s
+}
+
+// String returns human readable score, e.g. "strong".
+func (s Strength) String() string {
+	return labels[s.Score]
+}
+
+func charBits(password string) float64 {
+	var lower, upper, digits, symbols, other bool
+
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digits = true
+		case r < unicode.MaxASCII:
+			symbols = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+
+	for _, class := range []struct {
+		used bool
+		size int
+	}{
+		{lower, len(Lower)},
+		{upper, len(Upper)},
+		{digits, len(Digits)},
+		{symbols, 33},
+		{other, 100},
+	} {
+		if class.used {
+			pool += class.size
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	perChar := math.Log2(float64(pool))
+	bits := 0.0
+	prev := rune(-2)
+
+	for _, r := range password {
+		if d := r - prev; d >= -1 && d <= 1 {
+			bits++
+		} else {
+			bits += perChar
+		}
+
+		prev = r
+	}
+
+	return bits
+}
@@ -0,0 +1,82 @@
+// Package redact removes secrets like passwords, CVV codes and
+// card numbers from data before it gets logged.
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const Placeholder = "[REDACTED]"
+
+var (
+	// keys of JSON objects that hold secrets
+	secretKeys = map[string]struct{}{
+		"password":    {},
+		"pwd":         {},
+		"cvv":         {},
+		"cvv_hash":    {},
+		"number":      {},
+		"full_number": {},
+		"token":       {},
+	}
+
+	// card numbers are 12 to 19 digits long, optionally
+	// separated with spaces or dashes
+	reCardNumber = regexp.MustCompile(`\b\d(?:[ -]?\d){11,18}\b`)
+
+	// key=value and "key":"value" pairs that hold secrets
+	reSecretPair = regexp.MustCompile(
+		`(?i)("?(?:password|pwd|cvv|cvv_hash|full_number|token)"?\s*[:=]\s*)("[^"]*"|[^\s,&}]+)`)
+)
+
+// String masks card numbers and secret key-value pairs in s.
+func String(s string) string {
+	s = reSecretPair.ReplaceAllString(s, "${1}"+Placeholder)
+
+	return reCardNumber.ReplaceAllString(s, Placeholder)
+}
+
+// JSON replaces values of secret keys in JSON document b.
+// If b is not a valid JSON document it's redacted with String.
+func JSON(b []byte) []byte {
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return []byte(String(string(b)))
+	}
+
+	res, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return []byte(Placeholder)
+	}
+
+	return res
+}
+
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if _, ok := secretKeys[strings.ToLower(k)]; ok {
+				val[k] = Placeholder
+
+				continue
+			}
+
+			val[k] = redactValue(item)
+		}
+
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = redactValue(item)
+		}
+
+		return val
+	case string:
+		return String(val)
+	}
+
+	return v
+}
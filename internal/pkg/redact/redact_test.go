@@ -0,0 +1,50 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		secret string
+	}{
+		{name: "card number", in: "card 4111111111111111 declined", secret: "4111111111111111"},
+		{name: "spaced card number", in: "card 4111 1111 1111 1111", secret: "4111 1111 1111 1111"},
+		{name: "key value", in: "login=bob password=dragon", secret: "dragon"},
+		{name: "json pair", in: `invalid {"cvv": "123"}`, secret: "123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := String(tt.in)
+			assert.NotContains(t, got, tt.secret)
+			assert.Contains(t, got, Placeholder)
+		})
+	}
+
+	assert.Equal(t, "nothing to hide 123", String("nothing to hide 123"))
+}
+
+func TestJSON(t *testing.T) {
+	in := `[{"id":"1","credentials":{"login":"bob","password":"dragon"},` +
+		`"name":"mail","comment":"old card 5500000000000004"},` +
+		`{"id":"2","number":"5500000000000004","cvv_hash":"$argon2id$..."}]`
+
+	got := JSON([]byte(in))
+
+	for _, secret := range []string{"dragon", "5500000000000004", "argon2id"} {
+		assert.False(t, strings.Contains(string(got), secret), "%v is not redacted", secret)
+	}
+
+	var doc []map[string]any
+	require.NoError(t, json.Unmarshal(got, &doc))
+	assert.Equal(t, "bob", doc[0]["credentials"].(map[string]any)["login"])
+	assert.Equal(t, "mail", doc[0]["name"])
+}